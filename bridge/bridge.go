@@ -0,0 +1,344 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Message is a WalletConnect v1 bridge message. Messages with type "sub"
+// subscribe the sender to the topic, every other message with a topic is
+// published to its subscribers as-is.
+type Message struct {
+	Topic   string          `json:"topic"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Silent  bool            `json:"silent,omitempty"`
+}
+
+// Options configures the bridge server
+type Options struct {
+	// MessageTTL is how long messages are kept for offline subscribers
+	MessageTTL time.Duration
+	// MaxQueued limits the messages kept per topic, oldest are dropped first
+	MaxQueued int
+	// CleanupInterval is how often expired messages are dropped
+	CleanupInterval time.Duration
+}
+
+// DefaultOptions returns the options used by the bridge serve command
+func DefaultOptions() Options {
+	return Options{
+		MessageTTL:      24 * time.Hour,
+		MaxQueued:       100,
+		CleanupInterval: time.Minute,
+	}
+}
+
+type queuedMessage struct {
+	sender  *peer
+	data    []byte
+	expires time.Time
+}
+
+// peer is a connected websocket client
+type peer struct {
+	conn *websocket.Conn
+	send chan []byte
+	once sync.Once
+	done chan struct{}
+}
+
+func (p *peer) close() {
+	p.once.Do(func() {
+		close(p.done)
+		p.conn.Close()
+	})
+}
+
+// Server is a WalletConnect v1 pub/sub bridge
+type Server struct {
+	opts     Options
+	upgrader websocket.Upgrader
+
+	mu          sync.Mutex
+	peers       map[*peer]struct{}
+	subscribers map[string]map[*peer]struct{}
+	queued      map[string][]queuedMessage
+}
+
+// NewServer creates a bridge server, zero options fall back to DefaultOptions
+func NewServer(opts Options) *Server {
+	defaults := DefaultOptions()
+	if opts.MessageTTL <= 0 {
+		opts.MessageTTL = defaults.MessageTTL
+	}
+	if opts.MaxQueued <= 0 {
+		opts.MaxQueued = defaults.MaxQueued
+	}
+	if opts.CleanupInterval <= 0 {
+		opts.CleanupInterval = defaults.CleanupInterval
+	}
+
+	return &Server{
+		opts: opts,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin: func(r *http.Request) bool {
+				return true
+			},
+		},
+		peers:       make(map[*peer]struct{}),
+		subscribers: make(map[string]map[*peer]struct{}),
+		queued:      make(map[string][]queuedMessage),
+	}
+}
+
+// ServeHTTP upgrades websocket requests and answers the v1 hello endpoint
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		if r.URL.Path == "/hello" || r.URL.Path == "/" {
+			fmt.Fprintln(w, "Hello World, this is WalletConnect v1 bridge")
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	p := &peer{
+		conn: conn,
+		send: make(chan []byte, 64),
+		done: make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	s.peers[p] = struct{}{}
+	s.mu.Unlock()
+
+	go s.write(p)
+	s.read(p)
+}
+
+// Run drops expired messages until ctx is cancelled
+func (s *Server) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.cleanup(time.Now())
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ListenAndServe serves the bridge on addr until ctx is cancelled
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves the bridge on listener until ctx is cancelled
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{Handler: s}
+
+	go s.Run(ctx)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+		s.closePeers()
+	}()
+
+	err := server.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *Server) read(p *peer) {
+	defer s.disconnect(p)
+
+	for {
+		_, data, err := p.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			log.Printf("bridge: invalid message: %v", err)
+			continue
+		}
+		if msg.Topic == "" {
+			continue
+		}
+
+		switch msg.Type {
+		case "sub":
+			s.subscribe(p, msg.Topic)
+		case "ack":
+		default:
+			s.publish(p, msg.Topic, data)
+		}
+	}
+}
+
+func (s *Server) write(p *peer) {
+	for {
+		select {
+		case data := <-p.send:
+			p.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := p.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				p.close()
+				return
+			}
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// subscribe registers p for topic and flushes messages queued for it
+func (s *Server) subscribe(p *peer, topic string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subscribers[topic] == nil {
+		s.subscribers[topic] = make(map[*peer]struct{})
+	}
+	s.subscribers[topic][p] = struct{}{}
+
+	// messages are never echoed back to the peer which published them
+	now := time.Now()
+	var kept []queuedMessage
+	for _, msg := range s.queued[topic] {
+		switch {
+		case !now.Before(msg.expires):
+		case msg.sender == p:
+			kept = append(kept, msg)
+		default:
+			s.deliver(p, msg.data)
+		}
+	}
+
+	if len(kept) == 0 {
+		delete(s.queued, topic)
+	} else {
+		s.queued[topic] = kept
+	}
+}
+
+// publish delivers data to every subscriber of topic except the sender,
+// queueing it when nobody else is listening
+func (s *Server) publish(sender *peer, topic string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivered := false
+	for p := range s.subscribers[topic] {
+		if p == sender {
+			continue
+		}
+		s.deliver(p, data)
+		delivered = true
+	}
+
+	if delivered {
+		return
+	}
+
+	queue := append(s.queued[topic], queuedMessage{
+		sender:  sender,
+		data:    data,
+		expires: time.Now().Add(s.opts.MessageTTL),
+	})
+	if len(queue) > s.opts.MaxQueued {
+		queue = queue[len(queue)-s.opts.MaxQueued:]
+	}
+	s.queued[topic] = queue
+}
+
+// deliver hands data to the peer writer, dropping slow peers
+func (s *Server) deliver(p *peer, data []byte) {
+	select {
+	case p.send <- data:
+	case <-p.done:
+	default:
+		log.Printf("bridge: dropping slow peer")
+		p.close()
+	}
+}
+
+func (s *Server) disconnect(p *peer) {
+	p.close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.peers, p)
+	for topic, peers := range s.subscribers {
+		delete(peers, p)
+		if len(peers) == 0 {
+			delete(s.subscribers, topic)
+		}
+	}
+}
+
+// closePeers disconnects every websocket client, they are not tracked by http.Server
+func (s *Server) closePeers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for p := range s.peers {
+		p.close()
+	}
+}
+
+func (s *Server) cleanup(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for topic, queue := range s.queued {
+		kept := queue[:0]
+		for _, msg := range queue {
+			if now.Before(msg.expires) {
+				kept = append(kept, msg)
+			}
+		}
+		if len(kept) == 0 {
+			delete(s.queued, topic)
+		} else {
+			s.queued[topic] = kept
+		}
+	}
+}
+
+// Queued returns the number of messages waiting for subscribers on topic
+func (s *Server) Queued(topic string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queued[topic])
+}
@@ -0,0 +1,129 @@
+package bridge
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func dial(t *testing.T, url string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("failed to connect to bridge: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) Message {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var msg Message
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("failed to read message: %v", err)
+	}
+	return msg
+}
+
+func TestServer_PubSub(t *testing.T) {
+	server := httptest.NewServer(NewServer(Options{}))
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	dapp := dial(t, wsURL)
+	wallet := dial(t, wsURL)
+
+	assert.NoError(t, dapp.WriteJSON(Message{Topic: "handshake", Type: "sub"}))
+	assert.NoError(t, wallet.WriteJSON(Message{Topic: "handshake", Type: "sub"}))
+
+	// give the bridge time to register both subscriptions
+	time.Sleep(50 * time.Millisecond)
+
+	assert.NoError(t, dapp.WriteJSON(Message{Topic: "handshake", Type: "pub", Payload: []byte(`"request"`)}))
+	msg := readMessage(t, wallet)
+	assert.Equal(t, "handshake", msg.Topic)
+	assert.Equal(t, "pub", msg.Type)
+	assert.Equal(t, `"request"`, string(msg.Payload))
+
+	// messages are forwarded as-is, including their type
+	assert.NoError(t, wallet.WriteJSON(Message{Topic: "handshake", Type: "personal_sign", Payload: []byte(`"hello"`)}))
+	msg = readMessage(t, dapp)
+	assert.Equal(t, "personal_sign", msg.Type)
+	assert.Equal(t, `"hello"`, string(msg.Payload))
+}
+
+func TestServer_QueuesForOfflinePeers(t *testing.T) {
+	bridge := NewServer(Options{})
+	server := httptest.NewServer(bridge)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	dapp := dial(t, wsURL)
+	assert.NoError(t, dapp.WriteJSON(Message{Topic: "handshake", Type: "sub"}))
+	assert.NoError(t, dapp.WriteJSON(Message{Topic: "handshake", Type: "pub", Payload: []byte(`1`)}))
+	assert.NoError(t, dapp.WriteJSON(Message{Topic: "handshake", Type: "pub", Payload: []byte(`2`)}))
+
+	assert.Eventually(t, func() bool { return bridge.Queued("handshake") == 2 }, time.Second, 10*time.Millisecond)
+
+	wallet := dial(t, wsURL)
+	assert.NoError(t, wallet.WriteJSON(Message{Topic: "handshake", Type: "sub"}))
+
+	assert.Equal(t, `1`, string(readMessage(t, wallet).Payload))
+	assert.Equal(t, `2`, string(readMessage(t, wallet).Payload))
+	assert.Equal(t, 0, bridge.Queued("handshake"))
+}
+
+func TestServer_ExpiresQueuedMessages(t *testing.T) {
+	bridge := NewServer(Options{MessageTTL: 50 * time.Millisecond, MaxQueued: 2})
+	server := httptest.NewServer(bridge)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	dapp := dial(t, wsURL)
+	for _, payload := range []string{`1`, `2`, `3`} {
+		assert.NoError(t, dapp.WriteJSON(Message{Topic: "offline", Type: "pub", Payload: []byte(payload)}))
+	}
+
+	// only the newest messages are kept
+	assert.Eventually(t, func() bool { return bridge.Queued("offline") == 2 }, time.Second, 10*time.Millisecond)
+
+	time.Sleep(100 * time.Millisecond)
+	bridge.cleanup(time.Now())
+	assert.Equal(t, 0, bridge.Queued("offline"))
+}
+
+func TestServer_ServeShutdown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- NewServer(Options{}).Serve(ctx, listener)
+	}()
+
+	conn := dial(t, "ws://"+listener.Addr().String())
+	assert.NoError(t, conn.WriteJSON(Message{Topic: "topic", Type: "sub"}))
+
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for bridge to shut down")
+	}
+
+	// connected peers are disconnected as well
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = conn.ReadMessage()
+	assert.Error(t, err)
+}
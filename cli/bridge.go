@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/galihrivanto/omonOmon/bridge"
+	"github.com/spf13/cobra"
+)
+
+var BridgeCmd = &cobra.Command{
+	Use:   "bridge",
+	Short: "Run a local WalletConnect v1 bridge",
+}
+
+var bridgeServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a WalletConnect v1 bridge",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		addr, _ := cmd.Flags().GetString("addr")
+		ttl, _ := cmd.Flags().GetDuration("ttl")
+		maxQueued, _ := cmd.Flags().GetInt("max-queued")
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		server := bridge.NewServer(bridge.Options{
+			MessageTTL: ttl,
+			MaxQueued:  maxQueued,
+		})
+
+		fmt.Println("Bridge listening on", addr)
+		if err := server.ListenAndServe(ctx, addr); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	defaults := bridge.DefaultOptions()
+
+	bridgeServeCmd.Flags().String("addr", "127.0.0.1:5001", "Listen address")
+	bridgeServeCmd.Flags().Duration("ttl", defaults.MessageTTL, "How long messages are kept for offline peers")
+	bridgeServeCmd.Flags().Int("max-queued", defaults.MaxQueued, "Messages kept per topic for offline peers")
+
+	BridgeCmd.AddCommand(bridgeServeCmd)
+}
@@ -0,0 +1,606 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/galihrivanto/omonOmon/dex"
+	"github.com/galihrivanto/omonOmon/wallet"
+	"github.com/spf13/cobra"
+)
+
+var DexCmd = &cobra.Command{
+	Use:   "dex",
+	Short: "Swap and provide liquidity on DEXes",
+	Long: `Swap and provide liquidity on DEXes.
+
+The DEX contracts of every network are read from --config, for example:
+
+  networks:
+    monad-testnet:
+      wmon: "0x..."
+      tokens:
+        USDC: "0x..."
+      dexes:
+        uniswap:
+          type: v2
+          router: "0x..."
+          factory: "0x..."
+        uniswap-v3:
+          type: v3
+          router: "0x..."
+          factory: "0x..."
+          quoter: "0x..."
+          positionManager: "0x..."
+
+Swaps on v3 DEXes go through the pools of --fee, given once for every hop
+or per hop. Positions of v3 DEXes are NFTs, managed by mint, increase,
+decrease, collect and positions.
+
+Tokens are given by symbol or address, MON being the native token.
+Token approvals are sent when the allowance of the router is too low.`,
+}
+
+var dexQuoteCmd = &cobra.Command{
+	Use:   "quote [amount] [tokenIn] [tokenOut]",
+	Short: "Quote a swap",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, network := dexConfig(cmd)
+		ctx := cmd.Context()
+
+		tokens, err := swapPath(cmd, network, args[1], args[2])
+		if err != nil {
+			log.Fatal(err)
+		}
+		exactOut, _ := cmd.Flags().GetBool("exact-out")
+
+		if config.Type == dex.TypeV3 {
+			if exactOut {
+				log.Fatal("Exact output swaps are only supported on v2 DEXes")
+			}
+			d := dex.NewV3(config, network)
+			amount, err := tokenAmount(ctx, d.Backend, args[0], tokens[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			fees, _ := cmd.Flags().GetIntSlice("fee")
+			amountOut, err := d.QuoteExactIn(ctx, amount, tokens, fees)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			in, out := tokens[0], tokens[len(tokens)-1]
+			fmt.Println(formatAmount(ctx, d.Backend, amount, in), tokenName(network, in))
+			fmt.Println(formatAmount(ctx, d.Backend, amountOut, out), tokenName(network, out))
+			return
+		}
+
+		d := dex.NewV2(config, network)
+		amount, err := tokenAmount(ctx, d.Backend, args[0], amountToken(tokens, exactOut))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var amounts []*big.Int
+		if exactOut {
+			amounts, err = d.QuoteExactOut(ctx, amount, tokens)
+		} else {
+			amounts, err = d.QuoteExactIn(ctx, amount, tokens)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for i, token := range tokens {
+			fmt.Println(formatAmount(ctx, d.Backend, amounts[i], token), tokenName(network, token))
+		}
+	},
+}
+
+var dexSwapCmd = &cobra.Command{
+	Use:   "swap [amount] [tokenIn] [tokenOut]",
+	Short: "Swap tokens",
+	Long: `Swap tokens.
+
+The amount is the input, or with --exact-out the output, of the swap.
+Exact output swaps are only supported on v2 DEXes.`,
+	Args: cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, network := dexConfig(cmd)
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		tokens, err := swapPath(cmd, network, args[1], args[2])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		exactOut, _ := cmd.Flags().GetBool("exact-out")
+		if exactOut && config.Type == dex.TypeV3 {
+			log.Fatal("Exact output swaps are only supported on v2 DEXes")
+		}
+
+		opts := tradeOptions(cmd)
+		w := dexWallet(cmd)
+
+		var swap *dex.Swap
+		var backend wallet.Backend
+		if config.Type == dex.TypeV3 {
+			d := dex.NewV3(config, network)
+			backend = d.Backend
+			amount, err := tokenAmount(ctx, backend, args[0], tokens[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			fees, _ := cmd.Flags().GetIntSlice("fee")
+			if swap, err = d.SwapExactIn(ctx, w, tokens, fees, amount, opts); err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			d := dex.NewV2(config, network)
+			backend = d.Backend
+			amount, err := tokenAmount(ctx, backend, args[0], amountToken(tokens, exactOut))
+			if err != nil {
+				log.Fatal(err)
+			}
+			if exactOut {
+				swap, err = d.SwapExactOut(ctx, w, tokens, amount, opts)
+			} else {
+				swap, err = d.SwapExactIn(ctx, w, tokens, amount, opts)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		in, out := tokens[0], tokens[len(tokens)-1]
+		fmt.Println("Swapped", formatAmount(ctx, backend, swap.AmountIn, in), tokenName(network, in),
+			"for", formatAmount(ctx, backend, swap.AmountOut, out), tokenName(network, out))
+		fmt.Println("Transaction Hash:", swap.Hash)
+	},
+}
+
+var dexAddLiquidityCmd = &cobra.Command{
+	Use:   "add-liquidity [tokenA] [amountA] [tokenB] [amountB]",
+	Short: "Add liquidity to a pair",
+	Args:  cobra.ExactArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		d, network := v2Client(cmd)
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		tokenA, err := network.Token(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		tokenB, err := network.Token(args[2])
+		if err != nil {
+			log.Fatal(err)
+		}
+		amountA, err := tokenAmount(ctx, d.Backend, args[1], tokenA)
+		if err != nil {
+			log.Fatal(err)
+		}
+		amountB, err := tokenAmount(ctx, d.Backend, args[3], tokenB)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		liquidity, err := d.AddLiquidity(ctx, dexWallet(cmd), tokenA, tokenB, amountA, amountB, tradeOptions(cmd))
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Transaction Hash:", liquidity.Hash)
+	},
+}
+
+var dexPairCmd = &cobra.Command{
+	Use:   "pair [tokenA] [tokenB]",
+	Short: "Show the liquidity held in a pair",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		d, network := v2Client(cmd)
+		ctx := cmd.Context()
+
+		tokenA, err := network.Token(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		tokenB, err := network.Token(args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		position, err := d.Position(ctx, dexWallet(cmd).Address, tokenA, tokenB)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Pair:", position.Pair)
+		fmt.Println("Liquidity:", wallet.FormatUnits(position.Liquidity, 18))
+		fmt.Println(formatAmount(ctx, d.Backend, position.AmountA, tokenA), tokenName(network, tokenA))
+		fmt.Println(formatAmount(ctx, d.Backend, position.AmountB, tokenB), tokenName(network, tokenB))
+	},
+}
+
+var dexRemoveLiquidityCmd = &cobra.Command{
+	Use:   "remove-liquidity [tokenA] [tokenB] [liquidity|all]",
+	Short: "Remove liquidity from a pair",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		d, network := v2Client(cmd)
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		tokenA, err := network.Token(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		tokenB, err := network.Token(args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// pair tokens have 18 decimals
+		var liquidity *big.Int
+		if args[2] != "all" {
+			if liquidity, err = wallet.ParseUnits(args[2], 18); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		result, err := d.RemoveLiquidity(ctx, dexWallet(cmd), tokenA, tokenB, liquidity, tradeOptions(cmd))
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Removed", wallet.FormatUnits(result.Liquidity, 18), "liquidity for",
+			formatAmount(ctx, d.Backend, result.AmountA, tokenA), tokenName(network, tokenA), "and",
+			formatAmount(ctx, d.Backend, result.AmountB, tokenB), tokenName(network, tokenB))
+		fmt.Println("Transaction Hash:", result.Hash)
+	},
+}
+
+var dexMintCmd = &cobra.Command{
+	Use:   "mint [tokenA] [amountA] [tokenB] [amountB]",
+	Short: "Open a v3 position",
+	Long: `Open a v3 position.
+
+The position spans --tick-lower to --tick-upper, or the full range of the
+pool when they are left out. Up to both amounts are deposited.`,
+	Args: cobra.ExactArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		d, network := v3Client(cmd)
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		tokenA, err := network.Token(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		tokenB, err := network.Token(args[2])
+		if err != nil {
+			log.Fatal(err)
+		}
+		amountA, err := tokenAmount(ctx, d.Backend, args[1], tokenA)
+		if err != nil {
+			log.Fatal(err)
+		}
+		amountB, err := tokenAmount(ctx, d.Backend, args[3], tokenB)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fee, _ := cmd.Flags().GetInt("fee")
+		tickLower, _ := cmd.Flags().GetInt("tick-lower")
+		tickUpper, _ := cmd.Flags().GetInt("tick-upper")
+		switch {
+		case !cmd.Flags().Changed("tick-lower") && !cmd.Flags().Changed("tick-upper"):
+			if tickLower, tickUpper, err = d.FullRange(ctx, fee); err != nil {
+				log.Fatal(err)
+			}
+		case !cmd.Flags().Changed("tick-lower") || !cmd.Flags().Changed("tick-upper"):
+			log.Fatal("Both --tick-lower and --tick-upper are needed")
+		}
+
+		change, err := d.Mint(ctx, dexWallet(cmd), tokenA, tokenB, fee, tickLower, tickUpper, amountA, amountB, tradeOptions(cmd))
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Liquidity:", change.Liquidity)
+		fmt.Println("Transaction Hash:", change.Hash)
+	},
+}
+
+var dexIncreaseCmd = &cobra.Command{
+	Use:   "increase [positionID] [amount0] [amount1]",
+	Short: "Add liquidity to a v3 position",
+	Long: `Add liquidity to a v3 position.
+
+The amounts are of the tokens of the position, in the order listed by
+positions.`,
+	Args: cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		d, _ := v3Client(cmd)
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		id := positionID(args[0])
+		position, err := d.Position(ctx, id)
+		if err != nil {
+			log.Fatal(err)
+		}
+		amount0, err := tokenAmount(ctx, d.Backend, args[1], position.Token0)
+		if err != nil {
+			log.Fatal(err)
+		}
+		amount1, err := tokenAmount(ctx, d.Backend, args[2], position.Token1)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		change, err := d.IncreaseLiquidity(ctx, dexWallet(cmd), id, amount0, amount1, tradeOptions(cmd))
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Liquidity:", change.Liquidity)
+		fmt.Println("Transaction Hash:", change.Hash)
+	},
+}
+
+var dexDecreaseCmd = &cobra.Command{
+	Use:   "decrease [positionID] [liquidity|all]",
+	Short: "Remove liquidity from a v3 position",
+	Long: `Remove liquidity from a v3 position.
+
+The tokens withdrawn are collected along with the fees earned, unless
+--collect=false leaves them owed to the position.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		d, network := v3Client(cmd)
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		id := positionID(args[0])
+		var liquidity *big.Int
+		if args[1] != "all" {
+			var ok bool
+			if liquidity, ok = new(big.Int).SetString(args[1], 10); !ok || liquidity.Sign() <= 0 {
+				log.Fatal("Invalid liquidity")
+			}
+		}
+
+		w := dexWallet(cmd)
+		opts := tradeOptions(cmd)
+		change, err := d.DecreaseLiquidity(ctx, w, id, liquidity, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		position, err := d.Position(ctx, id)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Removed", change.Liquidity, "liquidity for",
+			formatAmount(ctx, d.Backend, change.Amount0, position.Token0), tokenName(network, position.Token0), "and",
+			formatAmount(ctx, d.Backend, change.Amount1, position.Token1), tokenName(network, position.Token1))
+		fmt.Println("Transaction Hash:", change.Hash)
+
+		if collect, _ := cmd.Flags().GetBool("collect"); collect {
+			hash, err := d.Collect(ctx, w, id, opts)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println("Collect Transaction Hash:", hash)
+		}
+	},
+}
+
+var dexCollectCmd = &cobra.Command{
+	Use:   "collect [positionID]",
+	Short: "Collect the tokens owed to a v3 position",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		d, _ := v3Client(cmd)
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		hash, err := d.Collect(ctx, dexWallet(cmd), positionID(args[0]), tradeOptions(cmd))
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Transaction Hash:", hash)
+	},
+}
+
+var dexPositionsCmd = &cobra.Command{
+	Use:   "positions",
+	Short: "List the v3 positions of the wallet",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		d, network := v3Client(cmd)
+		ctx := cmd.Context()
+
+		positions, err := d.Positions(ctx, dexWallet(cmd).Address)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(positions) == 0 {
+			fmt.Println("No positions")
+			return
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tTOKENS\tFEE\tTICKS\tLIQUIDITY\tOWED")
+		for _, p := range positions {
+			fmt.Fprintf(tw, "%s\t%s/%s\t%g%%\t%d..%d\t%s\t%s %s, %s %s\n",
+				p.ID, tokenName(network, p.Token0), tokenName(network, p.Token1), float64(p.Fee)/10000,
+				p.TickLower, p.TickUpper, p.Liquidity,
+				formatAmount(ctx, d.Backend, p.Owed0, p.Token0), tokenName(network, p.Token0),
+				formatAmount(ctx, d.Backend, p.Owed1, p.Token1), tokenName(network, p.Token1))
+		}
+		tw.Flush()
+	},
+}
+
+func positionID(arg string) *big.Int {
+	id, ok := new(big.Int).SetString(arg, 10)
+	if !ok || id.Sign() < 0 {
+		log.Fatal("Invalid position ID")
+	}
+	return id
+}
+
+// dexConfig reads the config, network and dex flags
+func dexConfig(cmd *cobra.Command) (*dex.DEX, *dex.Network) {
+	configPath, _ := cmd.Flags().GetString("config")
+	networkName, _ := cmd.Flags().GetString("network")
+	dexName, _ := cmd.Flags().GetString("dex")
+
+	config, err := dex.LoadConfig(configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	network, err := config.Network(networkName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	d, err := network.DEX(dexName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return d, network
+}
+
+func v2Client(cmd *cobra.Command) (*dex.V2, *dex.Network) {
+	config, network := dexConfig(cmd)
+	if config.Type != dex.TypeV2 {
+		log.Fatalf("%s needs a v2 DEX, use mint, increase, decrease and collect on v3 DEXes", cmd.Name())
+	}
+	return dex.NewV2(config, network), network
+}
+
+func v3Client(cmd *cobra.Command) (*dex.V3, *dex.Network) {
+	config, network := dexConfig(cmd)
+	if config.Type != dex.TypeV3 {
+		log.Fatalf("%s needs a v3 DEX", cmd.Name())
+	}
+	return dex.NewV3(config, network), network
+}
+
+func dexWallet(cmd *cobra.Command) *wallet.Wallet {
+	walletPath, _ := cmd.Flags().GetString("wallet-path")
+	return wallet.LoadWallet(walletPath)
+}
+
+// tradeOptions reads the slippage, in percent, and deadline flags
+func tradeOptions(cmd *cobra.Command) dex.TradeOptions {
+	slippage, _ := cmd.Flags().GetFloat64("slippage")
+	deadline, _ := cmd.Flags().GetDuration("deadline")
+	if slippage < 0 || slippage >= 100 {
+		log.Fatal("Invalid slippage")
+	}
+
+	// an explicit 0 must not fall back to the default slippage
+	opts := dex.TradeOptions{Slippage: int(math.Round(slippage * 100)), Deadline: deadline}
+	if opts.Slippage == 0 {
+		opts.Slippage = dex.NoSlippage
+	}
+	return opts
+}
+
+// swapPath resolves the tokens of a swap, routed through the --via tokens
+func swapPath(cmd *cobra.Command, network *dex.Network, tokenIn string, tokenOut string) ([]string, error) {
+	via, _ := cmd.Flags().GetStringSlice("via")
+
+	var tokens []string
+	for _, symbol := range append(append([]string{tokenIn}, via...), tokenOut) {
+		token, err := network.Token(symbol)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// amountToken is the token the amount of a swap is given in
+func amountToken(tokens []string, exactOut bool) string {
+	if exactOut {
+		return tokens[len(tokens)-1]
+	}
+	return tokens[0]
+}
+
+// tokenAmount parses an amount in units of token
+func tokenAmount(ctx context.Context, backend wallet.Backend, amount string, token string) (*big.Int, error) {
+	decimals, err := dex.Decimals(ctx, backend, token)
+	if err != nil {
+		return nil, err
+	}
+	value, err := wallet.ParseUnits(amount, decimals)
+	if err != nil {
+		return nil, err
+	}
+	if value.Sign() <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	return value, nil
+}
+
+// formatAmount formats a raw amount of token, as is when its decimals are
+// unknown
+func formatAmount(ctx context.Context, backend wallet.Backend, amount *big.Int, token string) string {
+	decimals, err := dex.Decimals(ctx, backend, token)
+	if err != nil {
+		return amount.String()
+	}
+	return wallet.FormatUnits(amount, decimals)
+}
+
+// tokenName returns the configured symbol of token, or its address
+func tokenName(network *dex.Network, token string) string {
+	for symbol, address := range network.Tokens {
+		if strings.EqualFold(address, token) {
+			return symbol
+		}
+	}
+	return token
+}
+
+func init() {
+	DexCmd.PersistentFlags().String("config", "dex.yaml", "DEX configuration file")
+	DexCmd.PersistentFlags().String("network", dex.DefaultNetwork, "Network of the DEX")
+	DexCmd.PersistentFlags().String("dex", "", "DEX to use, when the network has several")
+	DexCmd.PersistentFlags().StringP("wallet-path", "w", ".wallet", "Wallet path")
+	DexCmd.PersistentFlags().Float64("slippage", 0.5, "Price change tolerated, in percent")
+	DexCmd.PersistentFlags().Duration("deadline", 0, "How long transactions stay valid (default 20m)")
+
+	for _, cmd := range []*cobra.Command{dexQuoteCmd, dexSwapCmd} {
+		cmd.Flags().Bool("exact-out", false, "The amount is the output of the swap")
+		cmd.Flags().StringSlice("via", nil, "Tokens to route the swap through")
+		cmd.Flags().IntSlice("fee", []int{3000}, "Pool fee of v3 swaps, in hundredths of a basis point, once or per hop")
+	}
+	dexMintCmd.Flags().Int("fee", 3000, "Pool fee, in hundredths of a basis point")
+	dexMintCmd.Flags().Int("tick-lower", 0, "Lower tick of the position (default full range)")
+	dexMintCmd.Flags().Int("tick-upper", 0, "Upper tick of the position (default full range)")
+	dexDecreaseCmd.Flags().Bool("collect", true, "Collect the tokens withdrawn and the fees earned")
+
+	DexCmd.AddCommand(dexQuoteCmd)
+	DexCmd.AddCommand(dexSwapCmd)
+	DexCmd.AddCommand(dexAddLiquidityCmd)
+	DexCmd.AddCommand(dexPairCmd)
+	DexCmd.AddCommand(dexRemoveLiquidityCmd)
+	DexCmd.AddCommand(dexMintCmd)
+	DexCmd.AddCommand(dexIncreaseCmd)
+	DexCmd.AddCommand(dexDecreaseCmd)
+	DexCmd.AddCommand(dexCollectCmd)
+	DexCmd.AddCommand(dexPositionsCmd)
+}
@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/galihrivanto/omonOmon/scenario"
+	"github.com/galihrivanto/omonOmon/wallet"
+	"github.com/spf13/cobra"
+)
+
+var RunCmd = &cobra.Command{
+	Use:   "run [scenarioFile]",
+	Short: "Run a scenario of wallet, faucet and dApp steps",
+	Long: `Run a scenario of wallet, faucet and dApp steps.
+
+The scenario file lists the steps run in order, for example:
+
+  vars:
+    recipient: "0x..."
+  retries: 2
+  steps:
+    - action: faucet
+      faucet: default
+    - action: send
+      to: "{{.recipient}}"
+      amount: "0.01"
+      output: payment
+    - action: balance
+      min: "0.5"
+
+Faucet steps use the faucet flags, browser steps the browser flags.
+Signing and transaction requests of the dApps opened by browser steps
+are decided by --policy, those it leaves open are asked for here. Without
+--policy all of them are asked for here.
+
+With --accounts the scenario runs for every wallet of a file of private
+keys, one per line, or of a directory of wallet files, --workers at a
+time, each starting after a random delay of up to --max-start-delay once
+a worker is free.`,
+	Args: cobra.ExactArgs(1),
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		loadRecipes(cmd)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := scenario.Load(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		runner, err := scenarioRunner(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		reportPath, _ := cmd.Flags().GetString("report")
+
+		if accountsPath, _ := cmd.Flags().GetString("accounts"); accountsPath != "" {
+			wallets, err := wallet.LoadWallets(accountsPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			for _, w := range wallets {
+				if w.PrivateKey == "" {
+					log.Fatalf("%s: a private key is needed for %s", accountsPath, w.Address)
+				}
+			}
+
+			var opts scenario.BatchOptions
+			opts.Workers, _ = cmd.Flags().GetInt("workers")
+			opts.MaxStartDelay, _ = cmd.Flags().GetDuration("max-start-delay")
+			opts.OnReport = func(report *scenario.Report) {
+				status := "passed"
+				if !report.Passed {
+					status = "failed"
+				}
+				fmt.Println("Finished", report.Address, status)
+			}
+
+			fmt.Println("Running scenario", s.Name, "for", len(wallets), "accounts")
+			summary, err := runner.RunBatch(ctx, s, wallets, opts)
+			if err != nil {
+				log.Fatal(err)
+			}
+			summary.Print(os.Stdout)
+
+			if reportPath != "" {
+				if err := writeReport(reportPath, summary); err != nil {
+					log.Fatal(err)
+				}
+			}
+			if summary.Failed > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+
+		walletPath, _ := cmd.Flags().GetString("wallet-path")
+		w := wallet.LoadWallet(walletPath)
+
+		fmt.Println("Running scenario", s.Name, "with wallet", w.Address)
+		report := runner.Run(ctx, s, w)
+		report.Print(os.Stdout)
+
+		if reportPath != "" {
+			if err := writeReport(reportPath, report); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if !report.Passed {
+			os.Exit(1)
+		}
+	},
+}
+
+// scenarioRunner reads the variable, timing, approval, faucet and browser
+// flags
+func scenarioRunner(cmd *cobra.Command) (*scenario.Runner, error) {
+	claim, err := claimOptions(cmd)
+	if err != nil {
+		return nil, err
+	}
+	runner := &scenario.Runner{Claim: claim, Browser: claim.Browser, Vars: make(map[string]string)}
+	runner.MinStepDelay, _ = cmd.Flags().GetDuration("min-step-delay")
+	runner.MaxStepDelay, _ = cmd.Flags().GetDuration("max-step-delay")
+
+	vars, _ := cmd.Flags().GetStringArray("var")
+	for _, v := range vars {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid variable %q, expected name=value", v)
+		}
+		runner.Vars[name] = value
+	}
+
+	var policy *wallet.Policy
+	if policyPath, _ := cmd.Flags().GetString("policy"); policyPath != "" {
+		policy, err = wallet.LoadPolicy(policyPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// one approver for all accounts, so prompts are asked one at a time,
+	// a nil policy asks about every request
+	runner.Approve = wallet.PolicyApprover(policy, wallet.NewPromptApprover(os.Stdin, os.Stdout), os.Stdout)
+
+	return runner, nil
+}
+
+// writeReport saves a report as indented JSON
+func writeReport(path string, report interface{}) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write report: %v", err)
+	}
+	return nil
+}
+
+func init() {
+	RunCmd.Flags().StringP("wallet-path", "w", ".wallet", "Wallet path")
+	RunCmd.Flags().StringArray("var", nil, "Set a scenario variable, as name=value")
+	RunCmd.Flags().String("policy", "", "Approval policy file for the dApp requests of browser steps")
+	RunCmd.Flags().String("report", "", "Write the report as JSON to this file")
+	RunCmd.Flags().String("recipes", "faucets", "Directory of faucet recipes")
+	RunCmd.Flags().String("accounts", "", "File or directory of private keys to run the scenario for, instead of --wallet-path")
+	RunCmd.Flags().Int("workers", 1, "Number of accounts running the scenario at once")
+	RunCmd.Flags().Duration("max-start-delay", 0, "Maximum random delay before an account starts")
+	RunCmd.Flags().Duration("min-step-delay", 0, "Minimum random delay between steps")
+	RunCmd.Flags().Duration("max-step-delay", 0, "Maximum random delay between steps")
+	addBrowserFlags(RunCmd.Flags())
+	addClaimFlags(RunCmd)
+}
@@ -0,0 +1,245 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/galihrivanto/omonOmon/stake"
+	"github.com/galihrivanto/omonOmon/wallet"
+	"github.com/spf13/cobra"
+)
+
+var StakeCmd = &cobra.Command{
+	Use:   "stake",
+	Short: "Stake MON with liquid staking protocols",
+	Long: `Stake MON with liquid staking protocols.
+
+The protocols of every network are read from --config. Each operation
+names a method of the contract ABI and its arguments, for example:
+
+  networks:
+    monad-testnet:
+      protocols:
+        apriori:
+          address: "0x..."
+          abi: aprmon.json
+          symbol: aprMON
+          deposit: {method: deposit, args: [amount, address]}
+          balance: {method: balanceOf, args: [address]}
+          rate: {method: convertToAssets, args: [shares]}
+          withdraw: {method: requestRedeem, args: [shares, address, address]}
+          requests: {method: getUserRequests, args: [address]}
+          claim: {method: redeem, args: [ids, address]}
+
+The abi is a JSON ABI or the path of a file holding one, relative to the
+config. The arguments amount, shares, address, id and ids stand for the
+values of the operation, others are literals. Requests may return request
+ids, detailed by a request operation taking an id, or a list of tuples.
+Claim takes ids, all at once, or an id, one transaction each.`,
+}
+
+var stakeDepositCmd = &cobra.Command{
+	Use:   "deposit [amount]",
+	Short: "Stake MON",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		staker := stakeClient(cmd)
+
+		amount, err := wallet.ParseMON(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		hash, err := staker.Deposit(ctx, stakeWallet(cmd), amount)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Staked", wallet.FormatUnits(amount, 18), "MON")
+		fmt.Println("Transaction Hash:", hash)
+	},
+}
+
+var stakeBalanceCmd = &cobra.Command{
+	Use:   "balance",
+	Short: "Show the shares held and their value",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		staker := stakeClient(cmd)
+		ctx := context.Background()
+
+		shares, err := staker.Shares(ctx, stakeWallet(cmd).Address)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Balance:", wallet.FormatUnits(shares, 18), staker.Protocol.Symbol)
+
+		if staker.Protocol.Rate == nil {
+			return
+		}
+		rate, err := staker.Rate(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Value:", wallet.FormatUnits(stake.Value(shares, rate), 18), "MON")
+	},
+}
+
+var stakeRateCmd = &cobra.Command{
+	Use:   "rate",
+	Short: "Show the exchange rate of the shares",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		staker := stakeClient(cmd)
+
+		rate, err := staker.Rate(context.Background())
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("1", staker.Protocol.Symbol, "=", wallet.FormatUnits(rate, 18), "MON")
+	},
+}
+
+var stakeWithdrawCmd = &cobra.Command{
+	Use:   "withdraw [shares|all]",
+	Short: "Request the withdrawal of shares",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		staker := stakeClient(cmd)
+		w := stakeWallet(cmd)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		var shares *big.Int
+		var err error
+		if args[0] == "all" {
+			shares, err = staker.Shares(ctx, w.Address)
+		} else {
+			shares, err = wallet.ParseUnits(args[0], 18)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if shares.Sign() == 0 {
+			log.Fatal("No shares to withdraw")
+		}
+
+		hash, err := staker.RequestWithdraw(ctx, w, shares)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Requested the withdrawal of", wallet.FormatUnits(shares, 18), staker.Protocol.Symbol)
+		fmt.Println("Transaction Hash:", hash)
+	},
+}
+
+var stakeRequestsCmd = &cobra.Command{
+	Use:   "requests",
+	Short: "List the pending withdrawal requests",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		staker := stakeClient(cmd)
+
+		requests, err := staker.Requests(context.Background(), stakeWallet(cmd).Address)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(requests) == 0 {
+			fmt.Println("No pending requests")
+			return
+		}
+
+		for _, request := range requests {
+			fields := make([]string, len(request.Fields))
+			for i, field := range request.Fields {
+				fields[i] = field.Name + "=" + field.Value
+			}
+			if request.ID != nil {
+				fmt.Printf("Request %s: %s\n", request.ID, strings.Join(fields, " "))
+			} else {
+				fmt.Println("Request:", strings.Join(fields, " "))
+			}
+		}
+	},
+}
+
+var stakeClaimCmd = &cobra.Command{
+	Use:   "claim [requestID...]",
+	Short: "Claim withdrawal requests",
+	Long: `Claim withdrawal requests.
+
+The ids are listed by requests. They are not needed by protocols claiming
+every ready request at once.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		staker := stakeClient(cmd)
+
+		ids := make([]*big.Int, len(args))
+		for i, arg := range args {
+			id, ok := new(big.Int).SetString(arg, 10)
+			if !ok || id.Sign() < 0 {
+				log.Fatal("Invalid request ID: ", arg)
+			}
+			ids[i] = id
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		hashes, err := staker.Claim(ctx, stakeWallet(cmd), ids)
+		for _, hash := range hashes {
+			fmt.Println("Transaction Hash:", hash)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// stakeClient reads the config, network and protocol flags
+func stakeClient(cmd *cobra.Command) *stake.Staker {
+	configPath, _ := cmd.Flags().GetString("config")
+	networkName, _ := cmd.Flags().GetString("network")
+	protocolName, _ := cmd.Flags().GetString("protocol")
+
+	config, err := stake.LoadConfig(configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	network, err := config.Network(networkName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	protocol, err := network.Protocol(protocolName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return stake.NewStaker(protocol)
+}
+
+func stakeWallet(cmd *cobra.Command) *wallet.Wallet {
+	walletPath, _ := cmd.Flags().GetString("wallet-path")
+	return wallet.LoadWallet(walletPath)
+}
+
+func init() {
+	StakeCmd.PersistentFlags().String("config", "stake.yaml", "Staking configuration file")
+	StakeCmd.PersistentFlags().String("network", stake.DefaultNetwork, "Network of the protocol")
+	StakeCmd.PersistentFlags().String("protocol", "", "Protocol to use, when the network has several")
+	StakeCmd.PersistentFlags().StringP("wallet-path", "w", ".wallet", "Wallet path")
+
+	StakeCmd.AddCommand(stakeDepositCmd)
+	StakeCmd.AddCommand(stakeBalanceCmd)
+	StakeCmd.AddCommand(stakeRateCmd)
+	StakeCmd.AddCommand(stakeWithdrawCmd)
+	StakeCmd.AddCommand(stakeRequestsCmd)
+	StakeCmd.AddCommand(stakeClaimCmd)
+}
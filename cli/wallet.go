@@ -1,10 +1,18 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"math/big"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 
+	"github.com/galihrivanto/omonOmon/dapp"
+	"github.com/galihrivanto/omonOmon/dex"
 	"github.com/galihrivanto/omonOmon/wallet"
 	"github.com/spf13/cobra"
 )
@@ -67,24 +75,286 @@ var sendCmd = &cobra.Command{
 	},
 }
 
+var wrapCmd = &cobra.Command{
+	Use:   "wrap [amount]",
+	Short: "Wrap MON into WMON",
+	Long: `Wrap MON into WMON.
+
+The WMON contract is the wmon of the network in the DEX config, or --wmon.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWMON(cmd, args[0], (*wallet.Wallet).Wrap, "Wrapped")
+	},
+}
+
+var unwrapCmd = &cobra.Command{
+	Use:   "unwrap [amount]",
+	Short: "Unwrap WMON into MON",
+	Long: `Unwrap WMON into MON.
+
+The WMON contract is the wmon of the network in the DEX config, or --wmon.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWMON(cmd, args[0], (*wallet.Wallet).Unwrap, "Unwrapped")
+	},
+}
+
+// runWMON wraps or unwraps amount with the WMON contract of the network
+func runWMON(cmd *cobra.Command, amount string, transfer func(*wallet.Wallet, context.Context, string, *big.Int) (string, error), done string) {
+	walletPath, _ := cmd.Flags().GetString("wallet-path")
+
+	value, err := wallet.ParseMON(amount)
+	if err != nil {
+		log.Fatal(err)
+	}
+	token, err := wmonAddress(cmd)
+	if err != nil {
+		log.Fatal(err)
+	}
+	w := wallet.LoadWallet(walletPath)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	txHash, err := transfer(w, ctx, token, value)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(done, wallet.FormatUnits(value, 18), "MON")
+	fmt.Println("Transaction Hash:", txHash)
+}
+
+// wmonAddress returns --wmon, or the wmon of --network in --config
+func wmonAddress(cmd *cobra.Command) (string, error) {
+	if address, _ := cmd.Flags().GetString("wmon"); address != "" {
+		return address, nil
+	}
+
+	configPath, _ := cmd.Flags().GetString("config")
+	networkName, _ := cmd.Flags().GetString("network")
+
+	config, err := dex.LoadConfig(configPath)
+	if err != nil {
+		return "", err
+	}
+	network, err := config.Network(networkName)
+	if err != nil {
+		return "", err
+	}
+	if network.WMON == "" {
+		return "", fmt.Errorf("network %s has no wmon address, set it in %s or use --wmon", networkName, configPath)
+	}
+	return network.WMON, nil
+}
+
 var walletConnectCmd = &cobra.Command{
-	Use:   "wallet-connect [walletConnectURI]",
+	Use:   "wallet-connect [walletConnectURI|qrImage]",
 	Short: "Connect to a wallet using WalletConnect",
-	Args:  cobra.ExactArgs(1),
+	Long: `Connect to a wallet using WalletConnect.
+
+The URI can be given as is or as a PNG/JPEG image of its QR code,
+such as a screenshot of the dApp modal.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		walletPath, _ := cmd.Flags().GetString("wallet-path")
 
+		uri, err := wallet.ResolveWalletConnectURI(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		opts := connectOptions(cmd)
+
 		w := wallet.LoadWallet(walletPath)
-		if err := w.WalletConnect(args[0]); err != nil {
+		if err := w.WalletConnectWithOptions(uri, opts); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var walletConnectDaemonCmd = &cobra.Command{
+	Use:   "wallet-connect-daemon [sessionsFile]",
+	Short: "Serve many WalletConnect sessions at once",
+	Long: `Serve many WalletConnect sessions at once.
+
+The sessions file is a JSON array of {"wallet": "path", "uri": "wc:..."}
+entries. Entries without a wallet use --wallet-path. The uri may also be
+the path of a QR code image.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		walletPath, _ := cmd.Flags().GetString("wallet-path")
+
+		data, err := os.ReadFile(args[0])
+		if err != nil {
 			log.Fatal(err)
 		}
+
+		var entries []struct {
+			Wallet string `json:"wallet"`
+			URI    string `json:"uri"`
+		}
+		if err := json.Unmarshal(data, &entries); err != nil {
+			log.Fatal("invalid sessions file: ", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		queue := wallet.NewApprovalQueue(len(entries))
+		go queue.Run(ctx, wallet.NewPromptApprover(os.Stdin, os.Stdout))
+
+		daemon := wallet.NewDaemon(queue, connectOptions(cmd), os.Stdout)
+		wallets := make(map[string]*wallet.Wallet)
+		for _, entry := range entries {
+			path := entry.Wallet
+			if path == "" {
+				path = walletPath
+			}
+			w, ok := wallets[path]
+			if !ok {
+				w = wallet.LoadWallet(path)
+				wallets[path] = w
+			}
+
+			uri, err := wallet.ResolveWalletConnectURI(entry.URI)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+
+			session, err := daemon.Start(ctx, w, uri)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			fmt.Println("Started session", session.ID, "for", w.Address)
+
+			go func() {
+				<-session.Done()
+				if err := session.Err(); err != nil {
+					log.Printf("session %s ended: %v", session.ID, err)
+				}
+			}()
+		}
+
+		daemon.Wait()
 	},
 }
 
+var browseCmd = &cobra.Command{
+	Use:   "browse [url]",
+	Short: "Open a dApp in a browser using the wallet",
+	Long: `Open a dApp in a browser using the wallet.
+
+The page gets the wallet as window.ethereum, no extension needed. Signing
+and transaction requests are decided by --policy, or asked for here. The
+browser and its window are configured like the faucet ones.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		walletPath, _ := cmd.Flags().GetString("wallet-path")
+
+		opts, err := browserOptions(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !cmd.Flags().Changed("headful") {
+			opts.Headful = true
+		}
+
+		var policy *wallet.Policy
+		if policyPath, _ := cmd.Flags().GetString("policy"); policyPath != "" {
+			policy, err = wallet.LoadPolicy(policyPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		browser, err := opts.Launch(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer browser.Close()
+
+		w := wallet.LoadWallet(walletPath)
+		approve := wallet.PolicyApprover(policy, wallet.NewPromptApprover(os.Stdin, os.Stdout), os.Stdout)
+		session := dapp.NewSession(browser.Browser, wallet.NewProvider(w, approve))
+		defer session.Close()
+
+		if _, err := session.Open(ctx, args[0]); err != nil {
+			log.Println(err)
+			return
+		}
+		fmt.Println("Opened", args[0], "with wallet", w.Address, "- press Ctrl-C to quit")
+
+		<-ctx.Done()
+	},
+}
+
+var qrCmd = &cobra.Command{
+	Use:   "qr [walletConnectURI|qrImage]",
+	Short: "Show a WalletConnect URI as a terminal QR code",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		uri, err := wallet.ResolveWalletConnectURI(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := wallet.RenderQR(os.Stdout, uri); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(uri)
+	},
+}
+
+// connectOptions loads the approval policy given by --policy and the
+// domain list given by --domains
+func connectOptions(cmd *cobra.Command) wallet.ConnectOptions {
+	var opts wallet.ConnectOptions
+
+	if policyPath, _ := cmd.Flags().GetString("policy"); policyPath != "" {
+		policy, err := wallet.LoadPolicy(policyPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Policy = policy
+	}
+
+	if domainsPath, _ := cmd.Flags().GetString("domains"); domainsPath != "" {
+		domains, err := wallet.LoadDomainList(domainsPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Validator = wallet.NewPeerValidator(domains)
+	}
+
+	return opts
+}
+
 func init() {
 	WalletCmd.PersistentFlags().StringP("wallet-path", "w", ".wallet", "Wallet path")
+	walletConnectCmd.Flags().String("policy", "", "Approval policy file")
+	walletConnectDaemonCmd.Flags().String("policy", "", "Approval policy file")
+	walletConnectCmd.Flags().String("domains", "", "dApp domain allowlist/denylist file")
+	browseCmd.Flags().String("policy", "", "Approval policy file")
+	addBrowserFlags(browseCmd.Flags())
+	walletConnectDaemonCmd.Flags().String("domains", "", "dApp domain allowlist/denylist file")
+	for _, cmd := range []*cobra.Command{wrapCmd, unwrapCmd} {
+		cmd.Flags().String("config", "dex.yaml", "DEX configuration file holding the wmon address of the network")
+		cmd.Flags().String("network", dex.DefaultNetwork, "Network of the WMON contract")
+		cmd.Flags().String("wmon", "", "WMON contract address, instead of the one of the config")
+	}
+
 	WalletCmd.AddCommand(generateCmd)
 	WalletCmd.AddCommand(balanceCmd)
 	WalletCmd.AddCommand(sendCmd)
+	WalletCmd.AddCommand(wrapCmd)
+	WalletCmd.AddCommand(unwrapCmd)
 	WalletCmd.AddCommand(walletConnectCmd)
+	WalletCmd.AddCommand(walletConnectDaemonCmd)
+	WalletCmd.AddCommand(qrCmd)
+	WalletCmd.AddCommand(browseCmd)
 }
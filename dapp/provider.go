@@ -0,0 +1,171 @@
+package dapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/galihrivanto/omonOmon/wallet"
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// binding is the page function sending requests to Go
+const binding = "__omonOmonRequest"
+
+// providerJS defines window.ethereum on every document, forwarding its
+// requests through the binding, and announces it to EIP-6963 aware dApps
+const providerJS = `(() => {
+	if (window.ethereum && window.ethereum.isOmonOmon) return;
+
+	const send = window.__omonOmonRequest;
+	const pending = new Map();
+	let nextId = 0;
+	window.__omonOmonResolve = (id, res) => {
+		const callbacks = pending.get(id);
+		pending.delete(id);
+		if (callbacks) callbacks(res);
+	};
+
+	const listeners = {};
+	const emit = (event, ...args) => (listeners[event] || []).forEach((fn) => fn(...args));
+
+	const request = ({method, params}) => new Promise((resolve, reject) => {
+		const id = ++nextId;
+		pending.set(id, (res) => {
+			if (res.error) {
+				const err = new Error(res.error.message);
+				err.code = res.error.code;
+				reject(err);
+				return;
+			}
+			if (method === 'eth_requestAccounts') {
+				emit('connect', {chainId: '0x335f9'});
+				emit('accountsChanged', res.result);
+			}
+			resolve(res.result);
+		});
+		send(JSON.stringify({id, method, params: params === undefined ? [] : params}));
+	});
+
+	const provider = {
+		isOmonOmon: true,
+		isMetaMask: true,
+		chainId: '0x335f9',
+		request,
+		enable: () => request({method: 'eth_requestAccounts'}),
+		isConnected: () => true,
+		on(event, fn) {
+			(listeners[event] = listeners[event] || []).push(fn);
+			return provider;
+		},
+		removeListener(event, fn) {
+			listeners[event] = (listeners[event] || []).filter((f) => f !== fn);
+			return provider;
+		},
+		sendAsync(payload, callback) {
+			request(payload).then(
+				(result) => callback(null, {id: payload.id, jsonrpc: '2.0', result}),
+				(error) => callback(error),
+			);
+		},
+	};
+	provider.send = provider.sendAsync;
+
+	window.ethereum = provider;
+	window.dispatchEvent(new Event('ethereum#initialized'));
+
+	const info = {
+		uuid: '0d1c8c2e-5a39-4c6e-9c39-6f6d6f6e4f6d',
+		name: 'omonOmon',
+		icon: 'data:image/svg+xml,<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 1 1"><rect width="1" height="1" fill="%23836ef9"/></svg>',
+		rdns: 'xyz.omonomon',
+	};
+	const announce = () => window.dispatchEvent(new CustomEvent('eip6963:announceProvider', {
+		detail: Object.freeze({info, provider}),
+	}));
+	window.addEventListener('eip6963:requestProvider', announce);
+	announce();
+})()`
+
+// call is a request() call sent through the binding
+type call struct {
+	ID int64 `json:"id"`
+	wallet.ProviderRequest
+}
+
+// response is what the page receives for a call
+type response struct {
+	Result interface{}           `json:"result,omitempty"`
+	Error  *wallet.ProviderError `json:"error,omitempty"`
+}
+
+// Inject gives every document of page, frames included, a window.ethereum
+// backed by provider. It has to run before navigating so the provider
+// exists when the dApp loads. Requests are served until page's context
+// is done.
+//
+// The origin of a request is the one the browser gives the calling
+// execution context, scripts can not claim another.
+func Inject(page *rod.Page, provider *wallet.Provider) error {
+	if err := (proto.RuntimeAddBinding{Name: binding}).Call(page); err != nil {
+		return fmt.Errorf("failed to expose wallet: %v", err)
+	}
+	if _, err := page.EvalOnNewDocument(providerJS); err != nil {
+		return fmt.Errorf("failed to inject wallet provider: %v", err)
+	}
+
+	// events are handled in order, a context is known before it calls
+	origins := map[proto.RuntimeExecutionContextID]string{}
+
+	ctx := page.GetContext()
+	go page.EachEvent(func(e *proto.RuntimeExecutionContextCreated) {
+		origins[e.Context.ID] = e.Context.Origin
+	}, func(e *proto.RuntimeExecutionContextDestroyed) {
+		delete(origins, e.ExecutionContextID)
+	}, func(e *proto.RuntimeExecutionContextsCleared) {
+		clear(origins)
+	}, func(e *proto.RuntimeBindingCalled) {
+		if e.Name != binding {
+			return
+		}
+
+		var c call
+		if err := json.Unmarshal([]byte(e.Payload), &c); err != nil {
+			return
+		}
+		c.Origin = origins[e.ExecutionContextID]
+
+		// requests waiting for approval must not hold up the others
+		go func() {
+			var res response
+			result, err := provider.Request(ctx, c.ProviderRequest)
+			if err != nil {
+				res.Error = asProviderError(err)
+			} else {
+				res.Result = result
+			}
+
+			data, err := json.Marshal(res)
+			if err != nil {
+				data, _ = json.Marshal(response{Error: asProviderError(err)})
+			}
+
+			proto.RuntimeEvaluate{
+				Expression: fmt.Sprintf("window.__omonOmonResolve(%d, %s)", c.ID, data),
+				ContextID:  e.ExecutionContextID,
+			}.Call(page)
+		}()
+	})()
+
+	return nil
+}
+
+// asProviderError turns err into the error handed to the page
+func asProviderError(err error) *wallet.ProviderError {
+	var providerErr *wallet.ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr
+	}
+	return &wallet.ProviderError{Code: wallet.ProviderInternal, Message: err.Error()}
+}
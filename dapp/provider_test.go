@@ -0,0 +1,42 @@
+package dapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/galihrivanto/omonOmon/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallPayload(t *testing.T) {
+	var c call
+	err := json.Unmarshal([]byte(`{"id":3,"method":"personal_sign","params":["hi","0x1"],"origin":"https://app.example"}`), &c)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), c.ID)
+	assert.Equal(t, "personal_sign", c.Method)
+	assert.Equal(t, json.RawMessage(`["hi","0x1"]`), c.Params)
+	// pages can not choose the origin of their requests
+	assert.Empty(t, c.Origin)
+}
+
+func TestResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response response
+		expected string
+	}{
+		{"result", response{Result: []string{"0x1"}}, `{"result":["0x1"]}`},
+		{"provider error", response{Error: asProviderError(fmt.Errorf("wrapped: %w", &wallet.ProviderError{Code: wallet.ProviderUserRejected, Message: "rejected"}))}, `{"error":{"code":4001,"message":"rejected"}}`},
+		{"other error", response{Error: asProviderError(errors.New("node down"))}, `{"error":{"code":-32603,"message":"node down"}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := json.Marshal(test.response)
+			assert.NoError(t, err)
+			assert.JSONEq(t, test.expected, string(data))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package dapp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/galihrivanto/omonOmon/wallet"
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/stealth"
+)
+
+const pageTimeout = 30 * time.Second
+
+// Session drives dApps in a browser without a wallet extension, every
+// page it opens gets the wallet as window.ethereum. The pages share the
+// provider, so a dApp connected in one page is connected in all.
+type Session struct {
+	browser  *rod.Browser
+	provider *wallet.Provider
+
+	mu    sync.Mutex
+	pages []*rod.Page
+}
+
+// NewSession creates a session on an already connected browser
+func NewSession(browser *rod.Browser, provider *wallet.Provider) *Session {
+	return &Session{browser: browser, provider: provider}
+}
+
+// Provider returns the provider backing the pages
+func (s *Session) Provider() *wallet.Provider {
+	return s.provider
+}
+
+// Open opens url in a new page with the wallet injected and waits for
+// it to load. The wallet serves the page until ctx is done.
+func (s *Session) Open(ctx context.Context, url string) (*rod.Page, error) {
+	page, err := stealth.Page(s.browser.Context(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open page: %v", err)
+	}
+
+	s.mu.Lock()
+	s.pages = append(s.pages, page.Context(context.Background()))
+	s.mu.Unlock()
+
+	if err := Inject(page, s.provider); err != nil {
+		return nil, err
+	}
+
+	if err := page.Timeout(pageTimeout).Navigate(url); err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", url, err)
+	}
+	if err := page.Timeout(pageTimeout).WaitLoad(); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %v", url, err)
+	}
+
+	return page, nil
+}
+
+// Connect clicks the element matching selector, usually a "Connect
+// wallet" button, and waits for the dApp to request the accounts
+func (s *Session) Connect(ctx context.Context, page *rod.Page, selector string) error {
+	page = page.Context(ctx).Timeout(pageTimeout)
+	defer page.CancelTimeout()
+
+	el, err := page.Element(selector)
+	if err != nil {
+		return fmt.Errorf("failed to find %s: %v", selector, err)
+	}
+	if err := el.Click(proto.InputMouseButtonLeft, 1); err != nil {
+		return fmt.Errorf("failed to click %s: %v", selector, err)
+	}
+
+	for {
+		accounts, err := s.provider.Request(ctx, wallet.ProviderRequest{Method: "eth_accounts"})
+		if err == nil && len(accounts.([]string)) > 0 {
+			return nil
+		}
+
+		select {
+		case <-page.GetContext().Done():
+			return fmt.Errorf("dApp did not connect: %v", page.GetContext().Err())
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// Close closes the pages opened by the session and the provider RPC
+// connection, the browser is left to its owner
+func (s *Session) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, page := range s.pages {
+		page.Timeout(10 * time.Second).Close()
+	}
+	s.pages = nil
+	s.provider.Close()
+}
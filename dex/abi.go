@@ -0,0 +1,146 @@
+package dex
+
+import (
+	"github.com/galihrivanto/omonOmon/wallet"
+)
+
+// the parts of the contract ABIs used here
+
+const v2RouterJSON = `[
+	{"name": "getAmountsOut", "type": "function", "stateMutability": "view",
+		"inputs": [{"name": "amountIn", "type": "uint256"}, {"name": "path", "type": "address[]"}],
+		"outputs": [{"name": "amounts", "type": "uint256[]"}]},
+	{"name": "getAmountsIn", "type": "function", "stateMutability": "view",
+		"inputs": [{"name": "amountOut", "type": "uint256"}, {"name": "path", "type": "address[]"}],
+		"outputs": [{"name": "amounts", "type": "uint256[]"}]},
+	{"name": "swapExactTokensForTokens", "type": "function", "stateMutability": "nonpayable",
+		"inputs": [{"name": "amountIn", "type": "uint256"}, {"name": "amountOutMin", "type": "uint256"}, {"name": "path", "type": "address[]"}, {"name": "to", "type": "address"}, {"name": "deadline", "type": "uint256"}],
+		"outputs": [{"name": "amounts", "type": "uint256[]"}]},
+	{"name": "swapTokensForExactTokens", "type": "function", "stateMutability": "nonpayable",
+		"inputs": [{"name": "amountOut", "type": "uint256"}, {"name": "amountInMax", "type": "uint256"}, {"name": "path", "type": "address[]"}, {"name": "to", "type": "address"}, {"name": "deadline", "type": "uint256"}],
+		"outputs": [{"name": "amounts", "type": "uint256[]"}]},
+	{"name": "swapExactETHForTokens", "type": "function", "stateMutability": "payable",
+		"inputs": [{"name": "amountOutMin", "type": "uint256"}, {"name": "path", "type": "address[]"}, {"name": "to", "type": "address"}, {"name": "deadline", "type": "uint256"}],
+		"outputs": [{"name": "amounts", "type": "uint256[]"}]},
+	{"name": "swapETHForExactTokens", "type": "function", "stateMutability": "payable",
+		"inputs": [{"name": "amountOut", "type": "uint256"}, {"name": "path", "type": "address[]"}, {"name": "to", "type": "address"}, {"name": "deadline", "type": "uint256"}],
+		"outputs": [{"name": "amounts", "type": "uint256[]"}]},
+	{"name": "swapExactTokensForETH", "type": "function", "stateMutability": "nonpayable",
+		"inputs": [{"name": "amountIn", "type": "uint256"}, {"name": "amountOutMin", "type": "uint256"}, {"name": "path", "type": "address[]"}, {"name": "to", "type": "address"}, {"name": "deadline", "type": "uint256"}],
+		"outputs": [{"name": "amounts", "type": "uint256[]"}]},
+	{"name": "swapTokensForExactETH", "type": "function", "stateMutability": "nonpayable",
+		"inputs": [{"name": "amountOut", "type": "uint256"}, {"name": "amountInMax", "type": "uint256"}, {"name": "path", "type": "address[]"}, {"name": "to", "type": "address"}, {"name": "deadline", "type": "uint256"}],
+		"outputs": [{"name": "amounts", "type": "uint256[]"}]},
+	{"name": "addLiquidity", "type": "function", "stateMutability": "nonpayable",
+		"inputs": [{"name": "tokenA", "type": "address"}, {"name": "tokenB", "type": "address"}, {"name": "amountADesired", "type": "uint256"}, {"name": "amountBDesired", "type": "uint256"}, {"name": "amountAMin", "type": "uint256"}, {"name": "amountBMin", "type": "uint256"}, {"name": "to", "type": "address"}, {"name": "deadline", "type": "uint256"}],
+		"outputs": [{"name": "amountA", "type": "uint256"}, {"name": "amountB", "type": "uint256"}, {"name": "liquidity", "type": "uint256"}]},
+	{"name": "addLiquidityETH", "type": "function", "stateMutability": "payable",
+		"inputs": [{"name": "token", "type": "address"}, {"name": "amountTokenDesired", "type": "uint256"}, {"name": "amountTokenMin", "type": "uint256"}, {"name": "amountETHMin", "type": "uint256"}, {"name": "to", "type": "address"}, {"name": "deadline", "type": "uint256"}],
+		"outputs": [{"name": "amountToken", "type": "uint256"}, {"name": "amountETH", "type": "uint256"}, {"name": "liquidity", "type": "uint256"}]},
+	{"name": "removeLiquidity", "type": "function", "stateMutability": "nonpayable",
+		"inputs": [{"name": "tokenA", "type": "address"}, {"name": "tokenB", "type": "address"}, {"name": "liquidity", "type": "uint256"}, {"name": "amountAMin", "type": "uint256"}, {"name": "amountBMin", "type": "uint256"}, {"name": "to", "type": "address"}, {"name": "deadline", "type": "uint256"}],
+		"outputs": [{"name": "amountA", "type": "uint256"}, {"name": "amountB", "type": "uint256"}]},
+	{"name": "removeLiquidityETH", "type": "function", "stateMutability": "nonpayable",
+		"inputs": [{"name": "token", "type": "address"}, {"name": "liquidity", "type": "uint256"}, {"name": "amountTokenMin", "type": "uint256"}, {"name": "amountETHMin", "type": "uint256"}, {"name": "to", "type": "address"}, {"name": "deadline", "type": "uint256"}],
+		"outputs": [{"name": "amountToken", "type": "uint256"}, {"name": "amountETH", "type": "uint256"}]}
+]`
+
+const v2FactoryJSON = `[
+	{"name": "getPair", "type": "function", "stateMutability": "view",
+		"inputs": [{"name": "tokenA", "type": "address"}, {"name": "tokenB", "type": "address"}],
+		"outputs": [{"name": "pair", "type": "address"}]}
+]`
+
+const v2PairJSON = `[
+	{"name": "getReserves", "type": "function", "stateMutability": "view",
+		"inputs": [],
+		"outputs": [{"name": "reserve0", "type": "uint112"}, {"name": "reserve1", "type": "uint112"}, {"name": "blockTimestampLast", "type": "uint32"}]},
+	{"name": "token0", "type": "function", "stateMutability": "view",
+		"inputs": [],
+		"outputs": [{"name": "", "type": "address"}]},
+	{"name": "totalSupply", "type": "function", "stateMutability": "view",
+		"inputs": [],
+		"outputs": [{"name": "", "type": "uint256"}]}
+]`
+
+const v3QuoterJSON = `[
+	{"name": "quoteExactInput", "type": "function", "stateMutability": "nonpayable",
+		"inputs": [{"name": "path", "type": "bytes"}, {"name": "amountIn", "type": "uint256"}],
+		"outputs": [{"name": "amountOut", "type": "uint256"}, {"name": "sqrtPriceX96AfterList", "type": "uint160[]"}, {"name": "initializedTicksCrossedList", "type": "uint32[]"}, {"name": "gasEstimate", "type": "uint256"}]}
+]`
+
+const v3RouterJSON = `[
+	{"name": "exactInputSingle", "type": "function", "stateMutability": "payable",
+		"inputs": [{"name": "params", "type": "tuple", "components": [{"name": "tokenIn", "type": "address"}, {"name": "tokenOut", "type": "address"}, {"name": "fee", "type": "uint24"}, {"name": "recipient", "type": "address"}, {"name": "deadline", "type": "uint256"}, {"name": "amountIn", "type": "uint256"}, {"name": "amountOutMinimum", "type": "uint256"}, {"name": "sqrtPriceLimitX96", "type": "uint160"}]}],
+		"outputs": [{"name": "amountOut", "type": "uint256"}]},
+	{"name": "exactInput", "type": "function", "stateMutability": "payable",
+		"inputs": [{"name": "params", "type": "tuple", "components": [{"name": "path", "type": "bytes"}, {"name": "recipient", "type": "address"}, {"name": "deadline", "type": "uint256"}, {"name": "amountIn", "type": "uint256"}, {"name": "amountOutMinimum", "type": "uint256"}]}],
+		"outputs": [{"name": "amountOut", "type": "uint256"}]},
+	{"name": "unwrapWETH9", "type": "function", "stateMutability": "payable",
+		"inputs": [{"name": "amountMinimum", "type": "uint256"}, {"name": "recipient", "type": "address"}],
+		"outputs": []},
+	{"name": "refundETH", "type": "function", "stateMutability": "payable",
+		"inputs": [],
+		"outputs": []},
+	{"name": "multicall", "type": "function", "stateMutability": "payable",
+		"inputs": [{"name": "data", "type": "bytes[]"}],
+		"outputs": [{"name": "results", "type": "bytes[]"}]}
+]`
+
+const v3PositionManagerJSON = `[
+	{"name": "mint", "type": "function", "stateMutability": "payable",
+		"inputs": [{"name": "params", "type": "tuple", "components": [{"name": "token0", "type": "address"}, {"name": "token1", "type": "address"}, {"name": "fee", "type": "uint24"}, {"name": "tickLower", "type": "int24"}, {"name": "tickUpper", "type": "int24"}, {"name": "amount0Desired", "type": "uint256"}, {"name": "amount1Desired", "type": "uint256"}, {"name": "amount0Min", "type": "uint256"}, {"name": "amount1Min", "type": "uint256"}, {"name": "recipient", "type": "address"}, {"name": "deadline", "type": "uint256"}]}],
+		"outputs": [{"name": "tokenId", "type": "uint256"}, {"name": "liquidity", "type": "uint128"}, {"name": "amount0", "type": "uint256"}, {"name": "amount1", "type": "uint256"}]},
+	{"name": "increaseLiquidity", "type": "function", "stateMutability": "payable",
+		"inputs": [{"name": "params", "type": "tuple", "components": [{"name": "tokenId", "type": "uint256"}, {"name": "amount0Desired", "type": "uint256"}, {"name": "amount1Desired", "type": "uint256"}, {"name": "amount0Min", "type": "uint256"}, {"name": "amount1Min", "type": "uint256"}, {"name": "deadline", "type": "uint256"}]}],
+		"outputs": [{"name": "liquidity", "type": "uint128"}, {"name": "amount0", "type": "uint256"}, {"name": "amount1", "type": "uint256"}]},
+	{"name": "decreaseLiquidity", "type": "function", "stateMutability": "payable",
+		"inputs": [{"name": "params", "type": "tuple", "components": [{"name": "tokenId", "type": "uint256"}, {"name": "liquidity", "type": "uint128"}, {"name": "amount0Min", "type": "uint256"}, {"name": "amount1Min", "type": "uint256"}, {"name": "deadline", "type": "uint256"}]}],
+		"outputs": [{"name": "amount0", "type": "uint256"}, {"name": "amount1", "type": "uint256"}]},
+	{"name": "collect", "type": "function", "stateMutability": "payable",
+		"inputs": [{"name": "params", "type": "tuple", "components": [{"name": "tokenId", "type": "uint256"}, {"name": "recipient", "type": "address"}, {"name": "amount0Max", "type": "uint128"}, {"name": "amount1Max", "type": "uint128"}]}],
+		"outputs": [{"name": "amount0", "type": "uint256"}, {"name": "amount1", "type": "uint256"}]},
+	{"name": "positions", "type": "function", "stateMutability": "view",
+		"inputs": [{"name": "tokenId", "type": "uint256"}],
+		"outputs": [{"name": "nonce", "type": "uint96"}, {"name": "operator", "type": "address"}, {"name": "token0", "type": "address"}, {"name": "token1", "type": "address"}, {"name": "fee", "type": "uint24"}, {"name": "tickLower", "type": "int24"}, {"name": "tickUpper", "type": "int24"}, {"name": "liquidity", "type": "uint128"}, {"name": "feeGrowthInside0LastX128", "type": "uint256"}, {"name": "feeGrowthInside1LastX128", "type": "uint256"}, {"name": "tokensOwed0", "type": "uint128"}, {"name": "tokensOwed1", "type": "uint128"}]},
+	{"name": "balanceOf", "type": "function", "stateMutability": "view",
+		"inputs": [{"name": "owner", "type": "address"}],
+		"outputs": [{"name": "", "type": "uint256"}]},
+	{"name": "tokenOfOwnerByIndex", "type": "function", "stateMutability": "view",
+		"inputs": [{"name": "owner", "type": "address"}, {"name": "index", "type": "uint256"}],
+		"outputs": [{"name": "", "type": "uint256"}]},
+	{"name": "refundETH", "type": "function", "stateMutability": "payable",
+		"inputs": [],
+		"outputs": []},
+	{"name": "multicall", "type": "function", "stateMutability": "payable",
+		"inputs": [{"name": "data", "type": "bytes[]"}],
+		"outputs": [{"name": "results", "type": "bytes[]"}]}
+]`
+
+const v3FactoryJSON = `[
+	{"name": "getPool", "type": "function", "stateMutability": "view",
+		"inputs": [{"name": "tokenA", "type": "address"}, {"name": "tokenB", "type": "address"}, {"name": "fee", "type": "uint24"}],
+		"outputs": [{"name": "pool", "type": "address"}]},
+	{"name": "feeAmountTickSpacing", "type": "function", "stateMutability": "view",
+		"inputs": [{"name": "fee", "type": "uint24"}],
+		"outputs": [{"name": "", "type": "int24"}]}
+]`
+
+// only the leading slot0 outputs, the rest differs between forks
+const v3PoolJSON = `[
+	{"name": "slot0", "type": "function", "stateMutability": "view",
+		"inputs": [],
+		"outputs": [{"name": "sqrtPriceX96", "type": "uint160"}, {"name": "tick", "type": "int24"}]}
+]`
+
+var (
+	v2RouterABI  = wallet.MustParseABI(v2RouterJSON)
+	v2FactoryABI = wallet.MustParseABI(v2FactoryJSON)
+	v2PairABI    = wallet.MustParseABI(v2PairJSON)
+
+	v3QuoterABI          = wallet.MustParseABI(v3QuoterJSON)
+	v3RouterABI          = wallet.MustParseABI(v3RouterJSON)
+	v3PositionManagerABI = wallet.MustParseABI(v3PositionManagerJSON)
+	v3FactoryABI         = wallet.MustParseABI(v3FactoryJSON)
+	v3PoolABI            = wallet.MustParseABI(v3PoolJSON)
+)
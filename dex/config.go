@@ -0,0 +1,192 @@
+package dex
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultNetwork is the network the wallet is on
+const DefaultNetwork = "monad-testnet"
+
+// DEX types
+const (
+	TypeV2 = "v2"
+	TypeV3 = "v3"
+)
+
+// Config describes the DEXes of every network, for example:
+//
+//	networks:
+//	  monad-testnet:
+//	    wmon: "0x..."
+//	    tokens:
+//	      USDC: "0x..."
+//	    dexes:
+//	      uniswap:
+//	        type: v2
+//	        router: "0x..."
+//	        factory: "0x..."
+//	      uniswap-v3:
+//	        type: v3
+//	        router: "0x..."
+//	        factory: "0x..."
+//	        quoter: "0x..."
+//	        positionManager: "0x..."
+type Config struct {
+	Networks map[string]*Network `yaml:"networks"`
+}
+
+// Network holds the contracts of a network
+type Network struct {
+	// WMON is the wrapped native token, used when swapping MON
+	WMON string `yaml:"wmon"`
+	// Tokens maps token symbols to addresses
+	Tokens map[string]string `yaml:"tokens"`
+	DEXes  map[string]*DEX   `yaml:"dexes"`
+}
+
+// DEX holds the contracts of a DEX
+type DEX struct {
+	// Type is v2, the default, for Uniswap V2 forks or v3 for Uniswap V3
+	// forks
+	Type    string `yaml:"type"`
+	Router  string `yaml:"router"`
+	Factory string `yaml:"factory"`
+	// Quoter and PositionManager are the V3 periphery contracts, the
+	// position manager being only needed for positions
+	Quoter          string `yaml:"quoter"`
+	PositionManager string `yaml:"positionManager"`
+}
+
+// LoadConfig reads a YAML or JSON configuration file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: invalid config: %v", path, err)
+	}
+
+	for name, network := range config.Networks {
+		if err := network.compile(); err != nil {
+			return nil, fmt.Errorf("%s: network %s: %v", path, name, err)
+		}
+	}
+
+	return &config, nil
+}
+
+// Network returns the network called name
+func (c *Config) Network(name string) (*Network, error) {
+	network, ok := c.Networks[name]
+	if !ok {
+		return nil, fmt.Errorf("network %s is not configured", name)
+	}
+	return network, nil
+}
+
+// compile validates the addresses of the network
+func (n *Network) compile() error {
+	if n.WMON != "" && !common.IsHexAddress(n.WMON) {
+		return fmt.Errorf("invalid wmon address %q", n.WMON)
+	}
+	for symbol, address := range n.Tokens {
+		if !common.IsHexAddress(address) {
+			return fmt.Errorf("token %s: invalid address %q", symbol, address)
+		}
+	}
+
+	for name, dex := range n.DEXes {
+		if dex.Type == "" {
+			dex.Type = TypeV2
+		}
+
+		var err error
+		switch dex.Type {
+		case TypeV2:
+			err = requireAddresses("router", dex.Router, "factory", dex.Factory)
+		case TypeV3:
+			err = requireAddresses("router", dex.Router, "factory", dex.Factory, "quoter", dex.Quoter)
+			if err == nil && dex.PositionManager != "" {
+				err = requireAddresses("positionManager", dex.PositionManager)
+			}
+		default:
+			err = fmt.Errorf("unknown type %q", dex.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("dex %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// requireAddresses takes field name and address pairs
+func requireAddresses(fields ...string) error {
+	for i := 0; i < len(fields); i += 2 {
+		switch {
+		case fields[i+1] == "":
+			return fmt.Errorf("%s is required", fields[i])
+		case !common.IsHexAddress(fields[i+1]):
+			return fmt.Errorf("invalid %s address %q", fields[i], fields[i+1])
+		}
+	}
+	return nil
+}
+
+// DEX returns the DEX called name, which may be left out when the
+// network has a single one
+func (n *Network) DEX(name string) (*DEX, error) {
+	if name == "" {
+		if len(n.DEXes) == 1 {
+			for _, dex := range n.DEXes {
+				return dex, nil
+			}
+		}
+		if len(n.DEXes) == 0 {
+			return nil, errors.New("no DEX is configured")
+		}
+		return nil, fmt.Errorf("several DEXes are configured, choose one of %s", strings.Join(n.dexNames(), ", "))
+	}
+
+	dex, ok := n.DEXes[name]
+	if !ok {
+		return nil, fmt.Errorf("DEX %s is not configured", name)
+	}
+	return dex, nil
+}
+
+func (n *Network) dexNames() []string {
+	names := make([]string, 0, len(n.DEXes))
+	for name := range n.DEXes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Token resolves a token symbol, case insensitive, or address. MON is
+// returned as Native.
+func (n *Network) Token(symbol string) (string, error) {
+	if strings.EqualFold(symbol, Native) {
+		return Native, nil
+	}
+	if common.IsHexAddress(symbol) {
+		return common.HexToAddress(symbol).Hex(), nil
+	}
+	for name, address := range n.Tokens {
+		if strings.EqualFold(name, symbol) {
+			return common.HexToAddress(address).Hex(), nil
+		}
+	}
+	return "", fmt.Errorf("unknown token %s", symbol)
+}
@@ -0,0 +1,142 @@
+package dex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "dex.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name: "valid",
+			content: `
+networks:
+  monad-testnet:
+    wmon: "0x3333333333333333333333333333333333333333"
+    dexes:
+      uniswap:
+        router: "0x1111111111111111111111111111111111111111"
+        factory: "0x2222222222222222222222222222222222222222"
+`,
+		},
+		{
+			name: "missing factory",
+			content: `
+networks:
+  monad-testnet:
+    dexes:
+      uniswap:
+        router: "0x1111111111111111111111111111111111111111"
+`,
+			err: "network monad-testnet: dex uniswap: factory is required",
+		},
+		{
+			name: "invalid token",
+			content: `
+networks:
+  monad-testnet:
+    tokens:
+      USDC: "0x1234"
+`,
+			err: `network monad-testnet: token USDC: invalid address "0x1234"`,
+		},
+		{
+			name: "v3 without quoter",
+			content: `
+networks:
+  monad-testnet:
+    dexes:
+      uniswap:
+        type: v3
+        router: "0x1111111111111111111111111111111111111111"
+        factory: "0x2222222222222222222222222222222222222222"
+`,
+			err: "network monad-testnet: dex uniswap: quoter is required",
+		},
+		{
+			name: "unknown type",
+			content: `
+networks:
+  monad-testnet:
+    dexes:
+      uniswap:
+        type: v9
+`,
+			err: `network monad-testnet: dex uniswap: unknown type "v9"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := writeConfig(t, test.content)
+			config, err := LoadConfig(path)
+			if test.err != "" {
+				assert.EqualError(t, err, path+": "+test.err)
+				return
+			}
+			assert.NoError(t, err)
+			dex, err := config.Networks[DefaultNetwork].DEX("")
+			assert.NoError(t, err)
+			assert.Equal(t, TypeV2, dex.Type)
+		})
+	}
+}
+
+func TestNetworkDEX(t *testing.T) {
+	network := &Network{DEXes: map[string]*DEX{"uniswap": {}, "pancake": {}}}
+
+	_, err := network.DEX("")
+	assert.EqualError(t, err, "several DEXes are configured, choose one of pancake, uniswap")
+
+	dex, err := network.DEX("pancake")
+	assert.NoError(t, err)
+	assert.Same(t, network.DEXes["pancake"], dex)
+
+	_, err = network.DEX("sushi")
+	assert.EqualError(t, err, "DEX sushi is not configured")
+
+	_, err = (&Network{}).DEX("")
+	assert.EqualError(t, err, "no DEX is configured")
+}
+
+func TestNetworkToken(t *testing.T) {
+	network := &Network{Tokens: map[string]string{"USDC": "0x4444444444444444444444444444444444444444"}}
+
+	tests := []struct {
+		symbol string
+		token  string
+		err    string
+	}{
+		{"mon", Native, ""},
+		{"usdc", testUSDC, ""},
+		{"0x6666666666666666666666666666666666666666", testDAI, ""},
+		{"WETH", "", "unknown token WETH"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.symbol, func(t *testing.T) {
+			token, err := network.Token(test.symbol)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.token, token)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package dex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/galihrivanto/omonOmon/wallet"
+)
+
+// Native stands for MON in token paths, it is swapped through WMON
+const Native = "MON"
+
+// contract calls the methods of a contract through a backend
+type contract struct {
+	backend wallet.Backend
+	address string
+	abi     abi.ABI
+}
+
+// call runs a read only method, returning its outputs
+func (c contract) call(ctx context.Context, method string, args ...interface{}) ([]interface{}, error) {
+	data, err := c.abi.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.backend.Call(ctx, c.address, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s of %s: %v", method, c.address, err)
+	}
+
+	values, err := c.abi.Unpack(method, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s of %s: %v", method, c.address, err)
+	}
+	return values, nil
+}
+
+// transact sends a transaction calling method
+func (c contract) transact(ctx context.Context, w *wallet.Wallet, value *big.Int, method string, args ...interface{}) (string, error) {
+	data, err := c.abi.Pack(method, args...)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := c.backend.Transact(ctx, w, c.address, value, data)
+	if err != nil {
+		return hash, fmt.Errorf("failed to %s: %v", method, err)
+	}
+	return hash, nil
+}
+
+func (c contract) callUint(ctx context.Context, method string, args ...interface{}) (*big.Int, error) {
+	values, err := c.call(ctx, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := values[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("failed to call %s of %s: unexpected result", method, c.address)
+	}
+	return value, nil
+}
+
+func (c contract) callAddress(ctx context.Context, method string, args ...interface{}) (common.Address, error) {
+	values, err := c.call(ctx, method, args...)
+	if err != nil {
+		return common.Address{}, err
+	}
+	value, ok := values[0].(common.Address)
+	if !ok {
+		return common.Address{}, fmt.Errorf("failed to call %s of %s: unexpected result", method, c.address)
+	}
+	return value, nil
+}
+
+// Decimals returns the decimals of a token, 18 for Native
+func Decimals(ctx context.Context, backend wallet.Backend, token string) (int, error) {
+	if token == Native {
+		return 18, nil
+	}
+
+	return wallet.ERC20{Backend: backend, Address: token}.Decimals(ctx)
+}
+
+// Approve lets spender spend amount of token when its allowance is
+// lower, returning the approval transaction hash if one was sent
+func Approve(ctx context.Context, backend wallet.Backend, w *wallet.Wallet, token string, spender string, amount *big.Int) (string, error) {
+	erc20 := wallet.ERC20{Backend: backend, Address: token}
+
+	allowance, err := erc20.Allowance(ctx, w.Address, spender)
+	if err != nil {
+		return "", err
+	}
+	if allowance.Cmp(amount) >= 0 {
+		return "", nil
+	}
+
+	fmt.Printf("Approving %s to spend %s of %s...\n", spender, amount, token)
+	return erc20.Approve(ctx, w, spender, amount)
+}
+
+// tokenPath maps the tokens to a swap path, Native standing for wmon at
+// either end
+func tokenPath(wmon string, tokens []string) ([]common.Address, error) {
+	if len(tokens) < 2 {
+		return nil, errors.New("a path needs at least two tokens")
+	}
+
+	path := make([]common.Address, len(tokens))
+	for i, token := range tokens {
+		switch {
+		case token != Native:
+			path[i] = common.HexToAddress(token)
+		case i != 0 && i != len(tokens)-1:
+			return nil, errors.New("MON can only start or end a path")
+		case wmon == "":
+			return nil, errors.New("swapping MON needs the wmon address of the network")
+		default:
+			path[i] = common.HexToAddress(wmon)
+		}
+	}
+	return path, nil
+}
+
+// withSlippage lowers amount by slippage, in basis points
+func withSlippage(amount *big.Int, slippage int) *big.Int {
+	value := new(big.Int).Mul(amount, big.NewInt(int64(10000-slippage)))
+	return value.Quo(value, big.NewInt(10000))
+}
+
+// withSlippageUp raises amount by slippage, in basis points, rounding up
+func withSlippageUp(amount *big.Int, slippage int) *big.Int {
+	value := new(big.Int).Mul(amount, big.NewInt(int64(10000+slippage)))
+	value.Add(value, big.NewInt(9999))
+	return value.Quo(value, big.NewInt(10000))
+}
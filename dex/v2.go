@@ -0,0 +1,325 @@
+package dex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/galihrivanto/omonOmon/wallet"
+)
+
+const (
+	defaultSlippage = 50
+	defaultDeadline = 20 * time.Minute
+
+	// NoSlippage is the Slippage of trades that tolerate no price change
+	NoSlippage = -1
+)
+
+// TradeOptions protect swaps and liquidity changes
+type TradeOptions struct {
+	// Slippage is the price change tolerated, in basis points, defaults
+	// to 50 (0.5%), NoSlippage tolerates none
+	Slippage int
+	// Deadline is how long the transaction stays valid, defaults to 20m
+	Deadline time.Duration
+	// Recipient receives the output, defaults to the wallet
+	Recipient string
+}
+
+func (o TradeOptions) withDefaults(w *wallet.Wallet) TradeOptions {
+	switch {
+	case o.Slippage == 0:
+		o.Slippage = defaultSlippage
+	case o.Slippage < 0:
+		o.Slippage = 0
+	}
+	if o.Deadline <= 0 {
+		o.Deadline = defaultDeadline
+	}
+	if o.Recipient == "" {
+		o.Recipient = w.Address
+	}
+	return o
+}
+
+func (o TradeOptions) deadline() *big.Int {
+	return big.NewInt(time.Now().Add(o.Deadline).Unix())
+}
+
+// Swap describes a swap sent
+type Swap struct {
+	// AmountIn and AmountOut are the quoted amounts
+	AmountIn  *big.Int
+	AmountOut *big.Int
+	// Limit is the minimum output of exact input swaps, or the maximum
+	// input of exact output swaps
+	Limit *big.Int
+	Hash  string
+}
+
+// Liquidity describes a liquidity change sent
+type Liquidity struct {
+	Pair string
+	// AmountA and AmountB are the amounts deposited or expected back
+	AmountA   *big.Int
+	AmountB   *big.Int
+	Liquidity *big.Int
+	Hash      string
+}
+
+// V2 trades on a Uniswap V2 fork. Tokens are addresses or Native.
+type V2 struct {
+	Router  string
+	Factory string
+	WMON    string
+	// Backend defaults to the Monad testnet
+	Backend wallet.Backend
+}
+
+// NewV2 creates a client of a V2 DEX of network
+func NewV2(dex *DEX, network *Network) *V2 {
+	return &V2{Router: dex.Router, Factory: dex.Factory, WMON: network.WMON, Backend: wallet.RPCBackend{}}
+}
+
+func (d *V2) router() contract {
+	return contract{d.Backend, d.Router, v2RouterABI}
+}
+
+// path maps the tokens to a swap path
+func (d *V2) path(tokens []string) ([]common.Address, error) {
+	return tokenPath(d.WMON, tokens)
+}
+
+// QuoteExactIn returns the amounts along the path for amountIn, the last
+// one being the output
+func (d *V2) QuoteExactIn(ctx context.Context, amountIn *big.Int, tokens []string) ([]*big.Int, error) {
+	return d.quote(ctx, "getAmountsOut", amountIn, tokens)
+}
+
+// QuoteExactOut returns the amounts along the path for amountOut, the
+// first one being the input
+func (d *V2) QuoteExactOut(ctx context.Context, amountOut *big.Int, tokens []string) ([]*big.Int, error) {
+	return d.quote(ctx, "getAmountsIn", amountOut, tokens)
+}
+
+func (d *V2) quote(ctx context.Context, method string, amount *big.Int, tokens []string) ([]*big.Int, error) {
+	path, err := d.path(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := d.router().call(ctx, method, amount, path)
+	if err != nil {
+		return nil, err
+	}
+	amounts, ok := values[0].([]*big.Int)
+	if !ok || len(amounts) != len(path) {
+		return nil, fmt.Errorf("failed to call %s: unexpected result", method)
+	}
+	return amounts, nil
+}
+
+// SwapExactIn swaps amountIn of the first token for at least the quoted
+// output, less slippage, of the last one
+func (d *V2) SwapExactIn(ctx context.Context, w *wallet.Wallet, tokens []string, amountIn *big.Int, opts TradeOptions) (*Swap, error) {
+	opts = opts.withDefaults(w)
+
+	amounts, err := d.QuoteExactIn(ctx, amountIn, tokens)
+	if err != nil {
+		return nil, err
+	}
+	path, _ := d.path(tokens)
+
+	swap := &Swap{AmountIn: amountIn, AmountOut: amounts[len(amounts)-1]}
+	swap.Limit = withSlippage(swap.AmountOut, opts.Slippage)
+	to := common.HexToAddress(opts.Recipient)
+
+	switch {
+	case tokens[0] == Native:
+		swap.Hash, err = d.router().transact(ctx, w, amountIn, "swapExactETHForTokens", swap.Limit, path, to, opts.deadline())
+	case tokens[len(tokens)-1] == Native:
+		if _, err := Approve(ctx, d.Backend, w, tokens[0], d.Router, amountIn); err != nil {
+			return nil, err
+		}
+		swap.Hash, err = d.router().transact(ctx, w, nil, "swapExactTokensForETH", amountIn, swap.Limit, path, to, opts.deadline())
+	default:
+		if _, err := Approve(ctx, d.Backend, w, tokens[0], d.Router, amountIn); err != nil {
+			return nil, err
+		}
+		swap.Hash, err = d.router().transact(ctx, w, nil, "swapExactTokensForTokens", amountIn, swap.Limit, path, to, opts.deadline())
+	}
+	return swap, err
+}
+
+// SwapExactOut swaps at most the quoted input, plus slippage, of the
+// first token for amountOut of the last one
+func (d *V2) SwapExactOut(ctx context.Context, w *wallet.Wallet, tokens []string, amountOut *big.Int, opts TradeOptions) (*Swap, error) {
+	opts = opts.withDefaults(w)
+
+	amounts, err := d.QuoteExactOut(ctx, amountOut, tokens)
+	if err != nil {
+		return nil, err
+	}
+	path, _ := d.path(tokens)
+
+	swap := &Swap{AmountIn: amounts[0], AmountOut: amountOut}
+	swap.Limit = withSlippageUp(swap.AmountIn, opts.Slippage)
+	to := common.HexToAddress(opts.Recipient)
+
+	switch {
+	case tokens[0] == Native:
+		// the router refunds the MON left over
+		swap.Hash, err = d.router().transact(ctx, w, swap.Limit, "swapETHForExactTokens", amountOut, path, to, opts.deadline())
+	case tokens[len(tokens)-1] == Native:
+		if _, err := Approve(ctx, d.Backend, w, tokens[0], d.Router, swap.Limit); err != nil {
+			return nil, err
+		}
+		swap.Hash, err = d.router().transact(ctx, w, nil, "swapTokensForExactETH", amountOut, swap.Limit, path, to, opts.deadline())
+	default:
+		if _, err := Approve(ctx, d.Backend, w, tokens[0], d.Router, swap.Limit); err != nil {
+			return nil, err
+		}
+		swap.Hash, err = d.router().transact(ctx, w, nil, "swapTokensForExactTokens", amountOut, swap.Limit, path, to, opts.deadline())
+	}
+	return swap, err
+}
+
+// Pair returns the pair of tokenA and tokenB
+func (d *V2) Pair(ctx context.Context, tokenA string, tokenB string) (string, error) {
+	path, err := d.path([]string{tokenA, tokenB})
+	if err != nil {
+		return "", err
+	}
+
+	pair, err := contract{d.Backend, d.Factory, v2FactoryABI}.callAddress(ctx, "getPair", path[0], path[1])
+	if err != nil {
+		return "", err
+	}
+	if pair == (common.Address{}) {
+		return "", fmt.Errorf("no pair of %s and %s", tokenA, tokenB)
+	}
+	return pair.Hex(), nil
+}
+
+// AddLiquidity deposits up to amountA and amountB, at least those less
+// slippage, in the pair of tokenA and tokenB
+func (d *V2) AddLiquidity(ctx context.Context, w *wallet.Wallet, tokenA string, tokenB string, amountA *big.Int, amountB *big.Int, opts TradeOptions) (*Liquidity, error) {
+	opts = opts.withDefaults(w)
+	if tokenA == Native {
+		tokenA, tokenB, amountA, amountB = tokenB, tokenA, amountB, amountA
+	}
+	if tokenA == Native {
+		return nil, errors.New("MON can not be paired with itself")
+	}
+
+	liquidity := &Liquidity{AmountA: amountA, AmountB: amountB}
+	minA, minB := withSlippage(amountA, opts.Slippage), withSlippage(amountB, opts.Slippage)
+	to := common.HexToAddress(opts.Recipient)
+
+	if _, err := Approve(ctx, d.Backend, w, tokenA, d.Router, amountA); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if tokenB == Native {
+		liquidity.Hash, err = d.router().transact(ctx, w, amountB, "addLiquidityETH",
+			common.HexToAddress(tokenA), amountA, minA, minB, to, opts.deadline())
+		return liquidity, err
+	}
+
+	if _, err := Approve(ctx, d.Backend, w, tokenB, d.Router, amountB); err != nil {
+		return nil, err
+	}
+	liquidity.Hash, err = d.router().transact(ctx, w, nil, "addLiquidity",
+		common.HexToAddress(tokenA), common.HexToAddress(tokenB), amountA, amountB, minA, minB, to, opts.deadline())
+	return liquidity, err
+}
+
+// Position returns the pair liquidity held by owner and its share of the
+// reserves of tokenA and tokenB
+func (d *V2) Position(ctx context.Context, owner string, tokenA string, tokenB string) (*Liquidity, error) {
+	pairAddress, err := d.Pair(ctx, tokenA, tokenB)
+	if err != nil {
+		return nil, err
+	}
+	pair := contract{d.Backend, pairAddress, v2PairABI}
+
+	balance, err := wallet.ERC20{Backend: d.Backend, Address: pairAddress}.BalanceOf(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	supply, err := pair.callUint(ctx, "totalSupply")
+	if err != nil {
+		return nil, err
+	}
+	token0, err := pair.callAddress(ctx, "token0")
+	if err != nil {
+		return nil, err
+	}
+	reserves, err := pair.call(ctx, "getReserves")
+	if err != nil {
+		return nil, err
+	}
+
+	reserveA, reserveB := reserves[0].(*big.Int), reserves[1].(*big.Int)
+	path, _ := d.path([]string{tokenA, tokenB})
+	if !strings.EqualFold(token0.Hex(), path[0].Hex()) {
+		reserveA, reserveB = reserveB, reserveA
+	}
+
+	position := &Liquidity{Pair: pairAddress, Liquidity: balance, AmountA: new(big.Int), AmountB: new(big.Int)}
+	if supply.Sign() > 0 {
+		position.AmountA.Quo(new(big.Int).Mul(balance, reserveA), supply)
+		position.AmountB.Quo(new(big.Int).Mul(balance, reserveB), supply)
+	}
+	return position, nil
+}
+
+// RemoveLiquidity withdraws liquidity, all of the wallet's when nil, from
+// the pair of tokenA and tokenB, for at least its share of the reserves
+// less slippage
+func (d *V2) RemoveLiquidity(ctx context.Context, w *wallet.Wallet, tokenA string, tokenB string, liquidity *big.Int, opts TradeOptions) (*Liquidity, error) {
+	opts = opts.withDefaults(w)
+	if tokenA == Native {
+		tokenA, tokenB = tokenB, tokenA
+	}
+
+	position, err := d.Position(ctx, w.Address, tokenA, tokenB)
+	if err != nil {
+		return nil, err
+	}
+	if liquidity == nil {
+		liquidity = position.Liquidity
+	}
+	switch {
+	case liquidity.Sign() == 0:
+		return nil, errors.New("no liquidity to remove")
+	case liquidity.Cmp(position.Liquidity) > 0:
+		return nil, fmt.Errorf("only %s liquidity is held", position.Liquidity)
+	}
+
+	// the amounts expected for the part of the position removed
+	result := &Liquidity{Pair: position.Pair, Liquidity: liquidity}
+	result.AmountA = new(big.Int).Quo(new(big.Int).Mul(position.AmountA, liquidity), position.Liquidity)
+	result.AmountB = new(big.Int).Quo(new(big.Int).Mul(position.AmountB, liquidity), position.Liquidity)
+	minA, minB := withSlippage(result.AmountA, opts.Slippage), withSlippage(result.AmountB, opts.Slippage)
+	to := common.HexToAddress(opts.Recipient)
+
+	if _, err := Approve(ctx, d.Backend, w, position.Pair, d.Router, liquidity); err != nil {
+		return nil, err
+	}
+
+	if tokenB == Native {
+		result.Hash, err = d.router().transact(ctx, w, nil, "removeLiquidityETH",
+			common.HexToAddress(tokenA), liquidity, minA, minB, to, opts.deadline())
+		return result, err
+	}
+	result.Hash, err = d.router().transact(ctx, w, nil, "removeLiquidity",
+		common.HexToAddress(tokenA), common.HexToAddress(tokenB), liquidity, minA, minB, to, opts.deadline())
+	return result, err
+}
@@ -0,0 +1,200 @@
+package dex
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/galihrivanto/omonOmon/wallet"
+	"github.com/galihrivanto/omonOmon/wallet/wallettest"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testRouter  = "0x1111111111111111111111111111111111111111"
+	testFactory = "0x2222222222222222222222222222222222222222"
+	testWMON    = "0x3333333333333333333333333333333333333333"
+	testUSDC    = "0x4444444444444444444444444444444444444444"
+	testPair    = "0x5555555555555555555555555555555555555555"
+	testDAI     = "0x6666666666666666666666666666666666666666"
+)
+
+var testWallet = &wallet.Wallet{Address: "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"}
+
+func newFakeBackend(results map[string][]interface{}) *wallettest.Backend {
+	return wallettest.NewBackend(results, wallet.ERC20ABI, v2RouterABI, v2FactoryABI, v2PairABI,
+		v3QuoterABI, v3RouterABI, v3PositionManagerABI, v3FactoryABI, v3PoolABI)
+}
+
+func testV2(backend wallet.Backend) *V2 {
+	return &V2{Router: testRouter, Factory: testFactory, WMON: testWMON, Backend: backend}
+}
+
+func addresses(values ...string) []common.Address {
+	path := make([]common.Address, len(values))
+	for i, value := range values {
+		path[i] = common.HexToAddress(value)
+	}
+	return path
+}
+
+func TestV2SwapExactIn(t *testing.T) {
+	wallet := common.HexToAddress(testWallet.Address)
+
+	tests := []struct {
+		name      string
+		tokens    []string
+		allowance int64
+		value     *big.Int
+		method    string
+		approved  bool
+	}{
+		{"tokens", []string{testUSDC, testDAI}, 0, nil, "swapExactTokensForTokens", true},
+		{"allowance enough", []string{testUSDC, testDAI}, 1000, nil, "swapExactTokensForTokens", false},
+		{"from MON", []string{Native, testUSDC}, 0, big.NewInt(1000), "swapExactETHForTokens", false},
+		{"to MON", []string{testUSDC, Native}, 0, nil, "swapExactTokensForETH", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend := newFakeBackend(map[string][]interface{}{
+				"getAmountsOut": {[]*big.Int{big.NewInt(1000), big.NewInt(2000)}},
+				"allowance":     {big.NewInt(test.allowance)},
+			})
+
+			swap, err := testV2(backend).SwapExactIn(context.Background(), testWallet, test.tokens, big.NewInt(1000), TradeOptions{Slippage: 100})
+			assert.NoError(t, err)
+			assert.Equal(t, big.NewInt(2000), swap.AmountOut)
+			assert.Equal(t, big.NewInt(1980), swap.Limit)
+
+			if test.approved {
+				assert.Len(t, backend.Sent, 2)
+				assert.Equal(t, wallettest.Tx{To: testUSDC, Method: "approve", Args: []interface{}{common.HexToAddress(testRouter), big.NewInt(1000)}}, backend.Sent[0])
+			} else {
+				assert.Len(t, backend.Sent, 1)
+			}
+
+			swapTx := backend.Sent[len(backend.Sent)-1]
+			assert.Equal(t, testRouter, swapTx.To)
+			assert.Equal(t, test.method, swapTx.Method)
+			assert.Equal(t, test.value, swapTx.Value)
+
+			args := swapTx.Args
+			if test.value == nil {
+				assert.Equal(t, big.NewInt(1000), args[0])
+				args = args[1:]
+			}
+			path := make([]string, len(test.tokens))
+			for i, token := range test.tokens {
+				path[i] = map[bool]string{true: testWMON, false: token}[token == Native]
+			}
+			assert.Equal(t, big.NewInt(1980), args[0])
+			assert.Equal(t, addresses(path...), args[1])
+			assert.Equal(t, wallet, args[2])
+
+			deadline := args[3].(*big.Int).Int64()
+			assert.InDelta(t, time.Now().Add(defaultDeadline).Unix(), deadline, 5)
+		})
+	}
+}
+
+func TestV2SwapExactOut(t *testing.T) {
+	backend := newFakeBackend(map[string][]interface{}{
+		"getAmountsIn": {[]*big.Int{big.NewInt(1000), big.NewInt(500), big.NewInt(2000)}},
+		"allowance":    {big.NewInt(0)},
+	})
+
+	swap, err := testV2(backend).SwapExactOut(context.Background(), testWallet, []string{testUSDC, testWMON, testDAI}, big.NewInt(2000), TradeOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1000), swap.AmountIn)
+	// 1000 plus 0.5%, rounded up
+	assert.Equal(t, big.NewInt(1005), swap.Limit)
+
+	assert.Len(t, backend.Sent, 2)
+	assert.Equal(t, big.NewInt(1005), backend.Sent[0].Args[1])
+	assert.Equal(t, "swapTokensForExactTokens", backend.Sent[1].Method)
+	assert.Equal(t, big.NewInt(2000), backend.Sent[1].Args[0])
+	assert.Equal(t, big.NewInt(1005), backend.Sent[1].Args[1])
+	assert.Equal(t, addresses(testUSDC, testWMON, testDAI), backend.Sent[1].Args[2])
+}
+
+func TestTradeOptionsSlippage(t *testing.T) {
+	tests := []struct {
+		name     string
+		slippage int
+		expected int
+	}{
+		{"default", 0, defaultSlippage},
+		{"set", 100, 100},
+		{"none", NoSlippage, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := TradeOptions{Slippage: test.slippage}.withDefaults(testWallet)
+			assert.Equal(t, test.expected, opts.Slippage)
+		})
+	}
+}
+
+func TestV2Path(t *testing.T) {
+	d := testV2(nil)
+
+	_, err := d.path([]string{testUSDC})
+	assert.EqualError(t, err, "a path needs at least two tokens")
+
+	_, err = d.path([]string{testUSDC, Native, testDAI})
+	assert.EqualError(t, err, "MON can only start or end a path")
+
+	d.WMON = ""
+	_, err = d.path([]string{Native, testDAI})
+	assert.EqualError(t, err, "swapping MON needs the wmon address of the network")
+}
+
+func TestV2AddLiquidity(t *testing.T) {
+	backend := newFakeBackend(map[string][]interface{}{"allowance": {big.NewInt(0)}})
+
+	liquidity, err := testV2(backend).AddLiquidity(context.Background(), testWallet, Native, testUSDC, big.NewInt(2000), big.NewInt(1000), TradeOptions{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, liquidity.Hash)
+
+	// MON is paired through addLiquidityETH, only the token is approved
+	assert.Len(t, backend.Sent, 2)
+	assert.Equal(t, "approve", backend.Sent[0].Method)
+	assert.Equal(t, testUSDC, backend.Sent[0].To)
+
+	add := backend.Sent[1]
+	assert.Equal(t, "addLiquidityETH", add.Method)
+	assert.Equal(t, big.NewInt(2000), add.Value)
+	assert.Equal(t, common.HexToAddress(testUSDC), add.Args[0])
+	assert.Equal(t, []interface{}{big.NewInt(1000), big.NewInt(995), big.NewInt(1990)}, add.Args[1:4])
+}
+
+func TestV2RemoveLiquidity(t *testing.T) {
+	backend := newFakeBackend(map[string][]interface{}{
+		"getPair":     {common.HexToAddress(testPair)},
+		"balanceOf":   {big.NewInt(100)},
+		"totalSupply": {big.NewInt(1000)},
+		"token0":      {common.HexToAddress(testDAI)},
+		"getReserves": {big.NewInt(50000), big.NewInt(10000), uint32(0)},
+		"allowance":   {big.NewInt(0)},
+	})
+
+	result, err := testV2(backend).RemoveLiquidity(context.Background(), testWallet, testUSDC, testDAI, big.NewInt(50), TradeOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, testPair, result.Pair)
+	// half of a 10% share, DAI being token0
+	assert.Equal(t, big.NewInt(500), result.AmountA)
+	assert.Equal(t, big.NewInt(2500), result.AmountB)
+
+	assert.Len(t, backend.Sent, 2)
+	assert.Equal(t, testPair, backend.Sent[0].To)
+	remove := backend.Sent[1]
+	assert.Equal(t, "removeLiquidity", remove.Method)
+	assert.Equal(t, []interface{}{common.HexToAddress(testUSDC), common.HexToAddress(testDAI), big.NewInt(50), big.NewInt(497), big.NewInt(2487)}, remove.Args[:5])
+
+	_, err = testV2(backend).RemoveLiquidity(context.Background(), testWallet, testUSDC, testDAI, big.NewInt(200), TradeOptions{})
+	assert.EqualError(t, err, "only 100 liquidity is held")
+}
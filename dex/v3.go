@@ -0,0 +1,614 @@
+package dex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/galihrivanto/omonOmon/wallet"
+)
+
+// maxTick bounds the ticks of V3 pools
+const maxTick = 887272
+
+// maxUint128 collects everything owed to a position
+var maxUint128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+// q96 scales the square root prices of V3 pools, which are Q64.96 fixed
+// point numbers
+var q96 = new(big.Int).Lsh(big.NewInt(1), 96)
+
+// V3 trades on a Uniswap V3 fork. Tokens are addresses or Native, fees
+// are pool fee tiers in hundredths of a basis point, 3000 being 0.3%.
+type V3 struct {
+	Router          string
+	Factory         string
+	Quoter          string
+	PositionManager string
+	WMON            string
+	// Backend defaults to the Monad testnet
+	Backend wallet.Backend
+}
+
+// NewV3 creates a client of a V3 DEX of network
+func NewV3(dex *DEX, network *Network) *V3 {
+	return &V3{
+		Router:          dex.Router,
+		Factory:         dex.Factory,
+		Quoter:          dex.Quoter,
+		PositionManager: dex.PositionManager,
+		WMON:            network.WMON,
+		Backend:         wallet.RPCBackend{},
+	}
+}
+
+func (d *V3) router() contract {
+	return contract{d.Backend, d.Router, v3RouterABI}
+}
+
+func (d *V3) factory() contract {
+	return contract{d.Backend, d.Factory, v3FactoryABI}
+}
+
+func (d *V3) positionManager() (contract, error) {
+	if d.PositionManager == "" {
+		return contract{}, errors.New("positions need the positionManager address of the DEX")
+	}
+	return contract{d.Backend, d.PositionManager, v3PositionManagerABI}, nil
+}
+
+// route maps the tokens to a swap path, a single fee being used for
+// every hop, and encodes it as the router expects
+func (d *V3) route(tokens []string, fees []int) ([]common.Address, []int, []byte, error) {
+	path, err := tokenPath(d.WMON, tokens)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if len(fees) == 1 {
+		for len(fees) < len(path)-1 {
+			fees = append(fees, fees[0])
+		}
+	}
+	if len(fees) != len(path)-1 {
+		return nil, nil, nil, fmt.Errorf("a path of %d tokens needs %d fees", len(path), len(path)-1)
+	}
+
+	var encoded []byte
+	for i, address := range path {
+		encoded = append(encoded, address.Bytes()...)
+		if i == len(fees) {
+			break
+		}
+		if fees[i] <= 0 || fees[i] >= 1<<24 {
+			return nil, nil, nil, fmt.Errorf("invalid fee %d", fees[i])
+		}
+		encoded = append(encoded, byte(fees[i]>>16), byte(fees[i]>>8), byte(fees[i]))
+	}
+	return path, fees, encoded, nil
+}
+
+// QuoteExactIn returns the output of swapping amountIn along the path
+func (d *V3) QuoteExactIn(ctx context.Context, amountIn *big.Int, tokens []string, fees []int) (*big.Int, error) {
+	_, _, path, err := d.route(tokens, fees)
+	if err != nil {
+		return nil, err
+	}
+	return contract{d.Backend, d.Quoter, v3QuoterABI}.callUint(ctx, "quoteExactInput", path, amountIn)
+}
+
+type exactInputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	Fee               *big.Int
+	Recipient         common.Address
+	Deadline          *big.Int
+	AmountIn          *big.Int
+	AmountOutMinimum  *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+type exactInputParams struct {
+	Path             []byte
+	Recipient        common.Address
+	Deadline         *big.Int
+	AmountIn         *big.Int
+	AmountOutMinimum *big.Int
+}
+
+// SwapExactIn swaps amountIn of the first token for at least the quoted
+// output, less slippage, of the last one
+func (d *V3) SwapExactIn(ctx context.Context, w *wallet.Wallet, tokens []string, fees []int, amountIn *big.Int, opts TradeOptions) (*Swap, error) {
+	opts = opts.withDefaults(w)
+
+	path, fees, encoded, err := d.route(tokens, fees)
+	if err != nil {
+		return nil, err
+	}
+	amountOut, err := d.QuoteExactIn(ctx, amountIn, tokens, fees)
+	if err != nil {
+		return nil, err
+	}
+
+	swap := &Swap{AmountIn: amountIn, AmountOut: amountOut}
+	swap.Limit = withSlippage(swap.AmountOut, opts.Slippage)
+
+	var value *big.Int
+	if tokens[0] == Native {
+		value = amountIn
+	} else if _, err := Approve(ctx, d.Backend, w, tokens[0], d.Router, amountIn); err != nil {
+		return nil, err
+	}
+
+	// MON is unwrapped by the router once swapped
+	unwrap := tokens[len(tokens)-1] == Native
+	recipient := common.HexToAddress(opts.Recipient)
+	if unwrap {
+		recipient = common.HexToAddress(d.Router)
+	}
+
+	method, params := "exactInput", interface{}(exactInputParams{encoded, recipient, opts.deadline(), amountIn, swap.Limit})
+	if len(path) == 2 {
+		method = "exactInputSingle"
+		params = exactInputSingleParams{path[0], path[1], big.NewInt(int64(fees[0])), recipient, opts.deadline(), amountIn, swap.Limit, new(big.Int)}
+	}
+
+	if !unwrap {
+		swap.Hash, err = d.router().transact(ctx, w, value, method, params)
+		return swap, err
+	}
+
+	swapData, err := v3RouterABI.Pack(method, params)
+	if err != nil {
+		return nil, err
+	}
+	unwrapData, err := v3RouterABI.Pack("unwrapWETH9", swap.Limit, common.HexToAddress(opts.Recipient))
+	if err != nil {
+		return nil, err
+	}
+	swap.Hash, err = d.router().transact(ctx, w, value, "multicall", [][]byte{swapData, unwrapData})
+	return swap, err
+}
+
+// Position is a V3 liquidity position NFT
+type Position struct {
+	ID        *big.Int
+	Token0    string
+	Token1    string
+	Fee       int
+	TickLower int
+	TickUpper int
+	Liquidity *big.Int
+	// Owed0 and Owed1 are the amounts collectable as of the last change
+	// of the position, not counting fees earned since
+	Owed0 *big.Int
+	Owed1 *big.Int
+}
+
+// PositionChange describes a V3 position change sent
+type PositionChange struct {
+	// ID is unknown for mints until the transaction is looked at
+	ID        *big.Int
+	Liquidity *big.Int
+	// Amount0 and Amount1 are the amounts expected to be deposited or
+	// withdrawn
+	Amount0 *big.Int
+	Amount1 *big.Int
+	Hash    string
+}
+
+// Position returns the position NFT id
+func (d *V3) Position(ctx context.Context, id *big.Int) (*Position, error) {
+	pm, err := d.positionManager()
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := pm.call(ctx, "positions", id)
+	if err != nil {
+		return nil, err
+	}
+	return &Position{
+		ID:        id,
+		Token0:    values[2].(common.Address).Hex(),
+		Token1:    values[3].(common.Address).Hex(),
+		Fee:       int(values[4].(*big.Int).Int64()),
+		TickLower: int(values[5].(*big.Int).Int64()),
+		TickUpper: int(values[6].(*big.Int).Int64()),
+		Liquidity: values[7].(*big.Int),
+		Owed0:     values[10].(*big.Int),
+		Owed1:     values[11].(*big.Int),
+	}, nil
+}
+
+// Positions returns the position NFTs held by owner
+func (d *V3) Positions(ctx context.Context, owner string) ([]*Position, error) {
+	pm, err := d.positionManager()
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := pm.callUint(ctx, "balanceOf", common.HexToAddress(owner))
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]*Position, 0, count.Int64())
+	for i := int64(0); i < count.Int64(); i++ {
+		id, err := pm.callUint(ctx, "tokenOfOwnerByIndex", common.HexToAddress(owner), big.NewInt(i))
+		if err != nil {
+			return nil, err
+		}
+		position, err := d.Position(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, position)
+	}
+	return positions, nil
+}
+
+// FullRange returns the widest ticks of the pools of fee
+func (d *V3) FullRange(ctx context.Context, fee int) (int, int, error) {
+	spacing, err := d.factory().call(ctx, "feeAmountTickSpacing", big.NewInt(int64(fee)))
+	if err != nil {
+		return 0, 0, err
+	}
+	step := int(spacing[0].(*big.Int).Int64())
+	if step <= 0 {
+		return 0, 0, fmt.Errorf("fee %d is not enabled", fee)
+	}
+	return -maxTick / step * step, maxTick / step * step, nil
+}
+
+// sqrtPrice returns the square root of the price of the pool of token0
+// and token1, as a Q64.96
+func (d *V3) sqrtPrice(ctx context.Context, token0 common.Address, token1 common.Address, fee int) (*big.Int, error) {
+	pool, err := d.factory().callAddress(ctx, "getPool", token0, token1, big.NewInt(int64(fee)))
+	if err != nil {
+		return nil, err
+	}
+	if pool == (common.Address{}) {
+		return nil, fmt.Errorf("no pool of %s and %s with fee %d", token0.Hex(), token1.Hex(), fee)
+	}
+
+	slot0, err := contract{d.Backend, pool.Hex(), v3PoolABI}.call(ctx, "slot0")
+	if err != nil {
+		return nil, err
+	}
+	return slot0[0].(*big.Int), nil
+}
+
+// deposit returns the liquidity and amounts a deposit of up to amount0
+// and amount1 between the ticks is expected to take
+func (d *V3) deposit(ctx context.Context, token0 common.Address, token1 common.Address, fee int, tickLower int, tickUpper int, amount0 *big.Int, amount1 *big.Int) (*PositionChange, error) {
+	price, err := d.sqrtPrice(ctx, token0, token1, fee)
+	if err != nil {
+		return nil, err
+	}
+	lower, upper := sqrtPriceAt(tickLower), sqrtPriceAt(tickUpper)
+
+	liquidity := liquidityFor(price, lower, upper, amount0, amount1)
+	used0, used1 := amountsFor(price, lower, upper, liquidity)
+	return &PositionChange{Liquidity: liquidity, Amount0: used0, Amount1: used1}, nil
+}
+
+type mintParams struct {
+	Token0         common.Address
+	Token1         common.Address
+	Fee            *big.Int
+	TickLower      *big.Int
+	TickUpper      *big.Int
+	Amount0Desired *big.Int
+	Amount1Desired *big.Int
+	Amount0Min     *big.Int
+	Amount1Min     *big.Int
+	Recipient      common.Address
+	Deadline       *big.Int
+}
+
+// Mint opens a position in the pool of tokenA and tokenB with fee,
+// between tickLower and tickUpper, depositing up to amountA and amountB
+func (d *V3) Mint(ctx context.Context, w *wallet.Wallet, tokenA string, tokenB string, fee int, tickLower int, tickUpper int, amountA *big.Int, amountB *big.Int, opts TradeOptions) (*PositionChange, error) {
+	opts = opts.withDefaults(w)
+	pm, err := d.positionManager()
+	if err != nil {
+		return nil, err
+	}
+	if tickLower >= tickUpper {
+		return nil, errors.New("the lower tick must be below the upper tick")
+	}
+
+	path, err := tokenPath(d.WMON, []string{tokenA, tokenB})
+	if err != nil {
+		return nil, err
+	}
+	// pools order their tokens by address
+	switch bytes.Compare(path[0].Bytes(), path[1].Bytes()) {
+	case 0:
+		return nil, errors.New("a position needs two different tokens")
+	case 1:
+		path[0], path[1] = path[1], path[0]
+		tokenA, tokenB, amountA, amountB = tokenB, tokenA, amountB, amountA
+	}
+
+	change, err := d.deposit(ctx, path[0], path[1], fee, tickLower, tickUpper, amountA, amountB)
+	if err != nil {
+		return nil, err
+	}
+	value, err := fund(ctx, d.Backend, w, pm.address, []string{tokenA, tokenB}, []*big.Int{amountA, amountB})
+	if err != nil {
+		return nil, err
+	}
+
+	params := mintParams{
+		Token0:         path[0],
+		Token1:         path[1],
+		Fee:            big.NewInt(int64(fee)),
+		TickLower:      big.NewInt(int64(tickLower)),
+		TickUpper:      big.NewInt(int64(tickUpper)),
+		Amount0Desired: amountA,
+		Amount1Desired: amountB,
+		Amount0Min:     withSlippage(change.Amount0, opts.Slippage),
+		Amount1Min:     withSlippage(change.Amount1, opts.Slippage),
+		Recipient:      common.HexToAddress(opts.Recipient),
+		Deadline:       opts.deadline(),
+	}
+	change.Hash, err = transactRefunding(ctx, pm, w, value, "mint", params)
+	return change, err
+}
+
+type increaseLiquidityParams struct {
+	TokenId        *big.Int
+	Amount0Desired *big.Int
+	Amount1Desired *big.Int
+	Amount0Min     *big.Int
+	Amount1Min     *big.Int
+	Deadline       *big.Int
+}
+
+// IncreaseLiquidity deposits up to amount0 and amount1, in the token
+// order of the pool, in position id
+func (d *V3) IncreaseLiquidity(ctx context.Context, w *wallet.Wallet, id *big.Int, amount0 *big.Int, amount1 *big.Int, opts TradeOptions) (*PositionChange, error) {
+	opts = opts.withDefaults(w)
+	pm, err := d.positionManager()
+	if err != nil {
+		return nil, err
+	}
+
+	position, err := d.Position(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	change, err := d.deposit(ctx, common.HexToAddress(position.Token0), common.HexToAddress(position.Token1),
+		position.Fee, position.TickLower, position.TickUpper, amount0, amount1)
+	if err != nil {
+		return nil, err
+	}
+	change.ID = id
+
+	if _, err := fund(ctx, d.Backend, w, pm.address, []string{position.Token0, position.Token1}, []*big.Int{amount0, amount1}); err != nil {
+		return nil, err
+	}
+
+	params := increaseLiquidityParams{
+		TokenId:        id,
+		Amount0Desired: amount0,
+		Amount1Desired: amount1,
+		Amount0Min:     withSlippage(change.Amount0, opts.Slippage),
+		Amount1Min:     withSlippage(change.Amount1, opts.Slippage),
+		Deadline:       opts.deadline(),
+	}
+	change.Hash, err = pm.transact(ctx, w, nil, "increaseLiquidity", params)
+	return change, err
+}
+
+type decreaseLiquidityParams struct {
+	TokenId    *big.Int
+	Liquidity  *big.Int
+	Amount0Min *big.Int
+	Amount1Min *big.Int
+	Deadline   *big.Int
+}
+
+// DecreaseLiquidity withdraws liquidity, all of it when nil, from
+// position id, for at least its amounts at the pool price less slippage.
+// The amounts are owed to the position until collected.
+func (d *V3) DecreaseLiquidity(ctx context.Context, w *wallet.Wallet, id *big.Int, liquidity *big.Int, opts TradeOptions) (*PositionChange, error) {
+	opts = opts.withDefaults(w)
+	pm, err := d.positionManager()
+	if err != nil {
+		return nil, err
+	}
+
+	position, err := d.Position(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if liquidity == nil {
+		liquidity = position.Liquidity
+	}
+	switch {
+	case liquidity.Sign() == 0:
+		return nil, errors.New("no liquidity to remove")
+	case liquidity.Cmp(position.Liquidity) > 0:
+		return nil, fmt.Errorf("only %s liquidity is held", position.Liquidity)
+	}
+
+	price, err := d.sqrtPrice(ctx, common.HexToAddress(position.Token0), common.HexToAddress(position.Token1), position.Fee)
+	if err != nil {
+		return nil, err
+	}
+	amount0, amount1 := amountsFor(price, sqrtPriceAt(position.TickLower), sqrtPriceAt(position.TickUpper), liquidity)
+	change := &PositionChange{ID: id, Liquidity: liquidity, Amount0: amount0, Amount1: amount1}
+
+	params := decreaseLiquidityParams{
+		TokenId:    id,
+		Liquidity:  liquidity,
+		Amount0Min: withSlippage(change.Amount0, opts.Slippage),
+		Amount1Min: withSlippage(change.Amount1, opts.Slippage),
+		Deadline:   opts.deadline(),
+	}
+	change.Hash, err = pm.transact(ctx, w, nil, "decreaseLiquidity", params)
+	return change, err
+}
+
+type collectParams struct {
+	TokenId    *big.Int
+	Recipient  common.Address
+	Amount0Max *big.Int
+	Amount1Max *big.Int
+}
+
+// Collect sends everything owed to position id, withdrawn liquidity and
+// fees, to the recipient of opts
+func (d *V3) Collect(ctx context.Context, w *wallet.Wallet, id *big.Int, opts TradeOptions) (string, error) {
+	opts = opts.withDefaults(w)
+	pm, err := d.positionManager()
+	if err != nil {
+		return "", err
+	}
+	return pm.transact(ctx, w, nil, "collect", collectParams{id, common.HexToAddress(opts.Recipient), maxUint128, maxUint128})
+}
+
+// fund approves spender for the tokens, returning the MON to send along
+// for Native
+func fund(ctx context.Context, backend wallet.Backend, w *wallet.Wallet, spender string, tokens []string, amounts []*big.Int) (*big.Int, error) {
+	var value *big.Int
+	for i, token := range tokens {
+		if token == Native {
+			value = amounts[i]
+			continue
+		}
+		if _, err := Approve(ctx, backend, w, token, spender, amounts[i]); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// transactRefunding sends method, refunding the MON left over when value
+// is sent along
+func transactRefunding(ctx context.Context, c contract, w *wallet.Wallet, value *big.Int, method string, args ...interface{}) (string, error) {
+	if value == nil {
+		return c.transact(ctx, w, nil, method, args...)
+	}
+
+	data, err := c.abi.Pack(method, args...)
+	if err != nil {
+		return "", err
+	}
+	refund, err := c.abi.Pack("refundETH")
+	if err != nil {
+		return "", err
+	}
+	return c.transact(ctx, w, value, "multicall", [][]byte{data, refund})
+}
+
+// tickRatios are 2^128 / sqrt(1.0001)^bit for the bits of a tick, as in
+// the TickMath library of Uniswap V3
+var tickRatios = []string{
+	"fffcb933bd6fad37aa2d162d1a594001",
+	"fff97272373d413259a46990580e213a",
+	"fff2e50f5f656932ef12357cf3c7fdcc",
+	"ffe5caca7e10e4e61c3624eaa0941cd0",
+	"ffcb9843d60f6159c9db58835c926644",
+	"ff973b41fa98c081472e6896dfb254c0",
+	"ff2ea16466c96a3843ec78b326b52861",
+	"fe5dee046a99a2a811c461f1969c3053",
+	"fcbe86c7900a88aedcffc83b479aa3a4",
+	"f987a7253ac413176f2b074cf7815e54",
+	"f3392b0822b70005940c7a398e4b70f3",
+	"e7159475a2c29b7443b29c7fa6e889d9",
+	"d097f3bdfd2022b8845ad8f792aa5825",
+	"a9f746462d870fdf8a65dc1f90e061e5",
+	"70d869a156d2a1b890bb3df62baf32f7",
+	"31be135f97d08fd981231505542fcfa6",
+	"9aa508b5b7a84e1c677de54f3e99bc9",
+	"5d6af8dedb81196699c329225ee604",
+	"2216e584f5fa1ea926041bedfe98",
+	"48a170391f7dc42444e8fa2",
+}
+
+// sqrtPriceAt returns the square root of the price at tick as a Q64.96,
+// the same value the pool computes
+func sqrtPriceAt(tick int) *big.Int {
+	abs := tick
+	if abs < 0 {
+		abs = -abs
+	}
+
+	ratio := new(big.Int).Lsh(big.NewInt(1), 128)
+	for bit, hex := range tickRatios {
+		if abs&(1<<bit) != 0 {
+			factor, _ := new(big.Int).SetString(hex, 16)
+			ratio.Rsh(ratio.Mul(ratio, factor), 128)
+		}
+	}
+	if tick > 0 {
+		maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+		ratio.Quo(maxUint256, ratio)
+	}
+
+	// from Q128.128 to Q64.96, rounding up
+	price := new(big.Int).Rsh(ratio, 32)
+	if ratio.Cmp(new(big.Int).Lsh(price, 32)) != 0 {
+		price.Add(price, big.NewInt(1))
+	}
+	return price
+}
+
+// liquidityFor returns the most liquidity amount0 and amount1 provide
+// between the square root prices lower and upper, at price. Like the
+// position manager it rounds down.
+func liquidityFor(price *big.Int, lower *big.Int, upper *big.Int, amount0 *big.Int, amount1 *big.Int) *big.Int {
+	liquidity0 := func(lower, upper *big.Int) *big.Int {
+		return mulDiv(amount0, mulDiv(lower, upper, q96), new(big.Int).Sub(upper, lower))
+	}
+	liquidity1 := func(lower, upper *big.Int) *big.Int {
+		return mulDiv(amount1, q96, new(big.Int).Sub(upper, lower))
+	}
+
+	switch {
+	case price.Cmp(lower) <= 0:
+		return liquidity0(lower, upper)
+	case price.Cmp(upper) >= 0:
+		return liquidity1(lower, upper)
+	default:
+		l0, l1 := liquidity0(price, upper), liquidity1(lower, price)
+		if l0.Cmp(l1) < 0 {
+			return l0
+		}
+		return l1
+	}
+}
+
+// amountsFor returns the amounts liquidity is worth between the square
+// root prices lower and upper, at price. They are rounded down, never
+// more than the pool pays out or takes in.
+func amountsFor(price *big.Int, lower *big.Int, upper *big.Int, liquidity *big.Int) (*big.Int, *big.Int) {
+	amount0 := func(lower, upper *big.Int) *big.Int {
+		scaled := new(big.Int).Lsh(liquidity, 96)
+		return new(big.Int).Quo(mulDiv(scaled, new(big.Int).Sub(upper, lower), upper), lower)
+	}
+	amount1 := func(lower, upper *big.Int) *big.Int {
+		return mulDiv(liquidity, new(big.Int).Sub(upper, lower), q96)
+	}
+
+	switch {
+	case price.Cmp(lower) <= 0:
+		return amount0(lower, upper), new(big.Int)
+	case price.Cmp(upper) >= 0:
+		return new(big.Int), amount1(lower, upper)
+	default:
+		return amount0(price, upper), amount1(lower, price)
+	}
+}
+
+// mulDiv returns a * b / c, rounded down
+func mulDiv(a *big.Int, b *big.Int, c *big.Int) *big.Int {
+	return new(big.Int).Quo(new(big.Int).Mul(a, b), c)
+}
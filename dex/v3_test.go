@@ -0,0 +1,274 @@
+package dex
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/galihrivanto/omonOmon/wallet"
+	"github.com/galihrivanto/omonOmon/wallet/wallettest"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testQuoter          = "0x7777777777777777777777777777777777777777"
+	testPositionManager = "0x8888888888888888888888888888888888888888"
+	testPool            = "0x9999999999999999999999999999999999999999"
+)
+
+// sqrtPriceX96 of a price of 1
+var priceOne = new(big.Int).Lsh(big.NewInt(1), 96)
+
+func testV3(backend wallet.Backend) *V3 {
+	return &V3{
+		Router:          testRouter,
+		Factory:         testFactory,
+		Quoter:          testQuoter,
+		PositionManager: testPositionManager,
+		WMON:            testWMON,
+		Backend:         backend,
+	}
+}
+
+// unpackCalls decodes the calls of a router multicall
+func unpackCalls(t *testing.T, contractABI abi.ABI, multicall wallettest.Tx) []wallettest.Tx {
+	var calls []wallettest.Tx
+	for _, data := range multicall.Args[0].([][]byte) {
+		method, err := contractABI.MethodById(data[:4])
+		if err != nil {
+			t.Fatal(err)
+		}
+		args, err := method.Inputs.Unpack(data[4:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		calls = append(calls, wallettest.Tx{Method: method.Name, Args: args})
+	}
+	return calls
+}
+
+func TestV3Route(t *testing.T) {
+	d := testV3(nil)
+
+	_, fees, path, err := d.route([]string{testUSDC, testDAI, Native}, []int{500, 3000})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{500, 3000}, fees)
+	assert.Equal(t, strings.Repeat("44", 20)+"0001f4"+strings.Repeat("66", 20)+"000bb8"+strings.Repeat("33", 20), hex.EncodeToString(path))
+
+	_, fees, _, err = d.route([]string{testUSDC, testDAI, testWMON}, []int{100})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{100, 100}, fees)
+
+	_, _, _, err = d.route([]string{testUSDC, testDAI, testWMON}, []int{100, 500, 3000})
+	assert.EqualError(t, err, "a path of 3 tokens needs 2 fees")
+
+	_, _, _, err = d.route([]string{testUSDC, testDAI}, []int{1 << 24})
+	assert.EqualError(t, err, "invalid fee 16777216")
+}
+
+func TestV3SwapExactIn(t *testing.T) {
+	recipient := common.HexToAddress(testWallet.Address)
+
+	t.Run("single hop", func(t *testing.T) {
+		backend := newFakeBackend(map[string][]interface{}{
+			"quoteExactInput": {big.NewInt(2000), []*big.Int{}, []uint32{}, big.NewInt(0)},
+			"allowance":       {big.NewInt(0)},
+		})
+
+		swap, err := testV3(backend).SwapExactIn(context.Background(), testWallet, []string{testUSDC, testDAI}, []int{3000}, big.NewInt(1000), TradeOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, big.NewInt(1990), swap.Limit)
+
+		assert.Len(t, backend.Sent, 2)
+		assert.Equal(t, "approve", backend.Sent[0].Method)
+		assert.Equal(t, "exactInputSingle", backend.Sent[1].Method)
+		assert.Nil(t, backend.Sent[1].Value)
+
+		params := abi.ConvertType(backend.Sent[1].Args[0], new(exactInputSingleParams)).(*exactInputSingleParams)
+		assert.Equal(t, common.HexToAddress(testUSDC), params.TokenIn)
+		assert.Equal(t, common.HexToAddress(testDAI), params.TokenOut)
+		assert.Equal(t, big.NewInt(3000), params.Fee)
+		assert.Equal(t, recipient, params.Recipient)
+		assert.Equal(t, big.NewInt(1000), params.AmountIn)
+		assert.Equal(t, big.NewInt(1990), params.AmountOutMinimum)
+	})
+
+	t.Run("to MON", func(t *testing.T) {
+		backend := newFakeBackend(map[string][]interface{}{
+			"quoteExactInput": {big.NewInt(2000), []*big.Int{}, []uint32{}, big.NewInt(0)},
+			"allowance":       {big.NewInt(1000)},
+		})
+
+		_, err := testV3(backend).SwapExactIn(context.Background(), testWallet, []string{testUSDC, testDAI, Native}, []int{500}, big.NewInt(1000), TradeOptions{})
+		assert.NoError(t, err)
+
+		// the router receives WMON and unwraps it for the wallet
+		assert.Len(t, backend.Sent, 1)
+		assert.Equal(t, "multicall", backend.Sent[0].Method)
+		calls := unpackCalls(t, v3RouterABI, backend.Sent[0])
+		assert.Len(t, calls, 2)
+
+		assert.Equal(t, "exactInput", calls[0].Method)
+		params := abi.ConvertType(calls[0].Args[0], new(exactInputParams)).(*exactInputParams)
+		assert.Equal(t, common.HexToAddress(testRouter), params.Recipient)
+		assert.Len(t, params.Path, 66)
+
+		assert.Equal(t, wallettest.Tx{Method: "unwrapWETH9", Args: []interface{}{big.NewInt(1990), recipient}}, calls[1])
+	})
+
+	t.Run("from MON", func(t *testing.T) {
+		backend := newFakeBackend(map[string][]interface{}{
+			"quoteExactInput": {big.NewInt(2000), []*big.Int{}, []uint32{}, big.NewInt(0)},
+		})
+
+		_, err := testV3(backend).SwapExactIn(context.Background(), testWallet, []string{Native, testDAI}, []int{500}, big.NewInt(1000), TradeOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, backend.Sent, 1)
+		assert.Equal(t, "exactInputSingle", backend.Sent[0].Method)
+		assert.Equal(t, big.NewInt(1000), backend.Sent[0].Value)
+	})
+}
+
+func TestV3Mint(t *testing.T) {
+	backend := newFakeBackend(map[string][]interface{}{
+		"getPool":   {common.HexToAddress(testPool)},
+		"slot0":     {priceOne, big.NewInt(0)},
+		"allowance": {big.NewInt(0)},
+	})
+
+	// DAI sorts after MON's WMON, the amounts follow
+	change, err := testV3(backend).Mint(context.Background(), testWallet, testDAI, Native, 3000, -600, 600, big.NewInt(1000000), big.NewInt(2000000), TradeOptions{Slippage: 100})
+	assert.NoError(t, err)
+
+	// at a price of 1 a symmetric range takes equal amounts
+	assert.InDelta(t, 1000000, change.Amount0.Int64(), 1)
+	assert.InDelta(t, 1000000, change.Amount1.Int64(), 1)
+
+	assert.Len(t, backend.Sent, 2)
+	assert.Equal(t, wallettest.Tx{To: testDAI, Method: "approve", Args: []interface{}{common.HexToAddress(testPositionManager), big.NewInt(1000000)}}, backend.Sent[0])
+
+	mint := backend.Sent[1]
+	assert.Equal(t, "multicall", mint.Method)
+	assert.Equal(t, big.NewInt(2000000), mint.Value)
+	calls := unpackCalls(t, v3PositionManagerABI, mint)
+	assert.Equal(t, "refundETH", calls[1].Method)
+
+	params := abi.ConvertType(calls[0].Args[0], new(mintParams)).(*mintParams)
+	assert.Equal(t, common.HexToAddress(testWMON), params.Token0)
+	assert.Equal(t, common.HexToAddress(testDAI), params.Token1)
+	assert.Equal(t, big.NewInt(-600), params.TickLower)
+	assert.Equal(t, big.NewInt(2000000), params.Amount0Desired)
+	assert.Equal(t, big.NewInt(1000000), params.Amount1Desired)
+	assert.InDelta(t, 990000, params.Amount0Min.Int64(), 1)
+	assert.InDelta(t, 990000, params.Amount1Min.Int64(), 1)
+
+	_, err = testV3(backend).Mint(context.Background(), testWallet, testDAI, testUSDC, 3000, 600, -600, big.NewInt(1), big.NewInt(1), TradeOptions{})
+	assert.EqualError(t, err, "the lower tick must be below the upper tick")
+}
+
+func TestV3Positions(t *testing.T) {
+	backend := newFakeBackend(map[string][]interface{}{
+		"balanceOf":           {big.NewInt(1)},
+		"tokenOfOwnerByIndex": {big.NewInt(7)},
+		"positions": {
+			big.NewInt(0), common.Address{}, common.HexToAddress(testUSDC), common.HexToAddress(testDAI),
+			big.NewInt(500), big.NewInt(-120), big.NewInt(60), big.NewInt(5000),
+			big.NewInt(0), big.NewInt(0), big.NewInt(3), big.NewInt(4),
+		},
+	})
+
+	positions, err := testV3(backend).Positions(context.Background(), testWallet.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Position{{
+		ID:        big.NewInt(7),
+		Token0:    common.HexToAddress(testUSDC).Hex(),
+		Token1:    common.HexToAddress(testDAI).Hex(),
+		Fee:       500,
+		TickLower: -120,
+		TickUpper: 60,
+		Liquidity: big.NewInt(5000),
+		Owed0:     big.NewInt(3),
+		Owed1:     big.NewInt(4),
+	}}, positions)
+
+	d := testV3(backend)
+	d.PositionManager = ""
+	_, err = d.Positions(context.Background(), testWallet.Address)
+	assert.EqualError(t, err, "positions need the positionManager address of the DEX")
+}
+
+func TestV3DecreaseLiquidity(t *testing.T) {
+	backend := newFakeBackend(map[string][]interface{}{
+		"positions": {
+			big.NewInt(0), common.Address{}, common.HexToAddress(testUSDC), common.HexToAddress(testDAI),
+			big.NewInt(500), big.NewInt(600), big.NewInt(1200), big.NewInt(1000000000),
+			big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0),
+		},
+		"getPool": {common.HexToAddress(testPool)},
+		"slot0":   {priceOne, big.NewInt(0)},
+	})
+
+	change, err := testV3(backend).DecreaseLiquidity(context.Background(), testWallet, big.NewInt(7), nil, TradeOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1000000000), change.Liquidity)
+	// below the range, the position is all token0
+	assert.Positive(t, change.Amount0.Sign())
+	assert.Zero(t, change.Amount1.Sign())
+
+	params := abi.ConvertType(backend.Sent[0].Args[0], new(decreaseLiquidityParams)).(*decreaseLiquidityParams)
+	assert.Equal(t, big.NewInt(7), params.TokenId)
+	assert.Equal(t, withSlippage(change.Amount0, defaultSlippage), params.Amount0Min)
+
+	_, err = testV3(backend).DecreaseLiquidity(context.Background(), testWallet, big.NewInt(7), big.NewInt(2000000000), TradeOptions{})
+	assert.EqualError(t, err, "only 1000000000 liquidity is held")
+}
+
+func TestV3DecreaseLiquidityNoSlippage(t *testing.T) {
+	backend := newFakeBackend(map[string][]interface{}{
+		"positions": {
+			big.NewInt(0), common.Address{}, common.HexToAddress(testUSDC), common.HexToAddress(testDAI),
+			big.NewInt(500), big.NewInt(-600), big.NewInt(600), big.NewInt(1000000000),
+			big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0),
+		},
+		"getPool": {common.HexToAddress(testPool)},
+		"slot0":   {priceOne, big.NewInt(0)},
+	})
+
+	_, err := testV3(backend).DecreaseLiquidity(context.Background(), testWallet, big.NewInt(7), nil, TradeOptions{Slippage: NoSlippage})
+	assert.NoError(t, err)
+
+	// the minimums are what the pool pays out, rounded down like it does
+	params := abi.ConvertType(backend.Sent[0].Args[0], new(decreaseLiquidityParams)).(*decreaseLiquidityParams)
+	assert.Equal(t, big.NewInt(29553010), params.Amount0Min)
+	assert.Equal(t, big.NewInt(29553010), params.Amount1Min)
+}
+
+func TestSqrtPriceAt(t *testing.T) {
+	tests := []struct {
+		tick  int
+		price string
+	}{
+		{0, "79228162514264337593543950336"},
+		{600, "81640896826356156310682304526"},
+		{-600, "76886731765546235930195592750"},
+		{-maxTick, "4295128739"},
+		{maxTick, "1461446703485210103287273052203988822378723970342"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.price, sqrtPriceAt(test.tick).String(), test.tick)
+	}
+}
+
+func TestV3FullRange(t *testing.T) {
+	backend := newFakeBackend(map[string][]interface{}{"feeAmountTickSpacing": {big.NewInt(60)}})
+
+	lower, upper, err := testV3(backend).FullRange(context.Background(), 3000)
+	assert.NoError(t, err)
+	assert.Equal(t, -887220, lower)
+	assert.Equal(t, 887220, upper)
+}
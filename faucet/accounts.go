@@ -0,0 +1,54 @@
+package faucet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/galihrivanto/omonOmon/wallet"
+)
+
+// LoadAccounts reads the addresses to claim for. path is either a file
+// listing one address or private key per line, where blank lines and
+// lines starting with # are ignored, or a directory of wallet files as
+// written by wallet generate.
+func LoadAccounts(path string) ([]string, error) {
+	wallets, err := wallet.LoadWallets(path)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]string, len(wallets))
+	for i, w := range wallets {
+		addresses[i] = w.Address
+	}
+	return addresses, nil
+}
+
+// parseAccount accepts an address or a hex private key and returns the
+// checksummed address
+func parseAccount(s string) (string, error) {
+	if common.IsHexAddress(s) {
+		return common.HexToAddress(s).Hex(), nil
+	}
+
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return "", fmt.Errorf("not an address or private key")
+	}
+	return crypto.PubkeyToAddress(privateKey.PublicKey).Hex(), nil
+}
+
+func dedupe(addresses []string) []string {
+	seen := make(map[string]bool, len(addresses))
+	kept := addresses[:0]
+	for _, address := range addresses {
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+		kept = append(kept, address)
+	}
+	return kept
+}
@@ -0,0 +1,47 @@
+package faucet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testPrivateKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	testKeyAddress = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	testAddress    = "0x8ba1f109551bD432803012645Ac136ddd64DBA72"
+)
+
+func TestLoadAccountsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.txt")
+	content := "# team wallets\n" +
+		testAddress + "\n" +
+		"\n" +
+		"0x" + testPrivateKey + "\n" +
+		"0x8ba1f109551bd432803012645ac136ddd64dba72\n"
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	addresses, err := LoadAccounts(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{testAddress, testKeyAddress}, addresses)
+}
+
+func TestLoadAccountsFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.txt")
+	assert.NoError(t, os.WriteFile(path, []byte(testAddress+"\nnot-an-account\n"), 0644))
+
+	_, err := LoadAccounts(path)
+	assert.ErrorContains(t, err, "accounts.txt:2")
+}
+
+func TestLoadAccountsDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "wallet1"), []byte(testPrivateKey), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden"), []byte("ignored"), 0644))
+
+	addresses, err := LoadAccounts(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{testKeyAddress}, addresses)
+}
@@ -1,43 +1,63 @@
 package faucet
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
-	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/proto"
-	"github.com/go-rod/stealth"
 )
 
-type AprioFaucetClaimer struct{}
+const aprioURL = "https://stake.apr.io/faucet"
 
-func (f *AprioFaucetClaimer) Claim(address string) error {
+type AprioFaucetClaimer struct {
+	browserClaimer
+}
+
+func (f *AprioFaucetClaimer) Claim(ctx context.Context, address string) *Result {
 	if address == "" {
-		return errors.New("address is required")
+		return failed(errors.New("address is required"))
 	}
 
-	browser := rod.New()
+	fmt.Println("Connecting...")
+	browser, err := f.browser.Launch(ctx)
+	if err != nil {
+		return f.fail(nil, nil, "launch browser", err)
+	}
 	defer browser.Close()
 
-	page, err := stealth.Page(browser)
+	page, err := browser.Page(ctx, aprioURL)
 	if err != nil {
-		return err
+		return f.fail(browser, nil, "open page", err)
 	}
 
 	fmt.Println("Navigating to faucet...")
-	err = page.Navigate("https://stake.apr.io/faucet")
+	err = page.Timeout(f.stepTimeout()).Navigate(aprioURL)
 	if err != nil {
-		return err
+		return f.fail(browser, page, "navigate", err)
+	}
+
+	if result := f.captcha(browser, page, "navigate"); result != nil {
+		return result
 	}
 
-	button, err := page.Element("main button")
+	button, err := page.Timeout(f.stepTimeout()).Element("main button")
 	if err != nil {
-		return err
+		return f.fail(browser, page, "find claim button", err)
+	}
+
+	if err := button.Timeout(f.stepTimeout()).Click(proto.InputMouseButtonLeft, 1); err != nil {
+		return f.fail(browser, page, "click claim button", err)
+	}
+
+	if result := f.captcha(browser, page, "click claim button"); result != nil {
+		return result
 	}
 
-	return button.Click(proto.InputMouseButtonLeft, 1)
+	fmt.Println("Waiting for result...")
+	return f.capture(browser, page, "check result", pageOutcome(page, defaultToastWait))
 }
 
 func init() {
-	factories["aprio"] = func() FaucetClaimer { return &AprioFaucetClaimer{} }
+	Register("aprio", func() FaucetClaimer { return &AprioFaucetClaimer{} })
 }
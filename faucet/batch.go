@@ -0,0 +1,187 @@
+package faucet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// BatchOptions controls ClaimBatch
+type BatchOptions struct {
+	// Workers is the number of claims, and so browsers, running at once
+	Workers int
+	// MinDelay and MaxDelay bound the random delay a worker waits between claims
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	// Cooldown is how long an address is skipped after a claim or a rate limit
+	Cooldown time.Duration
+	// State records cooldowns, nil disables them
+	State *State
+	// History records every claim attempt when set
+	History *History
+	// Claim is used for every claim
+	Claim ClaimOptions
+	// OnResult is called from the worker goroutines after each address
+	OnResult func(*Result)
+}
+
+func (o BatchOptions) withDefaults() BatchOptions {
+	if o.Workers <= 0 {
+		o.Workers = 1
+	}
+	if o.MaxDelay < o.MinDelay {
+		o.MaxDelay = o.MinDelay
+	}
+	if o.Cooldown <= 0 {
+		o.Cooldown = 24 * time.Hour
+	}
+	if o.OnResult == nil {
+		o.OnResult = func(*Result) {}
+	}
+	return o
+}
+
+// randomDelay returns a duration between MinDelay and MaxDelay
+func (o BatchOptions) randomDelay() time.Duration {
+	if o.MaxDelay <= o.MinDelay {
+		return o.MinDelay
+	}
+	return o.MinDelay + rand.N(o.MaxDelay-o.MinDelay+1)
+}
+
+// ClaimBatch claims faucetName for every address with a pool of workers.
+// Addresses in cooldown are skipped, the results are in address order.
+func ClaimBatch(ctx context.Context, faucetName string, addresses []string, opts BatchOptions) ([]*Result, error) {
+	if _, err := New(faucetName); err != nil {
+		return nil, err
+	}
+	opts = opts.withDefaults()
+
+	if opts.Workers > 1 && opts.Claim.Browser.UserDataDir != "" {
+		return nil, errors.New("a browser user data dir can not be shared by several workers")
+	}
+
+	results := make([]*Result, len(addresses))
+	jobs := make(chan int)
+
+	var (
+		wg    sync.WaitGroup
+		errMu sync.Mutex
+		errs  []error
+	)
+
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+
+			// the first worker starts right away, the others are staggered
+			wait := worker > 0
+			for index := range jobs {
+				address := addresses[index]
+
+				if wait && !sleep(ctx, opts.randomDelay()) {
+					results[index] = skipped(faucetName, address, "cancelled")
+					opts.OnResult(results[index])
+					continue
+				}
+				wait = true
+
+				result := claim(ctx, faucetName, address, opts.Claim)
+				results[index] = result
+
+				if err := record(opts.State, opts.History, opts.Cooldown, result); err != nil {
+					errMu.Lock()
+					errs = append(errs, err)
+					errMu.Unlock()
+				}
+
+				opts.OnResult(result)
+			}
+		}(i)
+	}
+
+	for index, address := range addresses {
+		if opts.State != nil {
+			if eligible, until := opts.State.Eligible(faucetName, address, time.Now()); !eligible {
+				results[index] = skipped(faucetName, address, fmt.Sprintf("cooldown until %s", until.Format(time.RFC3339)))
+				opts.OnResult(results[index])
+				continue
+			}
+		}
+
+		if ctx.Err() != nil {
+			results[index] = skipped(faucetName, address, "cancelled")
+			opts.OnResult(results[index])
+			continue
+		}
+
+		select {
+		case jobs <- index:
+		case <-ctx.Done():
+			results[index] = skipped(faucetName, address, "cancelled")
+			opts.OnResult(results[index])
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return results, errs[0]
+	}
+	return results, nil
+}
+
+// claim runs a single claim, always returning a result
+func claim(ctx context.Context, faucetName string, address string, opts ClaimOptions) *Result {
+	result, err := ClaimWithOptions(ctx, faucetName, address, opts)
+	if result == nil {
+		result = failed(err)
+		result.Faucet = faucetName
+		result.Address = address
+	}
+	return result
+}
+
+// record starts the cooldown of claimed and rate limited addresses and
+// appends the result to the history, state and history may be nil
+func record(state *State, history *History, cooldown time.Duration, result *Result) error {
+	if state != nil && (result.Status == StatusClaimed || result.Status == StatusRateLimited) {
+		state.SetCooldown(result.Faucet, result.Address, time.Now().Add(cooldown))
+		if err := state.Save(); err != nil {
+			return fmt.Errorf("failed to save state: %v", err)
+		}
+	}
+
+	if history != nil {
+		if err := history.Record(result); err != nil {
+			return fmt.Errorf("failed to record history: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func skipped(faucetName string, address string, reason string) *Result {
+	return &Result{Faucet: faucetName, Address: address, Status: StatusSkipped, Reason: reason}
+}
+
+// sleep waits for d and reports false when ctx was cancelled first
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
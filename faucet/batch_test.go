@@ -0,0 +1,94 @@
+package faucet
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClaimer returns a fixed status per address and records the claims
+type fakeClaimer struct {
+	mu       sync.Mutex
+	statuses map[string]Status
+	claimed  []string
+}
+
+func (f *fakeClaimer) Claim(ctx context.Context, address string) *Result {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.claimed = append(f.claimed, address)
+	return &Result{Status: f.statuses[address]}
+}
+
+func TestClaimBatch(t *testing.T) {
+	claimer := &fakeClaimer{statuses: map[string]Status{
+		"0x1": StatusClaimed,
+		"0x2": StatusRateLimited,
+		"0x3": StatusFailed,
+		"0x4": StatusClaimed,
+	}}
+	Register("batch-test", func() FaucetClaimer { return claimer })
+
+	state, err := LoadState(filepath.Join(t.TempDir(), "state.json"))
+	assert.NoError(t, err)
+	state.SetCooldown("batch-test", "0x4", time.Now().Add(time.Hour))
+
+	var (
+		mu       sync.Mutex
+		reported int
+	)
+	opts := BatchOptions{
+		Workers:  2,
+		MaxDelay: 10 * time.Millisecond,
+		Cooldown: time.Hour,
+		State:    state,
+		OnResult: func(*Result) {
+			mu.Lock()
+			reported++
+			mu.Unlock()
+		},
+	}
+
+	addresses := []string{"0x1", "0x2", "0x3", "0x4"}
+	results, err := ClaimBatch(context.Background(), "batch-test", addresses, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, reported)
+
+	assert.Equal(t, StatusClaimed, results[0].Status)
+	assert.Equal(t, StatusRateLimited, results[1].Status)
+	assert.Equal(t, StatusFailed, results[2].Status)
+	assert.Equal(t, StatusSkipped, results[3].Status)
+	assert.Equal(t, "0x2", results[1].Address)
+	assert.ElementsMatch(t, []string{"0x1", "0x2", "0x3"}, claimer.claimed)
+
+	// claimed and rate limited addresses are now in cooldown, failures are retried
+	for address, want := range map[string]bool{"0x1": false, "0x2": false, "0x3": true} {
+		eligible, _ := state.Eligible("batch-test", address, time.Now())
+		assert.Equal(t, want, eligible, address)
+	}
+}
+
+func TestClaimBatchCancelled(t *testing.T) {
+	claimer := &fakeClaimer{statuses: map[string]Status{}}
+	Register("batch-cancel-test", func() FaucetClaimer { return claimer })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := ClaimBatch(ctx, "batch-cancel-test", []string{"0x1", "0x2"}, BatchOptions{})
+	assert.NoError(t, err)
+	for _, result := range results {
+		assert.Equal(t, StatusSkipped, result.Status)
+	}
+	assert.Empty(t, claimer.claimed)
+}
+
+func TestClaimBatchUnknownFaucet(t *testing.T) {
+	_, err := ClaimBatch(context.Background(), "does-not-exist", []string{"0x1"}, BatchOptions{})
+	assert.Error(t, err)
+}
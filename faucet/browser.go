@@ -0,0 +1,339 @@
+package faucet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/galihrivanto/omonOmon/dapp"
+	"github.com/galihrivanto/omonOmon/wallet"
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/launcher/flags"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/stealth"
+)
+
+// BrowserOptions configures the browser used by browser based claimers
+type BrowserOptions struct {
+	// Headful shows the browser window, browsers are headless by default
+	Headful bool `yaml:"headful"`
+	// Bin is the Chrome binary, by default one is looked up or downloaded
+	Bin string `yaml:"bin"`
+	// UserDataDir keeps cookies and logins between claims, by default a
+	// temporary profile is used and removed afterwards
+	UserDataDir string `yaml:"userDataDir"`
+	// RemoteURL connects to a running browser through its DevTools URL
+	// instead of launching one, e.g. http://127.0.0.1:9222
+	RemoteURL string `yaml:"remoteURL"`
+	// WindowWidth and WindowHeight set the window and viewport size
+	WindowWidth  int    `yaml:"windowWidth"`
+	WindowHeight int    `yaml:"windowHeight"`
+	UserAgent    string `yaml:"userAgent"`
+	// Proxy routes the browser traffic through an http, https or socks5
+	// proxy URL, credentials are supported
+	Proxy string `yaml:"proxy"`
+	// SlowMotion delays every browser action, for debugging
+	SlowMotion time.Duration `yaml:"slowMotion"`
+
+	// artifacts is where a failed attempt saves its diagnostics, set per
+	// attempt from ClaimOptions.ArtifactsDir
+	artifacts string
+	// solver is ClaimOptions.CaptchaSolver
+	solver CaptchaSolver
+	// stepTimeout is ClaimOptions.StepTimeout
+	stepTimeout time.Duration
+	// wallet is ClaimOptions.Wallet, exposed to pages as window.ethereum
+	wallet *wallet.Wallet
+}
+
+// ParseWindowSize parses a WIDTHxHEIGHT window size
+func ParseWindowSize(size string) (int, int, error) {
+	width, height, ok := strings.Cut(strings.ToLower(size), "x")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid window size %q, expected WIDTHxHEIGHT", size)
+	}
+
+	w, err := strconv.Atoi(width)
+	if err != nil || w <= 0 {
+		return 0, 0, fmt.Errorf("invalid window width %q", width)
+	}
+	h, err := strconv.Atoi(height)
+	if err != nil || h <= 0 {
+		return 0, 0, fmt.Errorf("invalid window height %q", height)
+	}
+
+	return w, h, nil
+}
+
+// Browser is a launched or remote browser
+type Browser struct {
+	*rod.Browser
+
+	opts     BrowserOptions
+	launcher *launcher.Launcher
+	relay    *proxyRelay
+	pages    []*rod.Page
+	recorder *recorder
+}
+
+// closeTimeout bounds closing the browser, a hung browser is killed
+const closeTimeout = 10 * time.Second
+
+// Launch starts a browser, or connects to the remote one, as configured.
+// ctx bounds the launch only, the browser runs until Close. A launched
+// browser is killed as well when the process exits without closing it.
+func (o BrowserOptions) Launch(ctx context.Context) (*Browser, error) {
+	b := &Browser{opts: o}
+
+	var controlURL string
+	if o.RemoteURL != "" {
+		if o.Proxy != "" {
+			return nil, errors.New("a proxy can not be set for a remote browser")
+		}
+
+		u, err := launcher.ResolveURL(o.RemoteURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %v", o.RemoteURL, err)
+		}
+		controlURL = u
+	} else {
+		b.launcher = launcher.New().Context(ctx).Leakless(true).Headless(!o.Headful)
+		if o.Bin != "" {
+			b.launcher.Bin(o.Bin)
+		}
+		if o.UserDataDir != "" {
+			b.launcher.UserDataDir(o.UserDataDir)
+		}
+		if o.WindowWidth > 0 && o.WindowHeight > 0 {
+			b.launcher.Set(flags.Flag("window-size"), fmt.Sprintf("%d,%d", o.WindowWidth, o.WindowHeight))
+		}
+		if o.Proxy != "" {
+			server, err := b.proxyServer()
+			if err != nil {
+				return nil, err
+			}
+			b.launcher.Proxy(server)
+		}
+
+		u, err := b.launcher.Launch()
+		if err != nil {
+			// a browser started before ctx was cancelled has been killed
+			if b.launcher.PID() != 0 {
+				b.cleanup()
+			}
+			b.closeRelay()
+			return nil, fmt.Errorf("failed to launch browser: %v", err)
+		}
+		controlURL = u
+	}
+
+	b.Browser = rod.New().ControlURL(controlURL)
+	if o.SlowMotion > 0 {
+		b.Browser = b.Browser.SlowMotion(o.SlowMotion)
+	}
+
+	if err := b.Browser.Connect(); err != nil {
+		if b.launcher != nil {
+			b.launcher.Kill()
+			b.cleanup()
+		}
+		b.closeRelay()
+		return nil, fmt.Errorf("failed to connect to browser: %v", err)
+	}
+
+	return b, nil
+}
+
+// Page opens a stealth page with the configured user agent and viewport,
+// for the faucet at site. Every action on the page fails once ctx is done.
+func (b *Browser) Page(ctx context.Context, site string) (*rod.Page, error) {
+	page, err := stealth.Page(b.Browser.Context(ctx))
+	if err != nil {
+		return nil, err
+	}
+	// pages are closed and recorded independently of ctx
+	b.pages = append(b.pages, page.Context(context.Background()))
+
+	if b.opts.artifacts != "" {
+		b.recorder = newRecorder(page.Context(context.Background()))
+	}
+
+	if b.opts.wallet != nil {
+		if err := dapp.Inject(page, wallet.NewProvider(b.opts.wallet, approveFaucet(site))); err != nil {
+			return nil, err
+		}
+	}
+
+	if b.opts.UserAgent != "" {
+		err := page.SetUserAgent(&proto.NetworkSetUserAgentOverride{UserAgent: b.opts.UserAgent})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if b.opts.WindowWidth > 0 && b.opts.WindowHeight > 0 {
+		err := page.SetViewport(&proto.EmulationSetDeviceMetricsOverride{
+			Width:  b.opts.WindowWidth,
+			Height: b.opts.WindowHeight,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// Close closes a launched browser, for a remote browser only the pages
+// opened by Page are closed
+func (b *Browser) Close() error {
+	if b.launcher == nil {
+		for _, page := range b.pages {
+			page.Timeout(closeTimeout).Close()
+		}
+		return nil
+	}
+
+	err := b.Browser.Timeout(closeTimeout).Close()
+	if err != nil {
+		b.launcher.Kill()
+	}
+	b.cleanup()
+	b.closeRelay()
+	return err
+}
+
+// approveFaucet lets the pages of the faucet at site sign in with the
+// wallet. Typed data and transactions, which may spend from the wallet,
+// and the requests of other hosts are rejected.
+func approveFaucet(site string) wallet.ApproveFunc {
+	host := hostname(site)
+	return func(req *wallet.ApprovalRequest) (bool, error) {
+		if req.Method != wallet.MethodPersonalSign || host == "" || hostname(req.PeerMeta.URL) != host {
+			return false, nil
+		}
+		fmt.Println("Signing message from", req.PeerMeta.URL)
+		return true, nil
+	}
+}
+
+// hostname returns the lowercase host name of rawURL
+func hostname(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// proxyServer returns the --proxy-server of the browser, proxies with
+// credentials are reached through a local relay
+func (b *Browser) proxyServer() (string, error) {
+	proxy, err := ParseProxy(b.opts.Proxy)
+	if err != nil {
+		return "", err
+	}
+
+	if !proxy.hasAuth() {
+		return proxy.server(), nil
+	}
+
+	b.relay, err = startRelay(proxy)
+	if err != nil {
+		return "", fmt.Errorf("failed to start proxy relay: %v", err)
+	}
+	return b.relay.URL(), nil
+}
+
+func (b *Browser) closeRelay() {
+	if b.relay != nil {
+		b.relay.Close()
+	}
+}
+
+// cleanup waits for the launched browser to exit and removes its
+// temporary profile, a configured user data dir is kept
+func (b *Browser) cleanup() {
+	if b.opts.UserDataDir != "" {
+		return
+	}
+	b.launcher.Cleanup()
+}
+
+// browserClaimer holds the browser options of the browser based claimers
+type browserClaimer struct {
+	browser BrowserOptions
+}
+
+// SetBrowserOptions configures the browser used for claims
+func (c *browserClaimer) SetBrowserOptions(opts BrowserOptions) {
+	c.browser = opts
+}
+
+// fail builds the result of a failed step, saving the diagnostics of the
+// attempt when enabled. browser and page are nil when the step failed
+// before they were opened.
+func (c *browserClaimer) fail(browser *Browser, page *rod.Page, step string, err error) *Result {
+	result := &Result{Status: StatusFailed, Reason: fmt.Sprintf("%s: %v", step, err)}
+	return c.capture(browser, page, step, result)
+}
+
+// stepTimeout is the timeout of a single browser step
+func (c *browserClaimer) stepTimeout() time.Duration {
+	if c.browser.stepTimeout > 0 {
+		return c.browser.stepTimeout
+	}
+	return defaultStepTimeout
+}
+
+// captcha passes the captcha on page if there is one, returning the
+// captcha blocked result when it could not be passed
+func (c *browserClaimer) captcha(browser *Browser, page *rod.Page, step string) *Result {
+	result := passCaptcha(page.GetContext(), page, c.browser.solver)
+	if result == nil {
+		return nil
+	}
+	return c.capture(browser, page, step, result)
+}
+
+// capture saves the diagnostics of a failed result when enabled
+func (c *browserClaimer) capture(browser *Browser, page *rod.Page, step string, result *Result) *Result {
+	if result.Status != StatusFailed && result.Status != StatusCaptcha {
+		return result
+	}
+	result.Step = step
+
+	if c.browser.artifacts == "" {
+		return result
+	}
+
+	var rec *recorder
+	if browser != nil {
+		rec = browser.recorder
+	}
+
+	if page != nil {
+		// the claim ctx is done when its deadline expired, the artifacts
+		// are captured independently of it like pages are closed
+		page = page.Context(context.Background())
+	}
+
+	cause := errors.New(result.Reason)
+	if err := saveArtifacts(c.browser.artifacts, page, rec, step, cause); err != nil {
+		fmt.Println(err)
+	}
+	result.Artifacts = c.browser.artifacts
+
+	return result
+}
+
+// BrowserClaimer is a claimer driving a browser
+type BrowserClaimer interface {
+	FaucetClaimer
+	SetBrowserOptions(opts BrowserOptions)
+}
@@ -0,0 +1,151 @@
+package faucet
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/galihrivanto/omonOmon/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWindowSize(t *testing.T) {
+	tests := []struct {
+		size          string
+		width, height int
+		wantErr       bool
+	}{
+		{"1280x800", 1280, 800, false},
+		{"1920X1080", 1920, 1080, false},
+		{"1280", 0, 0, true},
+		{"x800", 0, 0, true},
+		{"1280x-1", 0, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.size, func(t *testing.T) {
+			width, height, err := ParseWindowSize(test.size)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.width, width)
+			assert.Equal(t, test.height, height)
+		})
+	}
+}
+
+func TestApproveFaucet(t *testing.T) {
+	approve := approveFaucet("https://faucet.example/claim")
+
+	tests := []struct {
+		name   string
+		method string
+		origin string
+		want   bool
+	}{
+		{"sign in", wallet.MethodPersonalSign, "https://faucet.example", true},
+		{"other host", wallet.MethodPersonalSign, "https://evil.example", false},
+		{"subdomain", wallet.MethodPersonalSign, "https://ads.faucet.example", false},
+		{"typed data", "eth_signTypedData_v4", "https://faucet.example", false},
+		{"transaction", wallet.MethodSendTransaction, "https://faucet.example", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			approved, err := approve(&wallet.ApprovalRequest{Method: test.method, PeerMeta: wallet.PeerMeta{URL: test.origin}})
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, approved)
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "faucet.yaml")
+	content := `
+browser:
+  headful: true
+  bin: /usr/bin/chromium
+  userDataDir: profile
+  windowWidth: 1280
+  windowHeight: 800
+  userAgent: test-agent
+  slowMotion: 500ms
+`
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, BrowserOptions{
+		Headful:      true,
+		Bin:          "/usr/bin/chromium",
+		UserDataDir:  "profile",
+		WindowWidth:  1280,
+		WindowHeight: 800,
+		UserAgent:    "test-agent",
+		SlowMotion:   500 * time.Millisecond,
+	}, config.Browser)
+}
+
+func TestClaimWithOptionsSetsBrowser(t *testing.T) {
+	claimer := &browserTestClaimer{}
+	Register("browser-test", func() FaucetClaimer { return claimer })
+
+	opts := ClaimOptions{
+		Browser:     BrowserOptions{RemoteURL: "http://127.0.0.1:9222"},
+		StepTimeout: 10 * time.Second,
+	}
+	_, err := ClaimWithOptions(context.Background(), "browser-test", testAddress, opts)
+	assert.NoError(t, err)
+
+	expected := opts.Browser
+	expected.stepTimeout = 10 * time.Second
+	assert.Equal(t, expected, claimer.browser)
+	assert.Equal(t, 10*time.Second, claimer.stepTimeout())
+}
+
+// blockingClaimer hangs until its context is done, like a stuck page
+type blockingClaimer struct {
+	deadline time.Time
+}
+
+func (c *blockingClaimer) Claim(ctx context.Context, address string) *Result {
+	c.deadline, _ = ctx.Deadline()
+	<-ctx.Done()
+	return &Result{Status: StatusFailed, Reason: "navigate: " + ctx.Err().Error(), Step: "navigate"}
+}
+
+func TestClaimWithOptionsTimeout(t *testing.T) {
+	claimer := &blockingClaimer{}
+	Register("timeout-test", func() FaucetClaimer { return claimer })
+
+	start := time.Now()
+	result, err := ClaimWithOptions(context.Background(), "timeout-test", testAddress, ClaimOptions{Timeout: 50 * time.Millisecond})
+	assert.Error(t, err)
+	assert.Equal(t, StatusFailed, result.Status)
+	assert.Equal(t, "timed out after 50ms: navigate: context deadline exceeded", result.Reason)
+	assert.WithinDuration(t, start.Add(50*time.Millisecond), claimer.deadline, 20*time.Millisecond)
+}
+
+func TestClaimWithOptionsCancelled(t *testing.T) {
+	Register("cancel-test", func() FaucetClaimer { return &blockingClaimer{} })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	result, err := ClaimWithOptions(ctx, "cancel-test", testAddress, ClaimOptions{})
+	assert.Error(t, err)
+	assert.Equal(t, StatusSkipped, result.Status)
+	assert.Equal(t, "cancelled during navigate", result.Reason)
+}
+
+type browserTestClaimer struct {
+	browserClaimer
+}
+
+func (c *browserTestClaimer) Claim(ctx context.Context, address string) *Result {
+	return &Result{Status: StatusClaimed}
+}
@@ -0,0 +1,257 @@
+package faucet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+const (
+	// captchaAutoWait is how long an invisible captcha may take to pass
+	// on its own before it is reported
+	captchaAutoWait     = 5 * time.Second
+	captchaPollInterval = time.Second
+	defaultCaptchaWait  = 3 * time.Minute
+)
+
+// CaptchaType is the captcha provider of a widget
+type CaptchaType string
+
+const (
+	CaptchaTurnstile CaptchaType = "turnstile"
+	CaptchaHCaptcha  CaptchaType = "hcaptcha"
+	CaptchaReCaptcha CaptchaType = "recaptcha"
+)
+
+// Captcha is a captcha widget found on a faucet page
+type Captcha struct {
+	Type    CaptchaType `json:"type"`
+	SiteKey string      `json:"sitekey"`
+	PageURL string      `json:"url"`
+	Solved  bool        `json:"solved"`
+}
+
+// CaptchaSolver solves the captcha shown on page
+type CaptchaSolver interface {
+	Solve(ctx context.Context, page *rod.Page, captcha *Captcha) error
+}
+
+// detectCaptchaJS looks for a captcha widget and whether its response
+// field is filled in
+const detectCaptchaJS = `() => {
+	const checks = [
+		['turnstile', '.cf-turnstile, iframe[src*="challenges.cloudflare.com"]', 'cf-turnstile-response'],
+		['hcaptcha', '.h-captcha, iframe[src*="hcaptcha.com"]', 'h-captcha-response'],
+		['recaptcha', '.g-recaptcha, iframe[src*="/recaptcha/"]', 'g-recaptcha-response'],
+	];
+	for (const [type, selector, field] of checks) {
+		const widget = document.querySelector(selector);
+		if (!widget) continue;
+
+		const holder = widget.closest('[data-sitekey]') || document.querySelector('[data-sitekey]');
+		let sitekey = holder ? holder.getAttribute('data-sitekey') : '';
+		if (!sitekey && widget.src) {
+			const match = widget.src.match(/[?&#]sitekey=([^&]+)/) || widget.src.match(/\/(0x[0-9A-Za-z_-]+)\//);
+			if (match) sitekey = decodeURIComponent(match[1]);
+		}
+
+		const response = document.querySelector('[name="' + field + '"]');
+		return {type, sitekey, url: location.href, solved: !!(response && response.value)};
+	}
+	return null;
+}`
+
+// injectCaptchaJS fills the response fields of a captcha with a token and
+// calls the widget callback, as the widget itself would once solved
+const injectCaptchaJS = `(type, token) => {
+	const fields = {
+		turnstile: ['cf-turnstile-response'],
+		hcaptcha: ['h-captcha-response', 'g-recaptcha-response'],
+		recaptcha: ['g-recaptcha-response'],
+	}[type] || [];
+	for (const name of fields) {
+		for (const el of document.querySelectorAll('[name="' + name + '"]')) {
+			el.value = token;
+			el.dispatchEvent(new Event('input', {bubbles: true}));
+			el.dispatchEvent(new Event('change', {bubbles: true}));
+		}
+	}
+
+	const widget = document.querySelector('[data-sitekey][data-callback]');
+	const callback = widget && window[widget.getAttribute('data-callback')];
+	if (typeof callback === 'function') callback(token);
+}`
+
+// DetectCaptcha returns the captcha widget on page, or nil without one
+func DetectCaptcha(page *rod.Page) (*Captcha, error) {
+	res, err := page.Eval(detectCaptchaJS)
+	if err != nil {
+		return nil, err
+	}
+	if res.Value.Nil() {
+		return nil, nil
+	}
+
+	var captcha Captcha
+	if err := res.Value.Unmarshal(&captcha); err != nil {
+		return nil, err
+	}
+	return &captcha, nil
+}
+
+// Inject hands a solver token to the page
+func (c *Captcha) Inject(page *rod.Page, token string) error {
+	_, err := page.Eval(injectCaptchaJS, string(c.Type), token)
+	return err
+}
+
+// waitCaptchaSolved polls the page until the captcha response is filled in
+func waitCaptchaSolved(ctx context.Context, page *rod.Page, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		captcha, err := DetectCaptcha(page)
+		if err != nil {
+			return err
+		}
+		if captcha == nil || captcha.Solved {
+			return nil
+		}
+
+		if !sleep(ctx, captchaPollInterval) {
+			return fmt.Errorf("captcha not solved within %s", timeout)
+		}
+	}
+}
+
+// ManualCaptchaSolver waits for the captcha to be solved by hand in a
+// headful browser window
+type ManualCaptchaSolver struct {
+	// Timeout bounds the wait, defaults to 3 minutes
+	Timeout time.Duration
+	// Out receives the solve instructions, defaults to stdout
+	Out io.Writer
+}
+
+func (s *ManualCaptchaSolver) Solve(ctx context.Context, page *rod.Page, captcha *Captcha) error {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = defaultCaptchaWait
+	}
+	out := s.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	fmt.Fprintf(out, "Solve the %s captcha in the browser window within %s...\n", captcha.Type, timeout)
+	return waitCaptchaSolved(ctx, page, timeout)
+}
+
+// HTTPCaptchaSolver gets captcha tokens from an HTTP solver service. The
+// captcha is POSTed to Endpoint as JSON, {"type", "sitekey", "url"}, and
+// the service answers {"token"} or {"error"}.
+type HTTPCaptchaSolver struct {
+	Endpoint string
+	// APIKey is sent as a bearer token when set
+	APIKey string
+	// Timeout bounds a solve, defaults to 3 minutes
+	Timeout time.Duration
+	Client  *http.Client
+}
+
+func (s *HTTPCaptchaSolver) Solve(ctx context.Context, page *rod.Page, captcha *Captcha) error {
+	token, err := s.Token(ctx, captcha)
+	if err != nil {
+		return err
+	}
+	return captcha.Inject(page, token)
+}
+
+// Token asks the solver service for a token solving captcha
+func (s *HTTPCaptchaSolver) Token(ctx context.Context, captcha *Captcha) (string, error) {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = defaultCaptchaWait
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, err := json.Marshal(captcha)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach captcha solver: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var answer struct {
+		Token string `json:"token"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&answer); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("invalid captcha solver response: %v", err)
+	}
+
+	switch {
+	case answer.Error != "":
+		return "", fmt.Errorf("captcha solver: %s", answer.Error)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("captcha solver: %s", resp.Status)
+	case answer.Token == "":
+		return "", errors.New("captcha solver returned no token")
+	}
+
+	return answer.Token, nil
+}
+
+// passCaptcha checks page for an unsolved captcha and has the solver pass
+// it. It returns nil when there is no captcha left, or the captcha
+// required result otherwise.
+func passCaptcha(ctx context.Context, page *rod.Page, solver CaptchaSolver) *Result {
+	captcha, err := DetectCaptcha(page)
+	if err != nil || captcha == nil || captcha.Solved {
+		return nil
+	}
+
+	// invisible challenges often pass on their own
+	if waitCaptchaSolved(ctx, page, captchaAutoWait) == nil {
+		return nil
+	}
+
+	if solver == nil {
+		return &Result{Status: StatusCaptcha, Reason: fmt.Sprintf("%s captcha on %s", captcha.Type, captcha.PageURL)}
+	}
+
+	fmt.Printf("Solving %s captcha...\n", captcha.Type)
+	if err := solver.Solve(ctx, page, captcha); err != nil {
+		return &Result{Status: StatusCaptcha, Reason: fmt.Sprintf("%s captcha not solved: %v", captcha.Type, err)}
+	}
+
+	return nil
+}
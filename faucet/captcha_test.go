@@ -0,0 +1,88 @@
+package faucet
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// solverStub stands in for a captcha solving service
+func solverStub(t *testing.T, handle func(captcha Captcha) (int, interface{})) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+
+		var captcha Captcha
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&captcha))
+
+		status, body := handle(captcha)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPCaptchaSolverToken(t *testing.T) {
+	captcha := &Captcha{Type: CaptchaTurnstile, SiteKey: "0x4AAAAAAA", PageURL: "https://faucet.test"}
+
+	tests := []struct {
+		name    string
+		status  int
+		body    interface{}
+		token   string
+		wantErr string
+	}{
+		{"token", http.StatusOK, map[string]string{"token": "solved-token"}, "solved-token", ""},
+		{"solver error", http.StatusOK, map[string]string{"error": "sitekey unsupported"}, "", "sitekey unsupported"},
+		{"http error", http.StatusServiceUnavailable, nil, "", "503"},
+		{"no token", http.StatusOK, map[string]string{}, "", "no token"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := solverStub(t, func(got Captcha) (int, interface{}) {
+				assert.Equal(t, *captcha, got)
+				return test.status, test.body
+			})
+
+			solver := &HTTPCaptchaSolver{Endpoint: server.URL, APIKey: "test-key"}
+			token, err := solver.Token(context.Background(), captcha)
+			if test.wantErr != "" {
+				assert.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.token, token)
+		})
+	}
+}
+
+func TestHTTPCaptchaSolverTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	solver := &HTTPCaptchaSolver{Endpoint: server.URL, Timeout: 50 * time.Millisecond}
+	_, err := solver.Token(context.Background(), &Captcha{Type: CaptchaHCaptcha})
+	assert.Error(t, err)
+}
+
+func TestClaimWithOptionsSetsCaptchaSolver(t *testing.T) {
+	claimer := &browserTestClaimer{}
+	Register("captcha-test", func() FaucetClaimer { return claimer })
+
+	solver := &ManualCaptchaSolver{}
+	_, err := ClaimWithOptions(context.Background(), "captcha-test", testAddress, ClaimOptions{CaptchaSolver: solver})
+	assert.NoError(t, err)
+	assert.Equal(t, solver, claimer.browser.solver)
+}
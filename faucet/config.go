@@ -0,0 +1,34 @@
+package faucet
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the faucet configuration file
+//
+//	browser:
+//	  headful: true
+//	  userDataDir: chrome-profile
+//	  windowWidth: 1280
+//	  windowHeight: 800
+type Config struct {
+	Browser BrowserOptions `yaml:"browser"`
+}
+
+// LoadConfig reads a YAML or JSON configuration file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: invalid config: %v", path, err)
+	}
+
+	return &config, nil
+}
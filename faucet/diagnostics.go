@@ -0,0 +1,303 @@
+package faucet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// recorder captures the console messages and network requests of a page,
+// so they can be saved when a claim fails
+type recorder struct {
+	mu       sync.Mutex
+	console  []string
+	requests []*harEntry
+	byID     map[proto.NetworkRequestID]*harEntry
+}
+
+func newRecorder(page *rod.Page) *recorder {
+	r := &recorder{byID: make(map[proto.NetworkRequestID]*harEntry)}
+
+	go page.EachEvent(
+		func(e *proto.RuntimeConsoleAPICalled) {
+			args := make([]string, len(e.Args))
+			for i, arg := range e.Args {
+				args[i] = remoteObjectString(arg)
+			}
+			r.log(string(e.Type), strings.Join(args, " "))
+		},
+		func(e *proto.RuntimeExceptionThrown) {
+			text := e.ExceptionDetails.Text
+			if e.ExceptionDetails.Exception != nil {
+				text += " " + remoteObjectString(e.ExceptionDetails.Exception)
+			}
+			r.log("exception", text)
+		},
+		func(e *proto.NetworkRequestWillBeSent) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			entry := &harEntry{
+				StartedDateTime: e.WallTime.Time(),
+				Request: harRequest{
+					Method:      e.Request.Method,
+					URL:         e.Request.URL,
+					HTTPVersion: "HTTP/1.1",
+					Headers:     harHeaders(e.Request.Headers),
+					QueryString: []harHeader{},
+					Cookies:     []harHeader{},
+					HeadersSize: -1,
+					BodySize:    -1,
+				},
+				Response: harResponse{
+					Headers:     []harHeader{},
+					Cookies:     []harHeader{},
+					HeadersSize: -1,
+					BodySize:    -1,
+				},
+				Timings: harTimings{Send: -1, Wait: -1, Receive: -1},
+				started: e.Timestamp.Duration(),
+			}
+			r.requests = append(r.requests, entry)
+			r.byID[e.RequestID] = entry
+		},
+		func(e *proto.NetworkResponseReceived) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			entry, ok := r.byID[e.RequestID]
+			if !ok {
+				return
+			}
+			entry.Response.Status = e.Response.Status
+			entry.Response.StatusText = e.Response.StatusText
+			entry.Response.HTTPVersion = e.Response.Protocol
+			entry.Response.Headers = harHeaders(e.Response.Headers)
+			entry.Response.Content.MimeType = e.Response.MIMEType
+		},
+		func(e *proto.NetworkLoadingFinished) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			if entry, ok := r.byID[e.RequestID]; ok {
+				entry.Response.Content.Size = int64(e.EncodedDataLength)
+				entry.finish(e.Timestamp.Duration())
+			}
+		},
+		func(e *proto.NetworkLoadingFailed) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			if entry, ok := r.byID[e.RequestID]; ok {
+				entry.Response.Error = e.ErrorText
+				entry.finish(e.Timestamp.Duration())
+			}
+		},
+	)()
+
+	return r
+}
+
+func (r *recorder) log(level string, text string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.console = append(r.console, fmt.Sprintf("%s [%s] %s", time.Now().Format(time.RFC3339Nano), level, text))
+}
+
+func remoteObjectString(obj *proto.RuntimeRemoteObject) string {
+	switch {
+	case obj.Description != "":
+		return obj.Description
+	case obj.UnserializableValue != "":
+		return string(obj.UnserializableValue)
+	default:
+		return obj.Value.String()
+	}
+}
+
+// consoleLog returns the recorded console messages, one per line
+func (r *recorder) consoleLog() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.console) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(r.console, "\n") + "\n")
+}
+
+// har returns the recorded requests as a HAR 1.2 document
+func (r *recorder) har() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	doc := harDocument{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "omonOmon", Version: "1"},
+		Entries: r.requests,
+	}}
+	if doc.Log.Entries == nil {
+		doc.Log.Entries = []*harEntry{}
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Entries []*harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+
+	started time.Duration
+}
+
+// finish sets the entry duration from the monotonic end timestamp
+func (e *harEntry) finish(end time.Duration) {
+	e.Time = float64(end-e.started) / float64(time.Millisecond)
+	e.Timings.Receive = e.Time
+}
+
+type harRequest struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []harHeader `json:"headers"`
+	QueryString []harHeader `json:"queryString"`
+	Cookies     []harHeader `json:"cookies"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []harHeader `json:"headers"`
+	Cookies     []harHeader `json:"cookies"`
+	Content     harContent  `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+	// Error is the browser error of failed requests, a HAR custom field
+	Error string `json:"_error,omitempty"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func harHeaders(headers proto.NetworkHeaders) []harHeader {
+	list := make([]harHeader, 0, len(headers))
+	for name, value := range headers {
+		list = append(list, harHeader{Name: name, Value: value.Str()})
+	}
+	return list
+}
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// attemptDir returns the artifacts directory of a claim attempt under root
+func attemptDir(root string, faucetName string, address string) string {
+	if faucetName == "" {
+		faucetName = "default"
+	}
+	name := fmt.Sprintf("%s-%s-%s", time.Now().Format("20060102-150405.000"), faucetName, address)
+	return filepath.Join(root, unsafePathChars.ReplaceAllString(name, "_"))
+}
+
+// saveArtifacts writes the error, a screenshot, the page HTML, the console
+// messages and the network requests of a failed claim to dir. page and rec
+// may be nil when the failure happened before the page was opened.
+func saveArtifacts(dir string, page *rod.Page, rec *recorder, step string, cause error) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var errs []string
+	write := func(name string, data []byte) {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	report := fmt.Sprintf("step: %s\nerror: %v\n", step, cause)
+	if page != nil {
+		if info, err := page.Info(); err == nil {
+			report += fmt.Sprintf("url: %s\ntitle: %s\n", info.URL, info.Title)
+		}
+	}
+	write("error.txt", []byte(report))
+
+	if page != nil {
+		// the page may be stuck, artifacts must not hang the claim
+		page = page.Timeout(10 * time.Second)
+		defer page.CancelTimeout()
+
+		if screenshot, err := page.Screenshot(true, nil); err == nil {
+			write("screenshot.png", screenshot)
+		} else {
+			errs = append(errs, fmt.Sprintf("screenshot: %v", err))
+		}
+
+		if html, err := page.HTML(); err == nil {
+			write("page.html", []byte(html))
+		} else {
+			errs = append(errs, fmt.Sprintf("html: %v", err))
+		}
+	}
+
+	if rec != nil {
+		write("console.log", rec.consoleLog())
+
+		har, err := rec.har()
+		if err == nil {
+			write("network.har", har)
+		} else {
+			errs = append(errs, fmt.Sprintf("har: %v", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to save some artifacts: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
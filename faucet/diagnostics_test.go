@@ -0,0 +1,92 @@
+package faucet
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttemptDir(t *testing.T) {
+	dir := attemptDir("artifacts", "my faucet", testAddress)
+	assert.Equal(t, "artifacts", filepath.Dir(dir))
+	assert.True(t, strings.HasSuffix(dir, "-my_faucet-"+testAddress), dir)
+
+	dir = attemptDir("artifacts", "", testAddress)
+	assert.Contains(t, dir, "-default-")
+}
+
+func TestSaveArtifacts(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "attempt")
+
+	rec := &recorder{byID: make(map[proto.NetworkRequestID]*harEntry)}
+	rec.log("error", "wallet not found")
+	rec.requests = append(rec.requests, &harEntry{
+		StartedDateTime: time.Now(),
+		Request:         harRequest{Method: "GET", URL: "https://faucet.test"},
+		Response:        harResponse{Status: 502, Error: "net::ERR_TUNNEL_CONNECTION_FAILED"},
+	})
+
+	err := saveArtifacts(dir, nil, rec, "find address input", errors.New("context deadline exceeded"))
+	assert.NoError(t, err)
+
+	report, err := os.ReadFile(filepath.Join(dir, "error.txt"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(report), "step: find address input")
+	assert.Contains(t, string(report), "context deadline exceeded")
+
+	console, err := os.ReadFile(filepath.Join(dir, "console.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(console), "[error] wallet not found")
+
+	data, err := os.ReadFile(filepath.Join(dir, "network.har"))
+	assert.NoError(t, err)
+
+	var har struct {
+		Log struct {
+			Version string `json:"version"`
+			Entries []struct {
+				Request struct {
+					URL string `json:"url"`
+				} `json:"request"`
+				Response struct {
+					Status int    `json:"status"`
+					Error  string `json:"_error"`
+				} `json:"response"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &har))
+	assert.Equal(t, "1.2", har.Log.Version)
+	assert.Len(t, har.Log.Entries, 1)
+	assert.Equal(t, "https://faucet.test", har.Log.Entries[0].Request.URL)
+	assert.Equal(t, "net::ERR_TUNNEL_CONNECTION_FAILED", har.Log.Entries[0].Response.Error)
+
+	assert.NoFileExists(t, filepath.Join(dir, "screenshot.png"))
+}
+
+func TestBrowserClaimerFail(t *testing.T) {
+	var claimer browserClaimer
+
+	result := claimer.fail(nil, nil, "launch browser", errors.New("chrome not found"))
+	assert.Equal(t, StatusFailed, result.Status)
+	assert.Equal(t, "launch browser", result.Step)
+	assert.Equal(t, "launch browser: chrome not found", result.Reason)
+	assert.Empty(t, result.Artifacts)
+
+	claimer.browser.artifacts = filepath.Join(t.TempDir(), "attempt")
+	result = claimer.fail(nil, nil, "launch browser", errors.New("chrome not found"))
+	assert.Equal(t, claimer.browser.artifacts, result.Artifacts)
+	assert.FileExists(t, filepath.Join(result.Artifacts, "error.txt"))
+
+	// only failures are captured
+	result = claimer.capture(nil, nil, "check result", &Result{Status: StatusRateLimited})
+	assert.Empty(t, result.Step)
+	assert.Empty(t, result.Artifacts)
+}
@@ -1,84 +1,235 @@
 package faucet
 
 import (
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
-	"time"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
 
-	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/proto"
-	"github.com/go-rod/stealth"
 )
 
-var factories = map[string]FaucetClaimerFactory{
-	"":        func() FaucetClaimer { return &DefaultFaucetClaimer{} },
-	"default": func() FaucetClaimer { return &DefaultFaucetClaimer{} },
-}
+const defaultURL = "https://testnet.monad.xyz/"
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]FaucetClaimerFactory{
+		"":        func() FaucetClaimer { return &DefaultFaucetClaimer{} },
+		"default": func() FaucetClaimer { return &DefaultFaucetClaimer{} },
+	}
+)
 
+// FaucetClaimer claims a faucet for an address. Claims stop once ctx is
+// done, reporting the step they were at.
 type FaucetClaimer interface {
-	Claim(address string) error
+	Claim(ctx context.Context, address string) *Result
 }
 
 type FaucetClaimerFactory func() FaucetClaimer
 
-type DefaultFaucetClaimer struct{}
+type DefaultFaucetClaimer struct {
+	browserClaimer
+}
 
-func (f *DefaultFaucetClaimer) Claim(address string) error {
+func (f *DefaultFaucetClaimer) Claim(ctx context.Context, address string) *Result {
 	if address == "" {
-		return errors.New("address is required")
+		return failed(errors.New("address is required"))
 	}
 
-	browser := rod.New()
-	defer browser.Close()
-
 	fmt.Println("Connecting...")
-	if err := browser.Connect(); err != nil {
-		return err
+	browser, err := f.browser.Launch(ctx)
+	if err != nil {
+		return f.fail(nil, nil, "launch browser", err)
 	}
+	defer browser.Close()
 
 	// create stealth page
-	page, err := stealth.Page(browser)
+	page, err := browser.Page(ctx, defaultURL)
 	if err != nil {
-		return err
+		return f.fail(browser, nil, "open page", err)
 	}
 
 	fmt.Println("Navigating to faucet...")
-	err = page.Navigate("https://testnet.monad.xyz/")
+	err = page.Timeout(f.stepTimeout()).Navigate(defaultURL)
 	if err != nil {
-		return err
+		return f.fail(browser, page, "navigate", err)
+	}
+
+	if result := f.captcha(browser, page, "navigate"); result != nil {
+		return result
 	}
 
-	element, err := page.Element(".wallet-address-container input")
+	element, err := page.Timeout(f.stepTimeout()).Element(".wallet-address-container input")
 	if err != nil {
-		return err
+		return f.fail(browser, page, "find address input", err)
 	}
 
-	button, err := page.Element("button")
+	button, err := page.Timeout(f.stepTimeout()).Element("button")
 	if err != nil {
-		return err
+		return f.fail(browser, page, "find claim button", err)
 	}
 
 	fmt.Println("Entering address...")
-	err = element.Input(address)
+	err = element.Timeout(f.stepTimeout()).Input(address)
 	if err != nil {
-		return err
+		return f.fail(browser, page, "enter address", err)
 	}
 
 	fmt.Println("Clicking button...")
-	err = button.Click(proto.InputMouseButtonLeft, 1)
+	err = button.Timeout(f.stepTimeout()).Click(proto.InputMouseButtonLeft, 1)
 	if err != nil {
-		return err
+		return f.fail(browser, page, "click claim button", err)
+	}
+
+	if err := page.WaitIdle(f.stepTimeout()); err != nil {
+		return f.fail(browser, page, "wait for page", err)
+	}
+
+	// some faucets only show their captcha once the claim is submitted
+	if result := f.captcha(browser, page, "click claim button"); result != nil {
+		return result
 	}
 
-	return page.WaitIdle(30 * time.Second)
+	fmt.Println("Waiting for result...")
+	return f.capture(browser, page, "check result", pageOutcome(page, defaultToastWait))
 }
 
-// Claim claims the faucet for the given address
-func Claim(faucetName string, address string) error {
+// Register makes a faucet claimer available by name.
+// It panics if the name is already registered or factory is nil.
+func Register(name string, factory FaucetClaimerFactory) {
+	if err := register(name, factory); err != nil {
+		panic(err)
+	}
+}
+
+func register(name string, factory FaucetClaimerFactory) error {
+	if factory == nil {
+		return fmt.Errorf("faucet %s: factory is nil", name)
+	}
+
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if _, exists := factories[name]; exists {
+		return fmt.Errorf("faucet %s already registered", name)
+	}
+	factories[name] = factory
+	return nil
+}
+
+// Names returns the names of all registered faucets, sorted
+func Names() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the claimer registered under name
+func New(faucetName string) (FaucetClaimer, error) {
+	factoriesMu.RLock()
 	factory, ok := factories[faucetName]
+	factoriesMu.RUnlock()
+
 	if !ok {
-		return fmt.Errorf("faucet %s not found", faucetName)
+		return nil, fmt.Errorf("faucet %s not found", faucetName)
+	}
+	return factory(), nil
+}
+
+// Claim claims the faucet for the given address
+func Claim(ctx context.Context, faucetName string, address string) (*Result, error) {
+	return ClaimWithOptions(ctx, faucetName, address, ClaimOptions{})
+}
+
+// ClaimWithOptions claims the faucet for the given address, optionally
+// verifying the claim by the balance increase. The returned error is
+// nil only when the claim is confirmed. A claim interrupted by ctx is
+// reported as skipped, balance verification included, one exceeding
+// opts.Timeout as failed.
+func ClaimWithOptions(ctx context.Context, faucetName string, address string, opts ClaimOptions) (*Result, error) {
+	claimer, err := New(faucetName)
+	if err != nil {
+		return nil, err
+	}
+	opts = opts.withDefaults()
+
+	if opts.Wallet != nil && !strings.EqualFold(opts.Wallet.Address, address) {
+		return nil, fmt.Errorf("wallet %s can not sign for address %s", opts.Wallet.Address, address)
+	}
+
+	var before *big.Int
+	if opts.VerifyBalance {
+		before, err = opts.Balance(ctx, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read balance: %v", err)
+		}
+	}
+
+	var proxy *Proxy
+	if opts.Proxies != nil {
+		proxy, err = opts.Proxies.Acquire(address)
+		if err != nil {
+			return nil, err
+		}
+		opts.Browser.Proxy = proxy.URL()
+	}
+
+	opts.Browser.solver = opts.CaptchaSolver
+	opts.Browser.stepTimeout = opts.StepTimeout
+	opts.Browser.wallet = opts.Wallet
+	if opts.ArtifactsDir != "" {
+		opts.Browser.artifacts = attemptDir(opts.ArtifactsDir, faucetName, address)
+	}
+
+	if c, ok := claimer.(BrowserClaimer); ok {
+		c.SetBrowserOptions(opts.Browser)
+	}
+
+	claimCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	result := claimer.Claim(claimCtx, address)
+	cancel()
+	result.Faucet = faucetName
+	result.Address = address
+
+	switch {
+	case ctx.Err() != nil:
+		// nothing is known about an interrupted claim, so it neither
+		// starts a cooldown nor counts against its proxy
+		result.Status = StatusSkipped
+		result.Reason = fmt.Sprintf("cancelled during %s", cmp.Or(result.Step, "claim"))
+		return result, result.Err()
+	case errors.Is(claimCtx.Err(), context.DeadlineExceeded) && result.Status == StatusFailed:
+		result.Reason = fmt.Sprintf("timed out after %s: %s", opts.Timeout, result.Reason)
+	}
+
+	switch {
+	case proxy != nil:
+		health := opts.Proxies.Report(proxy, !isProxyFailure(result))
+		result.Proxy = &health
+	case opts.Browser.Proxy != "":
+		result.Proxy = proxyHealth(opts.Browser.Proxy, !isProxyFailure(result))
+	}
+
+	if opts.VerifyBalance {
+		fmt.Println("Waiting for balance to increase...")
+		result = opts.verify(ctx, result, address, before)
+	}
+
+	if result.Status == StatusUnconfirmed {
+		result.Status = StatusFailed
 	}
 
-	return factory().Claim(address)
+	return result, result.Err()
 }
@@ -0,0 +1,140 @@
+package faucet
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HistoryEntry is a recorded claim
+type HistoryEntry struct {
+	Time    time.Time `json:"time"`
+	Faucet  string    `json:"faucet"`
+	Address string    `json:"address"`
+	Status  Status    `json:"status"`
+	Reason  string    `json:"reason,omitempty"`
+	// Increase is the verified balance increase in wei
+	Increase string `json:"increase,omitempty"`
+	Proxy    string `json:"proxy,omitempty"`
+}
+
+// HistoryFilter selects history entries, zero fields match everything
+type HistoryFilter struct {
+	Faucet  string
+	Address string
+	Status  Status
+	Since   time.Time
+	// Limit keeps only the most recent entries
+	Limit int
+}
+
+func (f HistoryFilter) matches(entry HistoryEntry) bool {
+	switch {
+	case f.Faucet != "" && entry.Faucet != f.Faucet:
+		return false
+	case f.Address != "" && !strings.EqualFold(entry.Address, f.Address):
+		return false
+	case f.Status != "" && entry.Status != f.Status:
+		return false
+	case !f.Since.IsZero() && entry.Time.Before(f.Since):
+		return false
+	}
+	return true
+}
+
+// History is an append-only claim log stored as JSON lines
+type History struct {
+	mu   sync.Mutex
+	path string
+}
+
+// OpenHistory returns the history stored at path, the file is created on
+// the first record
+func OpenHistory(path string) *History {
+	return &History{path: path}
+}
+
+// Record appends the outcome of a claim, skipped claims are not recorded
+func (h *History) Record(result *Result) error {
+	if result.Status == StatusSkipped {
+		return nil
+	}
+
+	entry := HistoryEntry{
+		Time:    time.Now().UTC(),
+		Faucet:  result.Faucet,
+		Address: result.Address,
+		Status:  result.Status,
+		Reason:  result.Reason,
+	}
+	if result.Increase != nil {
+		entry.Increase = result.Increase.String()
+	}
+	if result.Proxy != nil {
+		entry.Proxy = result.Proxy.Proxy
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Query returns the entries matching filter, oldest first
+func (h *History) Query(filter HistoryFilter) ([]HistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	file, err := os.Open(h.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid entry: %v", h.path, line, err)
+		}
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries, nil
+}
@@ -0,0 +1,56 @@
+package faucet
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	history := OpenHistory(filepath.Join(t.TempDir(), "history.jsonl"))
+
+	entries, err := history.Query(HistoryFilter{})
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	results := []*Result{
+		{Faucet: "default", Address: testAddress, Status: StatusClaimed, Increase: big.NewInt(5)},
+		{Faucet: "aprio", Address: testAddress, Status: StatusRateLimited, Reason: "come back tomorrow"},
+		{Faucet: "default", Address: testKeyAddress, Status: StatusSkipped},
+		{Faucet: "default", Address: testKeyAddress, Status: StatusFailed},
+	}
+	for _, result := range results {
+		assert.NoError(t, history.Record(result))
+	}
+
+	entries, err = history.Query(HistoryFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "5", entries[0].Increase)
+
+	tests := []struct {
+		name   string
+		filter HistoryFilter
+		want   int
+	}{
+		{"faucet", HistoryFilter{Faucet: "default"}, 2},
+		{"address ignores case", HistoryFilter{Address: "0x8ba1f109551bd432803012645ac136ddd64dba72"}, 2},
+		{"status", HistoryFilter{Status: StatusRateLimited}, 1},
+		{"since", HistoryFilter{Since: time.Now().Add(time.Minute)}, 0},
+		{"limit", HistoryFilter{Limit: 1}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := history.Query(test.filter)
+			assert.NoError(t, err)
+			assert.Len(t, entries, test.want)
+		})
+	}
+
+	entries, _ = history.Query(HistoryFilter{Limit: 1})
+	assert.Equal(t, StatusFailed, entries[0].Status)
+}
@@ -0,0 +1,360 @@
+package faucet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// HTTPRequest describes the request claiming a faucet with a plain HTTP
+// endpoint. The recipe URL, header values, Body and Message are Go
+// templates receiving the claim address as {{.Address}}; Body and
+// headers also receive the signed message as {{.Message}} and
+// {{.Signature}}.
+type HTTPRequest struct {
+	// Method defaults to POST
+	Method  string            `yaml:"method"`
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+	// Message is signed with the claiming wallet when set
+	Message string        `yaml:"message"`
+	Success HTTPPredicate `yaml:"success"`
+	// Timeout bounds the request, defaults to the claim step timeout
+	Timeout time.Duration `yaml:"timeout"`
+
+	url     *template.Template
+	headers map[string]*template.Template
+	body    *template.Template
+	message *template.Template
+}
+
+// HTTPPredicate tells a successful claim response apart, every field set
+// has to match
+type HTTPPredicate struct {
+	// Status lists the accepted status codes, defaults to any 2xx
+	Status []int `yaml:"status"`
+	// Field is a dot separated path into the JSON response, e.g. data.ok
+	// or items.0.hash
+	Field string `yaml:"field"`
+	// Equals is the expected value of Field. Without it Field must be set
+	// to anything but false, null, 0 or "".
+	Equals string `yaml:"equals"`
+	// Match is a regular expression the response body must match
+	Match string `yaml:"match"`
+
+	match *regexp.Regexp
+}
+
+// compile validates the request and parses its templates
+func (r *HTTPRequest) compile(rawURL string) error {
+	if r.Method == "" {
+		r.Method = http.MethodPost
+	}
+	r.Method = strings.ToUpper(r.Method)
+
+	parse := func(name string, text string) (*template.Template, error) {
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", name, err)
+		}
+		return tmpl, nil
+	}
+
+	var err error
+	if r.url, err = parse("url", rawURL); err != nil {
+		return err
+	}
+	if r.body, err = parse("body", r.Body); err != nil {
+		return err
+	}
+	if r.message, err = parse("message", r.Message); err != nil {
+		return err
+	}
+
+	r.headers = make(map[string]*template.Template, len(r.Headers))
+	for name, value := range r.Headers {
+		if r.headers[name], err = parse("header "+name, value); err != nil {
+			return err
+		}
+	}
+
+	if r.Success.Match != "" {
+		r.Success.match, err = regexp.Compile(r.Success.Match)
+		if err != nil {
+			return fmt.Errorf("invalid success match: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// HTTPFaucetClaimer claims a faucet with a single HTTP request, as
+// described by an http recipe. Of the browser options only the proxy and
+// the step timeout apply.
+type HTTPFaucetClaimer struct {
+	browserClaimer
+	Recipe *Recipe
+	// Client sends the request, by default one using the configured proxy
+	Client *http.Client
+}
+
+func (f *HTTPFaucetClaimer) Claim(ctx context.Context, address string) *Result {
+	if address == "" {
+		return failed(errors.New("address is required"))
+	}
+	request := &f.Recipe.Request
+
+	data := recipeData{Address: address}
+	if request.Message != "" {
+		if f.browser.wallet == nil {
+			return f.fail(nil, nil, "sign message", errors.New("the faucet asks for a signed message, a wallet is required"))
+		}
+
+		message, err := execute(request.message, data)
+		if err != nil {
+			return f.fail(nil, nil, "sign message", err)
+		}
+		signature, err := f.browser.wallet.PersonalSign(message)
+		if err != nil {
+			return f.fail(nil, nil, "sign message", err)
+		}
+		data.Message = message
+		data.Signature = signature
+	}
+
+	req, err := request.build(ctx, data)
+	if err != nil {
+		return f.fail(nil, nil, "build request", err)
+	}
+
+	client, err := f.client()
+	if err != nil {
+		return f.fail(nil, nil, "build request", err)
+	}
+
+	timeout := request.Timeout
+	if timeout <= 0 {
+		timeout = f.stepTimeout()
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	fmt.Printf("Sending %s %s...\n", req.Method, req.URL.Redacted())
+	resp, err := client.Do(req.WithContext(reqCtx))
+	if err != nil {
+		return f.fail(nil, nil, "send request", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return f.fail(nil, nil, "read response", err)
+	}
+
+	result := request.Success.result(resp.StatusCode, body)
+	if result.Status == StatusFailed {
+		result.Step = "check result"
+	}
+	return result
+}
+
+// client returns the client sending the claim request
+func (f *HTTPFaucetClaimer) client() (*http.Client, error) {
+	if f.Client != nil {
+		return f.Client, nil
+	}
+	if f.browser.Proxy == "" {
+		return http.DefaultClient, nil
+	}
+
+	proxy, err := ParseProxy(f.browser.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	proxyURL, err := url.Parse(proxy.URL())
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	return &http.Client{Transport: transport}, nil
+}
+
+// build renders the request templates for a claim
+func (r *HTTPRequest) build(ctx context.Context, data recipeData) (*http.Request, error) {
+	rawURL, err := execute(r.url, data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := execute(r.body, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.Method, rawURL, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, tmpl := range r.headers {
+		value, err := execute(tmpl, data)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(name, value)
+	}
+	if body != "" && req.Header.Get("Content-Type") == "" && json.Valid([]byte(body)) {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return req, nil
+}
+
+func execute(tmpl *template.Template, data recipeData) (string, error) {
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// result classifies the response of a claim request
+func (p HTTPPredicate) result(status int, body []byte) *Result {
+	message := responseMessage(body)
+
+	ok, why := p.matches(status, body)
+	if ok {
+		return &Result{Status: StatusClaimed, Reason: message}
+	}
+
+	if status == http.StatusTooManyRequests {
+		return &Result{Status: StatusRateLimited, Reason: message}
+	}
+	if classified, found := ClassifyMessage(message); found && classified != StatusClaimed {
+		return &Result{Status: classified, Reason: message}
+	}
+
+	reason := fmt.Sprintf("HTTP %d, %s", status, why)
+	if message != "" {
+		reason += ": " + message
+	}
+	return &Result{Status: StatusFailed, Reason: reason}
+}
+
+// matches reports whether a response satisfies the predicate, or why not
+func (p HTTPPredicate) matches(status int, body []byte) (bool, string) {
+	if len(p.Status) > 0 {
+		if !slices.Contains(p.Status, status) {
+			return false, "unexpected status"
+		}
+	} else if status < 200 || status > 299 {
+		return false, "unexpected status"
+	}
+
+	if p.match != nil && !p.match.Match(body) {
+		return false, fmt.Sprintf("response does not match /%s/", p.Match)
+	}
+
+	if p.Field != "" {
+		var doc any
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return false, "response is not JSON"
+		}
+
+		value, found := lookupField(doc, p.Field)
+		switch {
+		case !found:
+			return false, fmt.Sprintf("no %s in response", p.Field)
+		case p.Equals != "" && fieldString(value) != p.Equals:
+			return false, fmt.Sprintf("%s is %s, expected %s", p.Field, fieldString(value), p.Equals)
+		case p.Equals == "" && !truthy(value):
+			return false, fmt.Sprintf("%s is %s", p.Field, fieldString(value))
+		}
+	}
+
+	return true, ""
+}
+
+// lookupField walks a dot separated path through JSON objects and arrays
+func lookupField(doc any, path string) (any, bool) {
+	value := doc
+	for _, key := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]any:
+			next, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			value = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// fieldString formats a JSON value, strings without their quotes
+func fieldString(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func truthy(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	}
+	return true
+}
+
+var messageFields = []string{"message", "msg", "error", "detail", "status"}
+
+// responseMessage returns the message of a JSON response, or the body
+// itself, shortened
+func responseMessage(body []byte) string {
+	var doc map[string]any
+	if json.Unmarshal(body, &doc) == nil {
+		for _, field := range messageFields {
+			if s, ok := doc[field].(string); ok && s != "" {
+				return s
+			}
+		}
+	}
+
+	message := strings.TrimSpace(string(body))
+	if len(message) > 200 {
+		message = message[:200] + "..."
+	}
+	return message
+}
@@ -0,0 +1,137 @@
+package faucet
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/galihrivanto/omonOmon/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+const testHTTPRecipe = `
+type: http
+url: %s/claim?address={{.Address}}
+request:
+  headers:
+    X-Address: "{{.Address}}"
+  body: '{"address": "{{.Address}}"}'
+  success:
+    field: data.ok
+`
+
+func TestHTTPFaucetClaimer(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		expected Status
+		reason   string
+	}{
+		{"claimed", http.StatusOK, `{"data": {"ok": true}, "message": "0.1 MON sent"}`, StatusClaimed, "0.1 MON sent"},
+		{"predicate not met", http.StatusOK, `{"data": {"ok": false}}`, StatusFailed, `HTTP 200, data.ok is false: {"data": {"ok": false}}`},
+		{"rate limited", http.StatusBadRequest, `{"error": "already claimed today"}`, StatusRateLimited, "already claimed today"},
+		{"too many requests", http.StatusTooManyRequests, `slow down`, StatusRateLimited, "slow down"},
+		{"server error", http.StatusBadGateway, `<html>bad gateway</html>`, StatusFailed, "HTTP 502, unexpected status: <html>bad gateway</html>"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var method, query, header, body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				method, query, header, body = r.Method, r.URL.Query().Get("address"), r.Header.Get("X-Address"), string(data)
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+				w.WriteHeader(test.status)
+				io.WriteString(w, test.response)
+			}))
+			defer server.Close()
+
+			recipe, err := LoadRecipe(writeRecipe(t, t.TempDir(), "http.yaml", fmt.Sprintf(testHTTPRecipe, server.URL)))
+			assert.NoError(t, err)
+
+			claimer := &HTTPFaucetClaimer{Recipe: recipe}
+			result := claimer.Claim(context.Background(), testAddress)
+			assert.Equal(t, test.expected, result.Status)
+			assert.Equal(t, test.reason, result.Reason)
+
+			assert.Equal(t, http.MethodPost, method)
+			assert.Equal(t, testAddress, query)
+			assert.Equal(t, testAddress, header)
+			assert.Equal(t, `{"address": "`+testAddress+`"}`, body)
+		})
+	}
+}
+
+func TestHTTPFaucetClaimer_Signed(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	recipe, err := LoadRecipe(writeRecipe(t, t.TempDir(), "http-signed.yaml", `
+type: http
+url: `+server.URL+`
+request:
+  message: "claim for {{.Address}}"
+  body: '{"message": "{{.Message}}", "signature": "{{.Signature}}"}'
+`))
+	assert.NoError(t, err)
+	assert.NoError(t, RegisterRecipe(recipe))
+
+	result, err := ClaimWithOptions(context.Background(), "http-signed", testKeyAddress, ClaimOptions{})
+	assert.Error(t, err)
+	assert.Equal(t, "sign message", result.Step)
+
+	w := &wallet.Wallet{Address: testKeyAddress, PrivateKey: testPrivateKey}
+	_, err = ClaimWithOptions(context.Background(), "http-signed", testKeyAddress, ClaimOptions{Wallet: w})
+	assert.NoError(t, err)
+
+	signature, err := w.PersonalSign("claim for " + testKeyAddress)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"message": "claim for `+testKeyAddress+`", "signature": "`+signature+`"}`, body)
+
+	// a wallet only signs for its own address
+	_, err = ClaimWithOptions(context.Background(), "http-signed", testAddress, ClaimOptions{Wallet: w})
+	assert.ErrorContains(t, err, "can not sign for address")
+}
+
+func TestHTTPPredicate(t *testing.T) {
+	tests := []struct {
+		name      string
+		predicate HTTPPredicate
+		status    int
+		body      string
+		expected  bool
+	}{
+		{"any 2xx", HTTPPredicate{}, http.StatusCreated, ``, true},
+		{"not 2xx", HTTPPredicate{}, http.StatusFound, ``, false},
+		{"listed status", HTTPPredicate{Status: []int{http.StatusAccepted}}, http.StatusAccepted, ``, true},
+		{"unlisted status", HTTPPredicate{Status: []int{http.StatusAccepted}}, http.StatusOK, ``, false},
+		{"field equals", HTTPPredicate{Field: "status", Equals: "queued"}, http.StatusOK, `{"status": "queued"}`, true},
+		{"field differs", HTTPPredicate{Field: "status", Equals: "queued"}, http.StatusOK, `{"status": "done"}`, false},
+		{"number equals", HTTPPredicate{Field: "code", Equals: "0"}, http.StatusOK, `{"code": 0}`, true},
+		{"array index", HTTPPredicate{Field: "txs.0.hash"}, http.StatusOK, `{"txs": [{"hash": "0xabc"}]}`, true},
+		{"missing field", HTTPPredicate{Field: "txs.1.hash"}, http.StatusOK, `{"txs": [{"hash": "0xabc"}]}`, false},
+		{"empty field", HTTPPredicate{Field: "hash"}, http.StatusOK, `{"hash": ""}`, false},
+		{"not json", HTTPPredicate{Field: "hash"}, http.StatusOK, `ok`, false},
+		{"match", HTTPPredicate{Match: `0x[0-9a-f]{3}`}, http.StatusOK, `sent in 0xabc`, true},
+		{"no match", HTTPPredicate{Match: `0x[0-9a-f]{3}`}, http.StatusOK, `queued`, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := HTTPRequest{Success: test.predicate}
+			assert.NoError(t, request.compile("https://faucet.test"))
+
+			ok, _ := request.Success.matches(test.status, []byte(test.body))
+			assert.Equal(t, test.expected, ok)
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package faucet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultStepTimeout   = 30 * time.Second
+	defaultResultTimeout = 30 * time.Second
+)
+
+// recipe types
+const (
+	RecipeBrowser = "browser"
+	RecipeHTTP    = "http"
+)
+
+// Recipe describes a faucet declaratively, so new faucets can be added
+// without writing a FaucetClaimer. Recipes are YAML or JSON files.
+// Browser recipes run their steps in a browser, http recipes send a
+// single request to URL.
+type Recipe struct {
+	Name string `yaml:"name"`
+	// Type is browser, the default, or http
+	Type  string       `yaml:"type"`
+	URL   string       `yaml:"url"`
+	Steps []RecipeStep `yaml:"steps"`
+	// Request describes the claim request of http recipes
+	Request HTTPRequest `yaml:"request"`
+	// Success and Failure markers are checked once all steps ran,
+	// without markers the toasts shown by the page are checked
+	Success []Marker `yaml:"success"`
+	Failure []Marker `yaml:"failure"`
+	// ResultTimeout bounds how long markers are waited for
+	ResultTimeout time.Duration `yaml:"resultTimeout"`
+
+	path string
+}
+
+// RecipeStep is a single browser action. Values are Go templates
+// receiving the claim address as {{.Address}}.
+//
+//	input: type Value into Selector
+//	click: click Selector
+//	wait:  wait until Selector (and Text, if set) is visible, until the
+//	       page is idle when no selector is set, or for Duration
+type RecipeStep struct {
+	Name     string        `yaml:"name"`
+	Action   string        `yaml:"action"`
+	Selector string        `yaml:"selector"`
+	Text     string        `yaml:"text"`
+	Value    string        `yaml:"value"`
+	Duration time.Duration `yaml:"duration"`
+	// Timeout bounds the step, defaults to the claim step timeout
+	Timeout time.Duration `yaml:"timeout"`
+
+	value *template.Template
+}
+
+// Marker matches an element by selector, text or both. Text is a
+// JavaScript regular expression matched against the element text,
+// without a selector the whole page body is searched.
+type Marker struct {
+	Selector string `yaml:"selector"`
+	Text     string `yaml:"text"`
+}
+
+func (m Marker) String() string {
+	switch {
+	case m.Selector != "" && m.Text != "":
+		return fmt.Sprintf("%s /%s/", m.Selector, m.Text)
+	case m.Text != "":
+		return fmt.Sprintf("/%s/", m.Text)
+	default:
+		return m.Selector
+	}
+}
+
+// LoadRecipe loads and validates a recipe file
+func LoadRecipe(path string) (*Recipe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var recipe Recipe
+	if err := yaml.Unmarshal(data, &recipe); err != nil {
+		return nil, fmt.Errorf("%s: invalid recipe: %v", path, err)
+	}
+	recipe.path = path
+
+	if recipe.Name == "" {
+		recipe.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	if err := recipe.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return &recipe, nil
+}
+
+// LoadRecipes registers every recipe found in dir
+func LoadRecipes(dir string) ([]*Recipe, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var recipes []*Recipe
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		if entry.IsDir() {
+			continue
+		}
+
+		recipe, err := LoadRecipe(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return recipes, err
+		}
+
+		if err := RegisterRecipe(recipe); err != nil {
+			return recipes, err
+		}
+		recipes = append(recipes, recipe)
+	}
+
+	return recipes, nil
+}
+
+// RegisterRecipe makes a recipe available as a faucet under its name
+func RegisterRecipe(recipe *Recipe) error {
+	if recipe.Type == RecipeHTTP {
+		return register(recipe.Name, func() FaucetClaimer {
+			return &HTTPFaucetClaimer{Recipe: recipe}
+		})
+	}
+	return register(recipe.Name, func() FaucetClaimer {
+		return &RecipeFaucetClaimer{Recipe: recipe}
+	})
+}
+
+// compile validates the recipe and parses its templates
+func (r *Recipe) compile() error {
+	if r.URL == "" {
+		return errors.New("url is required")
+	}
+
+	switch r.Type {
+	case "":
+		r.Type = RecipeBrowser
+	case RecipeBrowser:
+	case RecipeHTTP:
+		if len(r.Steps) > 0 || len(r.Success) > 0 || len(r.Failure) > 0 {
+			return errors.New("http recipes have no steps or markers, use request.success")
+		}
+		return r.Request.compile(r.URL)
+	default:
+		return fmt.Errorf("unknown recipe type %q", r.Type)
+	}
+
+	if r.ResultTimeout <= 0 {
+		r.ResultTimeout = defaultResultTimeout
+	}
+
+	for i := range r.Steps {
+		step := &r.Steps[i]
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step %d (%s)", i+1, step.Action)
+		}
+
+		switch step.Action {
+		case "input":
+			if step.Selector == "" {
+				return fmt.Errorf("%s: selector is required", step.Name)
+			}
+			tmpl, err := template.New(step.Name).Option("missingkey=error").Parse(step.Value)
+			if err != nil {
+				return fmt.Errorf("%s: invalid value: %v", step.Name, err)
+			}
+			step.value = tmpl
+		case "click":
+			if step.Selector == "" {
+				return fmt.Errorf("%s: selector is required", step.Name)
+			}
+		case "wait":
+		default:
+			return fmt.Errorf("%s: unknown action %q", step.Name, step.Action)
+		}
+	}
+
+	for _, marker := range append(r.Success, r.Failure...) {
+		if marker.Selector == "" && marker.Text == "" {
+			return errors.New("markers need a selector or a text")
+		}
+	}
+
+	return nil
+}
+
+// RecipeFaucetClaimer claims a faucet by following a recipe in a browser
+type RecipeFaucetClaimer struct {
+	browserClaimer
+	Recipe *Recipe
+}
+
+// recipeData is what recipe templates receive
+type recipeData struct {
+	Address string
+	// Message and Signature are the message signed for http recipes
+	Message   string
+	Signature string
+}
+
+func (f *RecipeFaucetClaimer) Claim(ctx context.Context, address string) *Result {
+	if address == "" {
+		return failed(errors.New("address is required"))
+	}
+
+	fmt.Println("Connecting...")
+	browser, err := f.browser.Launch(ctx)
+	if err != nil {
+		return f.fail(nil, nil, "launch browser", err)
+	}
+	defer browser.Close()
+
+	page, err := browser.Page(ctx, f.Recipe.URL)
+	if err != nil {
+		return f.fail(browser, nil, "open page", err)
+	}
+
+	fmt.Println("Navigating to faucet...")
+	if err := page.Timeout(f.stepTimeout()).Navigate(f.Recipe.URL); err != nil {
+		return f.fail(browser, page, "navigate", err)
+	}
+	if err := page.Timeout(f.stepTimeout()).WaitLoad(); err != nil {
+		return f.fail(browser, page, "navigate", err)
+	}
+
+	data := recipeData{Address: address}
+	for _, step := range f.Recipe.Steps {
+		// a captcha blocks whatever step comes next
+		if result := f.captcha(browser, page, step.Name); result != nil {
+			return result
+		}
+
+		fmt.Printf("Running %s...\n", step.Name)
+		if err := step.run(page, data, f.stepTimeout()); err != nil {
+			return f.fail(browser, page, step.Name, err)
+		}
+	}
+
+	if result := f.captcha(browser, page, "check result"); result != nil {
+		return result
+	}
+
+	return f.capture(browser, page, "check result", f.Recipe.checkResult(page))
+}
+
+// run runs the step, timeout applies to steps without their own
+func (s *RecipeStep) run(page *rod.Page, data recipeData, timeout time.Duration) error {
+	if s.Timeout > 0 {
+		timeout = s.Timeout
+	}
+	// fixed waits are not bound by the timeout
+	ctx := page.GetContext()
+	page = page.Timeout(timeout)
+	defer page.CancelTimeout()
+
+	switch s.Action {
+	case "input":
+		var value bytes.Buffer
+		if err := s.value.Execute(&value, data); err != nil {
+			return err
+		}
+
+		el, err := page.Element(s.Selector)
+		if err != nil {
+			return err
+		}
+		return el.Input(value.String())
+	case "click":
+		el, err := page.Element(s.Selector)
+		if err != nil {
+			return err
+		}
+		return el.Click(proto.InputMouseButtonLeft, 1)
+	case "wait":
+		switch {
+		case s.Duration > 0:
+			if !sleep(ctx, s.Duration) {
+				return ctx.Err()
+			}
+			return nil
+		case s.Selector != "" && s.Text != "":
+			el, err := page.ElementR(s.Selector, s.Text)
+			if err != nil {
+				return err
+			}
+			return el.WaitVisible()
+		case s.Selector != "":
+			el, err := page.Element(s.Selector)
+			if err != nil {
+				return err
+			}
+			return el.WaitVisible()
+		default:
+			return page.WaitIdle(timeout)
+		}
+	}
+
+	return fmt.Errorf("unknown action %q", s.Action)
+}
+
+// checkResult waits for a success or failure marker to show up, recipes
+// without markers fall back to the toasts shown by the page
+func (r *Recipe) checkResult(page *rod.Page) *Result {
+	if len(r.Success) == 0 && len(r.Failure) == 0 {
+		return pageOutcome(page, r.ResultTimeout)
+	}
+
+	deadline := time.Now().Add(r.ResultTimeout)
+	for time.Now().Before(deadline) {
+		for _, marker := range r.Failure {
+			if found, text := marker.find(page); found {
+				return failureResult(marker, text)
+			}
+		}
+
+		for _, marker := range r.Success {
+			if found, text := marker.find(page); found {
+				return &Result{Status: StatusClaimed, Reason: text}
+			}
+		}
+
+		if !sleep(page.GetContext(), 500*time.Millisecond) {
+			return failed(page.GetContext().Err())
+		}
+	}
+
+	if len(r.Success) == 0 {
+		return &Result{Status: StatusUnconfirmed, Reason: reasonUnconfirmed}
+	}
+	return &Result{
+		Status: StatusFailed,
+		Reason: fmt.Sprintf("no success marker found after %s", r.ResultTimeout),
+	}
+}
+
+// failureResult classifies the text of a failure marker, so rate limits
+// and captchas are told apart from other failures
+func failureResult(marker Marker, text string) *Result {
+	status, ok := ClassifyMessage(text)
+	if !ok || status == StatusClaimed {
+		status = StatusFailed
+	}
+	return &Result{
+		Status: status,
+		Reason: fmt.Sprintf("faucet reported failure (%s): %s", marker, text),
+	}
+}
+
+// find reports whether the marker is on the page, and the matching text
+func (m Marker) find(page *rod.Page) (bool, string) {
+	var (
+		found bool
+		el    *rod.Element
+		err   error
+	)
+
+	switch {
+	case m.Text != "":
+		selector := m.Selector
+		if selector == "" {
+			selector = "body *"
+		}
+		found, el, err = page.HasR(selector, m.Text)
+	default:
+		found, el, err = page.Has(m.Selector)
+	}
+
+	if err != nil || !found {
+		return false, ""
+	}
+
+	text, _ := el.Text()
+	return true, strings.TrimSpace(text)
+}
@@ -0,0 +1,111 @@
+package faucet
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRecipe = `
+name: recipe-test
+url: https://faucet.test
+steps:
+  - action: input
+    selector: .wallet-address-container input
+    value: "{{.Address}}"
+  - name: claim
+    action: click
+    selector: button
+  - action: wait
+    timeout: 10s
+success:
+  - text: "sent"
+failure:
+  - selector: .toast-error
+`
+
+func writeRecipe(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadRecipe(t *testing.T) {
+	path := writeRecipe(t, t.TempDir(), "recipe.yaml", testRecipe)
+
+	recipe, err := LoadRecipe(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "recipe-test", recipe.Name)
+	assert.Len(t, recipe.Steps, 3)
+	assert.Equal(t, "step 1 (input)", recipe.Steps[0].Name)
+	assert.Equal(t, "claim", recipe.Steps[1].Name)
+	assert.Equal(t, 10*time.Second, recipe.Steps[2].Timeout)
+	assert.Zero(t, recipe.Steps[0].Timeout)
+	assert.Equal(t, defaultResultTimeout, recipe.ResultTimeout)
+
+	var value bytes.Buffer
+	assert.NoError(t, recipe.Steps[0].value.Execute(&value, recipeData{Address: "0xabc"}))
+	assert.Equal(t, "0xabc", value.String())
+}
+
+func TestLoadRecipe_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	invalid := []string{
+		`steps: []`,
+		`{"url": "https://faucet.test", "steps": [{"action": "hover", "selector": "a"}]}`,
+		`{"url": "https://faucet.test", "steps": [{"action": "click"}]}`,
+		`{"url": "https://faucet.test", "steps": [{"action": "input", "selector": "a", "value": "{{.Address"}]}`,
+		`{"url": "https://faucet.test", "success": [{}]}`,
+		`{"url": "https://faucet.test", "type": "ftp"}`,
+		`{"url": "https://faucet.test", "type": "http", "steps": [{"action": "click", "selector": "a"}]}`,
+		`{"url": "https://faucet.test", "type": "http", "request": {"body": "{{.Address"}}`,
+		`{"url": "https://faucet.test", "type": "http", "request": {"success": {"match": "("}}}`,
+	}
+	for _, content := range invalid {
+		_, err := LoadRecipe(writeRecipe(t, dir, "invalid.json", content))
+		assert.Error(t, err, content)
+	}
+}
+
+func TestLoadRecipes(t *testing.T) {
+	dir := t.TempDir()
+	writeRecipe(t, dir, "recipe-json.json", `{"url": "https://json.test"}`)
+	writeRecipe(t, dir, "recipe-yaml.yml", "url: https://yaml.test\n")
+	writeRecipe(t, dir, "notes.txt", "not a recipe")
+
+	recipes, err := LoadRecipes(dir)
+	assert.NoError(t, err)
+	assert.Len(t, recipes, 2)
+
+	assert.Contains(t, Names(), "recipe-json")
+	assert.Contains(t, Names(), "recipe-yaml")
+	assert.NotContains(t, Names(), "")
+
+	claimer, err := New("recipe-yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://yaml.test", claimer.(*RecipeFaucetClaimer).Recipe.URL)
+
+	// names are unique across built-in faucets and recipes
+	_, err = LoadRecipes(dir)
+	assert.Error(t, err)
+}
+
+func TestRegister(t *testing.T) {
+	Register("register-test", func() FaucetClaimer { return &DefaultFaucetClaimer{} })
+	assert.Contains(t, Names(), "register-test")
+
+	assert.Panics(t, func() {
+		Register("register-test", func() FaucetClaimer { return &DefaultFaucetClaimer{} })
+	})
+	assert.Panics(t, func() { Register("nil-test", nil) })
+
+	_, err := New("missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,281 @@
+package faucet
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const proxyDialTimeout = 30 * time.Second
+
+// proxyRelay is a local HTTP proxy without credentials forwarding every
+// connection through an upstream proxy which needs them, since browsers
+// can not authenticate to SOCKS5 proxies and only prompt for HTTP ones
+type proxyRelay struct {
+	upstream *Proxy
+	listener net.Listener
+	wg       sync.WaitGroup
+}
+
+// startRelay listens on a random local port and relays to upstream
+func startRelay(upstream *Proxy) (*proxyRelay, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	r := &proxyRelay{upstream: upstream, listener: listener}
+	r.wg.Add(1)
+	go r.serve()
+
+	return r, nil
+}
+
+// URL is the relay address to give to the browser
+func (r *proxyRelay) URL() string {
+	return "http://" + r.listener.Addr().String()
+}
+
+// Close stops accepting connections, relayed connections end with the browser
+func (r *proxyRelay) Close() error {
+	err := r.listener.Close()
+	r.wg.Wait()
+	return err
+}
+
+func (r *proxyRelay) serve() {
+	defer r.wg.Done()
+
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			return
+		}
+		go r.handle(conn)
+	}
+}
+
+func (r *proxyRelay) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	req, err := http.ReadRequest(reader)
+	if err != nil {
+		return
+	}
+
+	target := req.Host
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		target = net.JoinHostPort(target, "80")
+	}
+
+	upstream, err := r.upstream.dial(target)
+	if err != nil {
+		fmt.Fprintf(conn, "HTTP/1.1 502 Bad Gateway\r\nContent-Length: 0\r\n\r\n")
+		return
+	}
+	defer upstream.Close()
+
+	if req.Method == http.MethodConnect {
+		if _, err := io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+			return
+		}
+	} else {
+		// plain http, one request per connection keeps the relay simple
+		req.Close = true
+		req.Header.Del("Proxy-Connection")
+		if err := req.Write(upstream); err != nil {
+			return
+		}
+	}
+
+	pipe(conn, reader, upstream)
+}
+
+// pipe copies between the client and upstream until either side closes
+func pipe(client net.Conn, clientReader io.Reader, upstream net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(upstream, clientReader)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(client, upstream)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// dial connects to target through the proxy
+func (p *Proxy) dial(target string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: proxyDialTimeout}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if p.url.Scheme == "https" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", p.url.Host, &tls.Config{ServerName: p.url.Hostname()})
+	} else {
+		conn, err = dialer.Dial("tcp", p.url.Host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Now().Add(proxyDialTimeout))
+	if p.url.Scheme == "socks5" {
+		err = p.socks5Connect(conn, target)
+	} else {
+		conn, err = p.httpConnect(conn, target)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s: %v", p, err)
+	}
+	conn.SetDeadline(time.Time{})
+
+	return conn, nil
+}
+
+// httpConnect opens a tunnel to target with an HTTP CONNECT request
+func (p *Proxy) httpConnect(conn net.Conn, target string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: target},
+		Host:   target,
+		Header: make(http.Header),
+	}
+	if p.url.User != nil {
+		password, _ := p.url.User.Password()
+		credentials := p.url.User.Username() + ":" + password
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	}
+
+	if err := req.Write(conn); err != nil {
+		return conn, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return conn, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return conn, fmt.Errorf("CONNECT %s: %s", target, resp.Status)
+	}
+
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// bufferedConn reads through the reader used to parse the proxy response
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// socks5Connect runs the RFC 1928 handshake, with RFC 1929 username and
+// password authentication when the proxy has credentials
+func (p *Proxy) socks5Connect(conn net.Conn, target string) error {
+	method := byte(0x00)
+	if p.url.User != nil {
+		method = 0x02
+	}
+
+	if _, err := conn.Write([]byte{0x05, 0x01, method}); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[0] != 0x05 || reply[1] != method {
+		return errors.New("socks5: authentication method not accepted")
+	}
+
+	if method == 0x02 {
+		username := p.url.User.Username()
+		password, _ := p.url.User.Password()
+		if len(username) > 255 || len(password) > 255 {
+			return errors.New("socks5: credentials too long")
+		}
+
+		auth := []byte{0x01, byte(len(username))}
+		auth = append(auth, username...)
+		auth = append(auth, byte(len(password)))
+		auth = append(auth, password...)
+		if _, err := conn.Write(auth); err != nil {
+			return err
+		}
+
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			return err
+		}
+		if reply[1] != 0x00 {
+			return errors.New("socks5: authentication failed")
+		}
+	}
+
+	host, portString, err := net.SplitHostPort(target)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(portString)
+	if err != nil {
+		return fmt.Errorf("socks5: invalid port %q", portString)
+	}
+	if len(host) > 255 {
+		return errors.New("socks5: host name too long")
+	}
+
+	request := []byte{0x05, 0x01, 0x00, 0x03, byte(len(host))}
+	request = append(request, host...)
+	request = binary.BigEndian.AppendUint16(request, uint16(port))
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[1] != 0x00 {
+		return fmt.Errorf("socks5: connect to %s failed with code %d", target, header[1])
+	}
+
+	// skip the bound address
+	var skip int
+	switch header[3] {
+	case 0x01:
+		skip = net.IPv4len
+	case 0x04:
+		skip = net.IPv6len
+	case 0x03:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return err
+		}
+		skip = int(length[0])
+	default:
+		return fmt.Errorf("socks5: invalid address type %d", header[3])
+	}
+	_, err = io.ReadFull(conn, make([]byte, skip+2))
+	return err
+}
@@ -0,0 +1,159 @@
+package faucet
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// listen starts a TCP server handling every connection with handle
+func listen(t *testing.T, handle func(net.Conn)) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func echo(conn net.Conn) {
+	io.Copy(conn, conn)
+}
+
+// httpProxy is a CONNECT proxy requiring user:secret
+func httpProxy(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	req, err := http.ReadRequest(reader)
+	if err != nil {
+		return
+	}
+
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:secret"))
+	if req.Method != http.MethodConnect || req.Header.Get("Proxy-Authorization") != want {
+		fmt.Fprint(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+		return
+	}
+
+	target, err := net.Dial("tcp", req.Host)
+	if err != nil {
+		fmt.Fprint(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+		return
+	}
+	defer target.Close()
+
+	fmt.Fprint(conn, "HTTP/1.1 200 OK\r\n\r\n")
+	pipe(conn, reader, target)
+}
+
+// socks5Proxy is a SOCKS5 proxy requiring user:secret
+func socks5Proxy(conn net.Conn) {
+	greeting := make([]byte, 3)
+	if _, err := io.ReadFull(conn, greeting); err != nil || greeting[2] != 0x02 {
+		conn.Write([]byte{0x05, 0xff})
+		return
+	}
+	conn.Write([]byte{0x05, 0x02})
+
+	header := make([]byte, 2)
+	io.ReadFull(conn, header)
+	username := make([]byte, header[1])
+	io.ReadFull(conn, username)
+	io.ReadFull(conn, header[:1])
+	password := make([]byte, header[0])
+	io.ReadFull(conn, password)
+	if string(username) != "user" || string(password) != "secret" {
+		conn.Write([]byte{0x01, 0x01})
+		return
+	}
+	conn.Write([]byte{0x01, 0x00})
+
+	request := make([]byte, 5)
+	io.ReadFull(conn, request)
+	host := make([]byte, request[4])
+	io.ReadFull(conn, host)
+	port := make([]byte, 2)
+	io.ReadFull(conn, port)
+
+	target, err := net.Dial("tcp", net.JoinHostPort(string(host), fmt.Sprint(binary.BigEndian.Uint16(port))))
+	if err != nil {
+		conn.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+
+	conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 127, 0, 0, 1, 0, 0})
+	pipe(conn, conn, target)
+}
+
+// connectThrough opens a tunnel to target through the relay and echoes a message
+func connectThrough(t *testing.T, relay *proxyRelay, target string) (string, error) {
+	conn, err := net.Dial("tcp", relay.listener.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n\r\n", target, target)
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s", resp.Status)
+	}
+
+	fmt.Fprint(conn, "hello\n")
+	return reader.ReadString('\n')
+}
+
+func TestRelay(t *testing.T) {
+	target := listen(t, echo)
+
+	tests := []struct {
+		name    string
+		proxy   string
+		wantErr bool
+	}{
+		{"http", "http://user:secret@" + listen(t, httpProxy), false},
+		{"http wrong password", "http://user:wrong@" + listen(t, httpProxy), true},
+		{"socks5", "socks5://user:secret@" + listen(t, socks5Proxy), false},
+		{"socks5 wrong password", "socks5://user:wrong@" + listen(t, socks5Proxy), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			proxy, err := ParseProxy(test.proxy)
+			assert.NoError(t, err)
+
+			relay, err := startRelay(proxy)
+			assert.NoError(t, err)
+			defer relay.Close()
+
+			line, err := connectThrough(t, relay, target)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "hello\n", line)
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package faucet
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/galihrivanto/omonOmon/wallet"
+	"github.com/go-rod/rod"
+)
+
+// Status is the outcome of a claim
+type Status string
+
+const (
+	StatusClaimed     Status = "claimed"
+	StatusRateLimited Status = "rate-limited"
+	StatusCaptcha     Status = "captcha-blocked"
+	StatusFailed      Status = "failed"
+	// StatusUnconfirmed means the claim was submitted but the page showed
+	// no result. ClaimWithOptions resolves it with a balance check, or
+	// reports it as failed.
+	StatusUnconfirmed Status = "unconfirmed"
+	// StatusSkipped means no claim was attempted, e.g. during a cooldown
+	StatusSkipped Status = "skipped"
+)
+
+const reasonUnconfirmed = "no confirmation shown on page"
+
+// Result describes the outcome of a claim
+type Result struct {
+	Faucet  string
+	Address string
+	Status  Status
+	Reason  string
+	// Increase is the balance increase observed, in wei, when verified
+	Increase *big.Int
+	// Proxy is the health of the proxy used for the claim, if any
+	Proxy *ProxyHealth
+	// Step is the automation step which failed
+	Step string
+	// Artifacts is the directory holding the diagnostics of a failed claim
+	Artifacts string
+}
+
+// Err returns nil when the claim succeeded, or an error describing the outcome
+func (r *Result) Err() error {
+	if r.Status == StatusClaimed {
+		return nil
+	}
+	return fmt.Errorf("faucet %s: %s: %s", r.Faucet, r.Status, r.Reason)
+}
+
+func (r *Result) String() string {
+	if r.Reason == "" {
+		return string(r.Status)
+	}
+	return fmt.Sprintf("%s: %s", r.Status, r.Reason)
+}
+
+// failed builds a failed result from err
+func failed(err error) *Result {
+	return &Result{Status: StatusFailed, Reason: err.Error()}
+}
+
+var (
+	captchaPattern     = regexp.MustCompile(`(?i)captcha|verify (that )?you are (a )?human|turnstile|are you a robot`)
+	rateLimitPattern   = regexp.MustCompile(`(?i)already claimed|rate.?limit|too many requests|try again (later|in)|cooldown`)
+	cooldownPattern    = regexp.MustCompile(`(?i)come back|once (every|per)|\b24 ?h(ours)?\b|wait \d+`)
+	failurePattern     = regexp.MustCompile(`(?i)error|failed|failure|invalid|insufficient|not eligible|denied|unable`)
+	successPattern     = regexp.MustCompile(`(?i)success|\bsent\b|claimed|on (its|the) way|transaction|tx hash|0x[0-9a-f]{64}`)
+	toastSelectors     = `[role=alert], [role=status], .toast, .Toastify__toast, [data-sonner-toast], .notification, .alert, .chakra-alert`
+	toastPollInterval  = 500 * time.Millisecond
+	defaultToastWait   = 15 * time.Second
+	defaultBalanceWait = 2 * time.Minute
+	defaultClaimWait   = 5 * time.Minute
+)
+
+// ClassifyMessage maps a message shown by a faucet to a claim status.
+// Failures are checked before successes since error toasts often mention
+// what would have been sent. Mentions of the next claim only mean a rate
+// limit without a success, success toasts announce them too.
+func ClassifyMessage(text string) (Status, bool) {
+	switch {
+	case captchaPattern.MatchString(text):
+		return StatusCaptcha, true
+	case rateLimitPattern.MatchString(text):
+		return StatusRateLimited, true
+	case failurePattern.MatchString(text):
+		return StatusFailed, true
+	case successPattern.MatchString(text):
+		return StatusClaimed, true
+	case cooldownPattern.MatchString(text):
+		return StatusRateLimited, true
+	}
+	return "", false
+}
+
+// pageOutcome waits for a toast or alert on the page and classifies it
+func pageOutcome(page *rod.Page, wait time.Duration) *Result {
+	ctx := page.GetContext()
+	deadline := time.Now().Add(wait)
+	for {
+		elements, err := page.Elements(toastSelectors)
+		if err == nil {
+			for _, el := range elements {
+				text, err := el.Text()
+				if err != nil {
+					continue
+				}
+				text = strings.TrimSpace(text)
+				if status, ok := ClassifyMessage(text); ok {
+					return &Result{Status: status, Reason: text}
+				}
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return &Result{Status: StatusUnconfirmed, Reason: reasonUnconfirmed}
+		}
+		if !sleep(ctx, toastPollInterval) {
+			return failed(ctx.Err())
+		}
+	}
+}
+
+// ClaimOptions controls how claims are verified
+type ClaimOptions struct {
+	// VerifyBalance polls the address balance after the claim until it
+	// grew by at least MinIncrease
+	VerifyBalance bool
+	// MinIncrease is the expected balance increase in wei, defaults to any increase
+	MinIncrease *big.Int
+	// BalanceTimeout bounds how long the balance is polled
+	BalanceTimeout time.Duration
+	// PollInterval is the delay between balance checks
+	PollInterval time.Duration
+	// Balance returns the balance of an address in wei, defaults to wallet.BalanceAt
+	Balance func(ctx context.Context, address string) (*big.Int, error)
+	// Browser configures the browser of browser based claimers
+	Browser BrowserOptions
+	// Proxies assigns a proxy of the pool to every claim, replacing Browser.Proxy
+	Proxies *ProxyPool
+	// CaptchaSolver passes the captchas found by browser based claimers,
+	// without one a captcha ends the claim as captcha blocked
+	CaptchaSolver CaptchaSolver
+	// Wallet holds the key of the claimed address, for faucets asking to
+	// connect a wallet or sign a message. Browser pages get it as an
+	// injected window.ethereum provider.
+	Wallet *wallet.Wallet
+	// ArtifactsDir saves a screenshot, the page HTML, console messages and
+	// network requests of failed claims, in a directory per attempt
+	ArtifactsDir string
+	// StepTimeout bounds every browser step, such as navigating or
+	// finding an element, defaults to 30 seconds
+	StepTimeout time.Duration
+	// Timeout is the deadline of the whole claim, balance verification
+	// excluded, defaults to 5 minutes
+	Timeout time.Duration
+}
+
+func (o ClaimOptions) withDefaults() ClaimOptions {
+	if o.MinIncrease == nil || o.MinIncrease.Sign() <= 0 {
+		o.MinIncrease = big.NewInt(1)
+	}
+	if o.BalanceTimeout <= 0 {
+		o.BalanceTimeout = defaultBalanceWait
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = 5 * time.Second
+	}
+	if o.Balance == nil {
+		o.Balance = wallet.BalanceAt
+	}
+	if o.StepTimeout <= 0 {
+		o.StepTimeout = defaultStepTimeout
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaultClaimWait
+	}
+	return o
+}
+
+// verify resolves the claim result with the balance observed after the
+// claim. Nothing is known of a claim whose verification is interrupted by
+// ctx, it is reported as skipped.
+func (o ClaimOptions) verify(ctx context.Context, result *Result, address string, before *big.Int) *Result {
+	switch result.Status {
+	case StatusClaimed, StatusUnconfirmed:
+	default:
+		return result
+	}
+
+	deadline := time.Now().Add(o.BalanceTimeout)
+	for {
+		after, err := o.Balance(ctx, address)
+		if err == nil {
+			increase := new(big.Int).Sub(after, before)
+			if increase.Cmp(o.MinIncrease) >= 0 {
+				if result.Status == StatusUnconfirmed {
+					result.Reason = fmt.Sprintf("balance increased by %s wei", increase)
+				}
+				result.Status = StatusClaimed
+				result.Increase = increase
+				return result
+			}
+		}
+
+		if ctx.Err() != nil || !sleep(ctx, o.PollInterval) {
+			result.Status = StatusSkipped
+			result.Reason = fmt.Sprintf("cancelled during balance check (page: %s)", result.Reason)
+			return result
+		}
+		if time.Now().After(deadline) {
+			result.Status = StatusFailed
+			result.Reason = fmt.Sprintf("balance did not increase within %s (page: %s)", o.BalanceTimeout, result.Reason)
+			return result
+		}
+	}
+}
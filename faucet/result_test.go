@@ -0,0 +1,123 @@
+package faucet
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyMessage(t *testing.T) {
+	tests := []struct {
+		text   string
+		status Status
+		ok     bool
+	}{
+		{"Success! 0.5 MON sent to your wallet", StatusClaimed, true},
+		{"Tokens are on the way", StatusClaimed, true},
+		{"0.5 MON sent! Come back in 24 hours", StatusClaimed, true},
+		{"Come back in 24 hours", StatusRateLimited, true},
+		{"You already claimed today, come back in 24 hours", StatusRateLimited, true},
+		{"Too many requests", StatusRateLimited, true},
+		{"Please complete the captcha", StatusCaptcha, true},
+		{"Verify you are human", StatusCaptcha, true},
+		{"Transaction failed", StatusFailed, true},
+		{"Invalid address", StatusFailed, true},
+		{"Welcome to the faucet", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			status, ok := ClassifyMessage(test.text)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.status, status)
+		})
+	}
+}
+
+// fakeBalance returns each balance in turn, repeating the last one
+func fakeBalance(balances ...int64) func(context.Context, string) (*big.Int, error) {
+	return func(ctx context.Context, address string) (*big.Int, error) {
+		balance := balances[0]
+		if len(balances) > 1 {
+			balances = balances[1:]
+		}
+		return big.NewInt(balance), nil
+	}
+}
+
+func TestClaimOptionsVerify(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   Status
+		balances []int64
+		want     Status
+		increase int64
+	}{
+		{"confirmed", StatusUnconfirmed, []int64{100, 100, 150}, StatusClaimed, 50},
+		{"page success", StatusClaimed, []int64{150}, StatusClaimed, 50},
+		{"no increase", StatusUnconfirmed, []int64{100}, StatusFailed, 0},
+		{"increase too small", StatusClaimed, []int64{105}, StatusFailed, 0},
+		{"rate limited is kept", StatusRateLimited, []int64{100}, StatusRateLimited, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := ClaimOptions{
+				VerifyBalance:  true,
+				MinIncrease:    big.NewInt(10),
+				BalanceTimeout: 50 * time.Millisecond,
+				PollInterval:   5 * time.Millisecond,
+				Balance:        fakeBalance(test.balances...),
+			}.withDefaults()
+
+			result := opts.verify(context.Background(), &Result{Status: test.status}, "0xabc", big.NewInt(100))
+			assert.Equal(t, test.want, result.Status)
+			if test.increase > 0 {
+				assert.Equal(t, big.NewInt(test.increase), result.Increase)
+			}
+		})
+	}
+}
+
+func TestClaimOptionsVerifyBalanceError(t *testing.T) {
+	opts := ClaimOptions{
+		BalanceTimeout: 20 * time.Millisecond,
+		PollInterval:   5 * time.Millisecond,
+		Balance: func(context.Context, string) (*big.Int, error) {
+			return nil, errors.New("rpc down")
+		},
+	}.withDefaults()
+
+	result := opts.verify(context.Background(), &Result{Status: StatusClaimed, Reason: "sent"}, "0xabc", big.NewInt(0))
+	assert.Equal(t, StatusFailed, result.Status)
+	assert.Error(t, result.Err())
+}
+
+func TestClaimOptionsVerifyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	opts := ClaimOptions{
+		BalanceTimeout: time.Minute,
+		PollInterval:   5 * time.Millisecond,
+		Balance: func(ctx context.Context, address string) (*big.Int, error) {
+			cancel()
+			return nil, ctx.Err()
+		},
+	}.withDefaults()
+
+	result := opts.verify(ctx, &Result{Status: StatusUnconfirmed, Reason: "no toast"}, "0xabc", big.NewInt(0))
+	assert.Equal(t, StatusSkipped, result.Status)
+	assert.Equal(t, "cancelled during balance check (page: no toast)", result.Reason)
+}
+
+func TestFailureResult(t *testing.T) {
+	marker := Marker{Selector: ".toast-error"}
+
+	assert.Equal(t, StatusRateLimited, failureResult(marker, "Already claimed").Status)
+	assert.Equal(t, StatusCaptcha, failureResult(marker, "captcha required").Status)
+	assert.Equal(t, StatusFailed, failureResult(marker, "something went wrong").Status)
+	assert.Equal(t, StatusFailed, failureResult(marker, "tx sent").Status)
+}
@@ -0,0 +1,197 @@
+package faucet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultCooldown   = 24 * time.Hour
+	defaultRetryDelay = time.Hour
+)
+
+// Schedule lists the faucet and account pairs claimed by the scheduler.
+// Schedules are YAML or JSON files.
+type Schedule struct {
+	// Jitter is the maximum random delay added after each cooldown
+	Jitter time.Duration `yaml:"jitter"`
+	// Cooldown is how long an address waits after a claim, defaults to 24h
+	Cooldown time.Duration `yaml:"cooldown"`
+	// RetryDelay is how long to wait before retrying a failed claim, defaults to 1h
+	RetryDelay time.Duration   `yaml:"retryDelay"`
+	Entries    []ScheduleEntry `yaml:"entries"`
+}
+
+// ScheduleEntry claims Faucet for Addresses and for the accounts found in
+// Accounts, a file or directory as read by LoadAccounts. Relative paths
+// are resolved against the schedule file.
+type ScheduleEntry struct {
+	Faucet    string   `yaml:"faucet"`
+	Accounts  string   `yaml:"accounts"`
+	Addresses []string `yaml:"addresses"`
+	// Cooldown overrides the schedule cooldown for this faucet
+	Cooldown time.Duration `yaml:"cooldown"`
+}
+
+// LoadSchedule loads and validates a schedule file
+func LoadSchedule(path string) (*Schedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var schedule Schedule
+	if err := yaml.Unmarshal(data, &schedule); err != nil {
+		return nil, fmt.Errorf("%s: invalid schedule: %v", path, err)
+	}
+
+	if schedule.Cooldown <= 0 {
+		schedule.Cooldown = defaultCooldown
+	}
+	if schedule.RetryDelay <= 0 {
+		schedule.RetryDelay = defaultRetryDelay
+	}
+	if len(schedule.Entries) == 0 {
+		return nil, fmt.Errorf("%s: no entries", path)
+	}
+
+	for i := range schedule.Entries {
+		entry := &schedule.Entries[i]
+		if entry.Cooldown <= 0 {
+			entry.Cooldown = schedule.Cooldown
+		}
+
+		if entry.Accounts != "" {
+			accounts := entry.Accounts
+			if !filepath.IsAbs(accounts) {
+				accounts = filepath.Join(filepath.Dir(path), accounts)
+			}
+			addresses, err := LoadAccounts(accounts)
+			if err != nil {
+				return nil, fmt.Errorf("%s: entry %d: %v", path, i+1, err)
+			}
+			entry.Addresses = append(entry.Addresses, addresses...)
+		}
+
+		for j, address := range entry.Addresses {
+			account, err := parseAccount(address)
+			if err != nil {
+				return nil, fmt.Errorf("%s: entry %d: %s: %v", path, i+1, address, err)
+			}
+			entry.Addresses[j] = account
+		}
+		entry.Addresses = dedupe(entry.Addresses)
+
+		if len(entry.Addresses) == 0 {
+			return nil, fmt.Errorf("%s: entry %d: no accounts", path, i+1)
+		}
+	}
+
+	return &schedule, nil
+}
+
+// scheduledClaim is a faucet and address pair with its next planned claim
+type scheduledClaim struct {
+	faucet   string
+	address  string
+	cooldown time.Duration
+	next     time.Time
+}
+
+// Scheduler claims every scheduled pair as soon as its cooldown is over,
+// one claim at a time
+type Scheduler struct {
+	Schedule *Schedule
+	State    *State
+	History  *History
+	Claim    ClaimOptions
+	// OnResult is called after each claim
+	OnResult func(*Result)
+	// OnWait is called before waiting for the next claim
+	OnWait func(faucet string, address string, at time.Time)
+
+	claims []*scheduledClaim
+}
+
+// Run claims until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) error {
+	if s.State == nil {
+		return errors.New("scheduler needs a state")
+	}
+
+	for _, entry := range s.Schedule.Entries {
+		if _, err := New(entry.Faucet); err != nil {
+			return err
+		}
+		for _, address := range entry.Addresses {
+			job := &scheduledClaim{faucet: entry.Faucet, address: address, cooldown: entry.Cooldown}
+			s.plan(job, time.Now())
+			s.claims = append(s.claims, job)
+		}
+	}
+
+	if len(s.claims) == 0 {
+		return errors.New("nothing to schedule")
+	}
+
+	for {
+		job := s.nextClaim()
+		if s.OnWait != nil {
+			s.OnWait(job.faucet, job.address, job.next)
+		}
+		if !sleep(ctx, time.Until(job.next)) {
+			return nil
+		}
+
+		result := claim(ctx, job.faucet, job.address, s.Claim)
+		// the cooldown is kept in memory when it can not be saved, the
+		// schedule goes on
+		if err := record(s.State, s.History, job.cooldown, result); err != nil {
+			fmt.Println(err)
+		}
+		if s.OnResult != nil {
+			s.OnResult(result)
+		}
+
+		switch result.Status {
+		case StatusClaimed, StatusRateLimited:
+			s.plan(job, time.Now())
+		default:
+			job.next = time.Now().Add(s.Schedule.RetryDelay + s.jitter())
+		}
+	}
+}
+
+// plan sets the next claim to the end of the cooldown recorded in the
+// state, plus jitter
+func (s *Scheduler) plan(claim *scheduledClaim, now time.Time) {
+	next := now
+	if eligible, until := s.State.Eligible(claim.faucet, claim.address, now); !eligible {
+		next = until
+	}
+	claim.next = next.Add(s.jitter())
+}
+
+func (s *Scheduler) jitter() time.Duration {
+	if s.Schedule.Jitter <= 0 {
+		return 0
+	}
+	return rand.N(s.Schedule.Jitter + 1)
+}
+
+func (s *Scheduler) nextClaim() *scheduledClaim {
+	next := s.claims[0]
+	for _, claim := range s.claims[1:] {
+		if claim.next.Before(next.next) {
+			next = claim
+		}
+	}
+	return next
+}
@@ -0,0 +1,136 @@
+package faucet
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSchedule(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "accounts.txt"), []byte(testPrivateKey+"\n"), 0644))
+
+	path := filepath.Join(dir, "schedule.yaml")
+	content := `
+jitter: 10m
+entries:
+  - faucet: default
+    accounts: accounts.txt
+    addresses: [` + testAddress + `]
+  - faucet: aprio
+    addresses: [` + testAddress + `]
+    cooldown: 12h
+`
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	schedule, err := LoadSchedule(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, schedule.Jitter)
+	assert.Equal(t, defaultRetryDelay, schedule.RetryDelay)
+	assert.Equal(t, []string{testAddress, testKeyAddress}, schedule.Entries[0].Addresses)
+	assert.Equal(t, defaultCooldown, schedule.Entries[0].Cooldown)
+	assert.Equal(t, 12*time.Hour, schedule.Entries[1].Cooldown)
+}
+
+func TestLoadScheduleInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("entries:\n  - faucet: default\n"), 0644))
+
+	_, err := LoadSchedule(path)
+	assert.ErrorContains(t, err, "no accounts")
+}
+
+// countingClaimer claims successfully and counts claims per address
+type countingClaimer struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *countingClaimer) Claim(ctx context.Context, address string) *Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[address]++
+	return &Result{Status: StatusClaimed}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	claimer := &countingClaimer{counts: make(map[string]int)}
+	Register("schedule-test", func() FaucetClaimer { return claimer })
+
+	dir := t.TempDir()
+	state, err := LoadState(filepath.Join(dir, "state.json"))
+	assert.NoError(t, err)
+
+	// the second address is in cooldown from a previous run
+	state.SetCooldown("schedule-test", testKeyAddress, time.Now().Add(time.Hour))
+
+	scheduler := &Scheduler{
+		Schedule: &Schedule{
+			Jitter:     5 * time.Millisecond,
+			RetryDelay: time.Hour,
+			Entries: []ScheduleEntry{{
+				Faucet:    "schedule-test",
+				Addresses: []string{testAddress, testKeyAddress},
+				Cooldown:  50 * time.Millisecond,
+			}},
+		},
+		State:   state,
+		History: OpenHistory(filepath.Join(dir, "history.jsonl")),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, scheduler.Run(ctx))
+
+	claimer.mu.Lock()
+	defer claimer.mu.Unlock()
+	assert.GreaterOrEqual(t, claimer.counts[testAddress], 2)
+	assert.Zero(t, claimer.counts[testKeyAddress])
+
+	entries, err := scheduler.History.Query(HistoryFilter{Faucet: "schedule-test"})
+	assert.NoError(t, err)
+	assert.Len(t, entries, claimer.counts[testAddress])
+
+	// the state survives a restart
+	loaded, err := LoadState(filepath.Join(dir, "state.json"))
+	assert.NoError(t, err)
+	eligible, _ := loaded.Eligible("schedule-test", testKeyAddress, time.Now())
+	assert.False(t, eligible)
+}
+
+func TestSchedulerRunRecordFailure(t *testing.T) {
+	claimer := &countingClaimer{counts: make(map[string]int)}
+	Register("schedule-record-test", func() FaucetClaimer { return claimer })
+
+	dir := t.TempDir()
+	state, err := LoadState(filepath.Join(dir, "state.json"))
+	assert.NoError(t, err)
+
+	scheduler := &Scheduler{
+		Schedule: &Schedule{
+			RetryDelay: time.Hour,
+			Entries: []ScheduleEntry{{
+				Faucet:    "schedule-record-test",
+				Addresses: []string{testAddress},
+				Cooldown:  50 * time.Millisecond,
+			}},
+		},
+		State: state,
+		// the history can not be written to a directory
+		History: OpenHistory(dir),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, scheduler.Run(ctx))
+
+	claimer.mu.Lock()
+	defer claimer.mu.Unlock()
+	assert.GreaterOrEqual(t, claimer.counts[testAddress], 2)
+}
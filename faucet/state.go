@@ -0,0 +1,118 @@
+package faucet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// State keeps, per faucet and address, when the address may claim again.
+// It is persisted as JSON so cooldowns survive between runs.
+type State struct {
+	// Cooldowns maps faucet name to address to the time the address is eligible again
+	Cooldowns map[string]map[string]time.Time `json:"cooldowns"`
+
+	mu   sync.Mutex
+	path string
+}
+
+// LoadState reads the state file at path, a missing file is an empty state
+func LoadState(path string) (*State, error) {
+	state := &State{
+		Cooldowns: make(map[string]map[string]time.Time),
+		path:      path,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("%s: invalid state: %v", path, err)
+	}
+	if state.Cooldowns == nil {
+		state.Cooldowns = make(map[string]map[string]time.Time)
+	}
+
+	return state, nil
+}
+
+// Eligible reports whether address may claim faucet at now, and otherwise
+// when it may
+func (s *State) Eligible(faucet string, address string, now time.Time) (bool, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until := s.Cooldowns[faucet][stateKey(address)]
+	return !now.Before(until), until
+}
+
+// SetCooldown marks address as not eligible for faucet until the given time
+func (s *State) SetCooldown(faucet string, address string, until time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Cooldowns[faucet] == nil {
+		s.Cooldowns[faucet] = make(map[string]time.Time)
+	}
+	s.Cooldowns[faucet][stateKey(address)] = until
+}
+
+// Save writes the state back to its file, expired cooldowns are dropped
+func (s *State) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for faucet, cooldowns := range s.Cooldowns {
+		for address, until := range cooldowns {
+			if !now.Before(until) {
+				delete(cooldowns, address)
+			}
+		}
+		if len(cooldowns) == 0 {
+			delete(s.Cooldowns, faucet)
+		}
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so a crash never leaves a truncated state
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// stateKey normalizes addresses so the checksum casing does not matter
+func stateKey(address string) string {
+	if common.IsHexAddress(address) {
+		return common.HexToAddress(address).Hex()
+	}
+	return address
+}
@@ -0,0 +1,39 @@
+package faucet
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateCooldown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	state, err := LoadState(path)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	eligible, _ := state.Eligible("default", testAddress, now)
+	assert.True(t, eligible)
+
+	until := now.Add(time.Hour).Truncate(time.Second)
+	state.SetCooldown("default", testAddress, until)
+	state.SetCooldown("default", testKeyAddress, now.Add(-time.Minute))
+	assert.NoError(t, state.Save())
+
+	loaded, err := LoadState(path)
+	assert.NoError(t, err)
+
+	eligible, next := loaded.Eligible("default", strings.ToLower(testAddress), now)
+	assert.False(t, eligible)
+	assert.True(t, until.Equal(next))
+
+	eligible, _ = loaded.Eligible("aprio", testAddress, now)
+	assert.True(t, eligible)
+
+	// expired cooldowns are not saved
+	assert.NotContains(t, loaded.Cooldowns["default"], testKeyAddress)
+}
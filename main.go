@@ -16,6 +16,10 @@ var rootCmd = &cobra.Command{
 func main() {
 	rootCmd.AddCommand(cli.WalletCmd)
 	rootCmd.AddCommand(cli.FaucetCmd)
+	rootCmd.AddCommand(cli.BridgeCmd)
+	rootCmd.AddCommand(cli.RunCmd)
+	rootCmd.AddCommand(cli.DexCmd)
+	rootCmd.AddCommand(cli.StakeCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
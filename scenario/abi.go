@@ -0,0 +1,153 @@
+package scenario
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// parseMethod parses a method signature such as transfer(address,uint256),
+// parameter names are allowed and ignored. Tuples are not supported.
+func parseMethod(signature string, returns []string) (abi.Method, error) {
+	open := strings.Index(signature, "(")
+	if open <= 0 || !strings.HasSuffix(signature, ")") {
+		return abi.Method{}, fmt.Errorf("invalid method %q, expected e.g. transfer(address,uint256)", signature)
+	}
+	name := strings.TrimSpace(signature[:open])
+
+	var params []string
+	if list := strings.TrimSpace(signature[open+1 : len(signature)-1]); list != "" {
+		params = strings.Split(list, ",")
+	}
+
+	inputs, err := arguments(params)
+	if err != nil {
+		return abi.Method{}, fmt.Errorf("invalid method %q: %v", signature, err)
+	}
+	outputs, err := arguments(returns)
+	if err != nil {
+		return abi.Method{}, fmt.Errorf("invalid returns: %v", err)
+	}
+
+	return abi.NewMethod(name, name, abi.Function, "", false, false, inputs, outputs), nil
+}
+
+func arguments(params []string) (abi.Arguments, error) {
+	var args abi.Arguments
+	for _, param := range params {
+		fields := strings.Fields(param)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("empty parameter")
+		}
+
+		typ, err := abi.NewType(fields[0], "", nil)
+		if err != nil {
+			return nil, err
+		}
+		switch typ.T {
+		case abi.TupleTy, abi.SliceTy, abi.ArrayTy:
+			return nil, fmt.Errorf("%s parameters are not supported", fields[0])
+		}
+		args = append(args, abi.Argument{Type: typ})
+	}
+	return args, nil
+}
+
+// pack encodes a call of method with args given as text
+func pack(method abi.Method, args []string) ([]byte, error) {
+	if len(args) != len(method.Inputs) {
+		return nil, fmt.Errorf("%s takes %d arguments, got %d", method.Sig, len(method.Inputs), len(args))
+	}
+
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		value, err := convert(method.Inputs[i].Type, arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %v", i+1, err)
+		}
+		values[i] = value
+	}
+
+	data, err := method.Inputs.Pack(values...)
+	if err != nil {
+		return nil, err
+	}
+	return append(method.ID, data...), nil
+}
+
+// convert parses arg as a value of the ABI type
+func convert(typ abi.Type, arg string) (interface{}, error) {
+	switch typ.T {
+	case abi.AddressTy:
+		if !common.IsHexAddress(arg) {
+			return nil, fmt.Errorf("invalid address %q", arg)
+		}
+		return common.HexToAddress(arg), nil
+	case abi.UintTy, abi.IntTy:
+		n, ok := new(big.Int).SetString(arg, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s %q", typ, arg)
+		}
+		if typ.Size > 64 {
+			return n, nil
+		}
+
+		value := reflect.New(typ.GetType()).Elem()
+		switch {
+		case typ.T == abi.UintTy && n.IsUint64() && !value.OverflowUint(n.Uint64()):
+			value.SetUint(n.Uint64())
+		case typ.T == abi.IntTy && n.IsInt64() && !value.OverflowInt(n.Int64()):
+			value.SetInt(n.Int64())
+		default:
+			return nil, fmt.Errorf("%s out of range for %s", arg, typ)
+		}
+		return value.Interface(), nil
+	case abi.BoolTy:
+		return strconv.ParseBool(arg)
+	case abi.StringTy:
+		return arg, nil
+	case abi.BytesTy:
+		return hexutil.Decode(arg)
+	case abi.FixedBytesTy:
+		data, err := hexutil.Decode(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) != typ.Size {
+			return nil, fmt.Errorf("expected %d bytes, got %d", typ.Size, len(data))
+		}
+		value := reflect.New(typ.GetType()).Elem()
+		reflect.Copy(value, reflect.ValueOf(data))
+		return value.Interface(), nil
+	}
+	return nil, fmt.Errorf("%s arguments are not supported", typ)
+}
+
+// format formats values read from a contract, space separated
+func format(values []interface{}) string {
+	texts := make([]string, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case common.Address:
+			texts[i] = v.Hex()
+		case []byte:
+			texts[i] = hexutil.Encode(v)
+		default:
+			rv := reflect.ValueOf(value)
+			if rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 {
+				data := make([]byte, rv.Len())
+				reflect.Copy(reflect.ValueOf(data), rv)
+				texts[i] = hexutil.Encode(data)
+			} else {
+				texts[i] = fmt.Sprint(value)
+			}
+		}
+	}
+	return strings.Join(texts, " ")
+}
@@ -0,0 +1,96 @@
+package scenario
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPack(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature string
+		args      []string
+		expected  string
+		err       string
+	}{
+		{
+			name:      "transfer",
+			signature: "transfer(address,uint256)",
+			args:      []string{"0x8ba1f109551bD432803012645Ac136ddd64DBA72", "1000"},
+			expected:  "0xa9059cbb0000000000000000000000008ba1f109551bd432803012645ac136ddd64dba7200000000000000000000000000000000000000000000000000000000000003e8",
+		},
+		{
+			name:      "named parameters",
+			signature: "transfer(address to, uint256 amount)",
+			args:      []string{"0x8ba1f109551bD432803012645Ac136ddd64DBA72", "0x3e8"},
+			expected:  "0xa9059cbb0000000000000000000000008ba1f109551bd432803012645ac136ddd64dba7200000000000000000000000000000000000000000000000000000000000003e8",
+		},
+		{
+			name:      "no arguments",
+			signature: "deposit()",
+			expected:  "0xd0e30db0",
+		},
+		{
+			name:      "small integers and bool",
+			signature: "set(uint8,bool)",
+			args:      []string{"7", "true"},
+			expected:  "0x9b60b9ac00000000000000000000000000000000000000000000000000000000000000070000000000000000000000000000000000000000000000000000000000000001",
+		},
+		{
+			name:      "out of range",
+			signature: "set(uint8,bool)",
+			args:      []string{"256", "true"},
+			err:       "argument 1: 256 out of range for uint8",
+		},
+		{
+			name:      "invalid address",
+			signature: "transfer(address,uint256)",
+			args:      []string{"bob", "1"},
+			err:       `argument 1: invalid address "bob"`,
+		},
+		{
+			name:      "argument count",
+			signature: "transfer(address,uint256)",
+			args:      []string{"0x8ba1f109551bD432803012645Ac136ddd64DBA72"},
+			err:       "transfer(address,uint256) takes 2 arguments, got 1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method, err := parseMethod(test.signature, nil)
+			assert.NoError(t, err)
+
+			data, err := pack(method, test.args)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, hexutil.Encode(data))
+		})
+	}
+}
+
+func TestParseMethodUnsupported(t *testing.T) {
+	_, err := parseMethod("swap(address[],uint256)", nil)
+	assert.ErrorContains(t, err, "address[] parameters are not supported")
+
+	_, err = parseMethod("balanceOf(address)", []string{"money"})
+	assert.ErrorContains(t, err, "invalid returns")
+}
+
+func TestFormat(t *testing.T) {
+	values := []interface{}{
+		big.NewInt(42),
+		common.HexToAddress("0x8ba1f109551bD432803012645Ac136ddd64DBA72"),
+		true,
+		[]byte{0xca, 0xfe},
+		[2]byte{0xbe, 0xef},
+	}
+	assert.Equal(t, "42 0x8ba1f109551bD432803012645Ac136ddd64DBA72 true 0xcafe 0xbeef", format(values))
+}
@@ -0,0 +1,168 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/galihrivanto/omonOmon/wallet"
+)
+
+// BatchOptions controls RunBatch
+type BatchOptions struct {
+	// Workers is the number of accounts running the scenario at once
+	Workers int
+	// MaxStartDelay bounds the random delay before each account starts,
+	// once a worker is free to run it
+	MaxStartDelay time.Duration
+	// OnReport is called from the worker goroutines after each account
+	OnReport func(*Report)
+}
+
+func (o BatchOptions) withDefaults() BatchOptions {
+	if o.Workers <= 0 {
+		o.Workers = 1
+	}
+	if o.OnReport == nil {
+		o.OnReport = func(*Report) {}
+	}
+	return o
+}
+
+// RunBatch runs the scenario with every wallet, Workers at a time. The
+// reports are in wallet order.
+func (r *Runner) RunBatch(ctx context.Context, s *Scenario, wallets []*wallet.Wallet, opts BatchOptions) (*Summary, error) {
+	opts = opts.withDefaults()
+	if opts.Workers > 1 && r.Browser.UserDataDir != "" {
+		return nil, errors.New("a browser user data dir can not be shared by several workers")
+	}
+
+	started := time.Now()
+	reports := make([]*Report, len(wallets))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				w := wallets[index]
+
+				// the offset runs from when the worker takes the account, so
+				// accounts queued behind a busy worker are spread out too
+				if sleep(ctx, randomDelay(0, opts.MaxStartDelay)) != nil {
+					reports[index] = cancelledReport(s, w.Address)
+				} else {
+					reports[index] = r.Run(ctx, s, w)
+				}
+				opts.OnReport(reports[index])
+			}
+		}()
+	}
+
+	for index, w := range wallets {
+		select {
+		case jobs <- index:
+		case <-ctx.Done():
+			reports[index] = cancelledReport(s, w.Address)
+			opts.OnReport(reports[index])
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	summary := &Summary{Scenario: s.Name, Accounts: len(wallets), Reports: reports, Duration: time.Since(started)}
+	for _, report := range reports {
+		if report.Passed {
+			summary.Passed++
+		} else {
+			summary.Failed++
+		}
+	}
+	return summary, nil
+}
+
+// cancelledReport is the report of an account which did not start
+func cancelledReport(s *Scenario, address string) *Report {
+	report := &Report{Scenario: s.Name, Address: address}
+	for _, step := range s.Steps {
+		report.Steps = append(report.Steps, StepReport{Name: step.Name, Action: step.Action, Status: StepSkipped, Error: "cancelled"})
+	}
+	return report
+}
+
+// randomDelay returns a duration between min and max
+func randomDelay(min time.Duration, max time.Duration) time.Duration {
+	if max <= min {
+		return min
+	}
+	return min + rand.N(max-min+1)
+}
+
+// Summary aggregates the reports of a batch
+type Summary struct {
+	Scenario string        `json:"scenario"`
+	Accounts int           `json:"accounts"`
+	Passed   int           `json:"passed"`
+	Failed   int           `json:"failed"`
+	Reports  []*Report     `json:"reports"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Print writes a table of accounts, one of steps and the totals
+func (s *Summary) Print(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ADDRESS\tSTATUS\tSTEPS\tDURATION\tFAILED STEP")
+	for _, report := range s.Reports {
+		status, passed, failed := "passed", 0, ""
+		if !report.Passed {
+			status = "failed"
+		}
+		for _, step := range report.Steps {
+			switch {
+			case step.Status == StepPassed:
+				passed++
+			case failed == "":
+				failed = fmt.Sprintf("%s: %s", step.Name, step.Error)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\t%s\n", report.Address, status, passed, len(report.Steps),
+			report.Duration.Round(time.Second), failed)
+	}
+	w.Flush()
+	fmt.Fprintln(out)
+
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tPASSED\tFAILED\tSKIPPED")
+	for i, counts := range s.stepCounts() {
+		name := ""
+		if len(s.Reports) > 0 {
+			name = s.Reports[0].Steps[i].Name
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", name, counts[StepPassed], counts[StepFailed], counts[StepSkipped])
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\nDone %s: %d of %d accounts passed, %d failed, in %s\n",
+		s.Scenario, s.Passed, s.Accounts, s.Failed, s.Duration.Round(time.Second))
+}
+
+// stepCounts counts the outcomes of every step over the accounts
+func (s *Summary) stepCounts() []map[StepStatus]int {
+	var counts []map[StepStatus]int
+	for _, report := range s.Reports {
+		for i, step := range report.Steps {
+			if i == len(counts) {
+				counts = append(counts, make(map[StepStatus]int))
+			}
+			counts[i][step.Status]++
+		}
+	}
+	return counts
+}
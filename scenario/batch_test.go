@@ -0,0 +1,89 @@
+package scenario
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/galihrivanto/omonOmon/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+func testWallets(n int) []*wallet.Wallet {
+	wallets := make([]*wallet.Wallet, n)
+	for i := range wallets {
+		wallets[i] = wallet.GenerateWallet()
+	}
+	return wallets
+}
+
+func TestRunBatch(t *testing.T) {
+	scenario := &Scenario{Name: "daily", Steps: []Step{
+		{Action: ActionSend, To: testRecipient, Amount: "0.1"},
+		{Name: "funded", Action: ActionBalance, Min: "1"},
+	}}
+	assert.NoError(t, scenario.compile())
+
+	wallets := testWallets(3)
+	chain := &fakeChain{balances: map[string]*big.Int{
+		wallets[0].Address: big.NewInt(2e18),
+		wallets[2].Address: big.NewInt(3e18),
+	}}
+
+	runner := testRunner(chain)
+	runner.MaxStepDelay = 5 * time.Millisecond
+
+	var reported atomic.Int32
+	summary, err := runner.RunBatch(context.Background(), scenario, wallets, BatchOptions{
+		Workers:       2,
+		MaxStartDelay: 10 * time.Millisecond,
+		OnReport:      func(*Report) { reported.Add(1) },
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, int32(3), reported.Load())
+	assert.Equal(t, 3, summary.Accounts)
+	assert.Equal(t, 2, summary.Passed)
+	assert.Equal(t, 1, summary.Failed)
+	for i, report := range summary.Reports {
+		assert.Equal(t, wallets[i].Address, report.Address)
+	}
+	assert.False(t, summary.Reports[1].Passed)
+	assert.Equal(t, "balance 0 is below 1", summary.Reports[1].Steps[1].Error)
+	assert.Len(t, chain.sent, 3)
+
+	var out bytes.Buffer
+	summary.Print(&out)
+	assert.Contains(t, out.String(), wallets[1].Address+"  failed  1/2")
+	assert.Contains(t, out.String(), "funded: balance 0 is below 1")
+	assert.Contains(t, out.String(), "funded         2       1       0")
+	assert.Contains(t, out.String(), "Done daily: 2 of 3 accounts passed, 1 failed")
+}
+
+func TestRunBatchCancelled(t *testing.T) {
+	scenario := &Scenario{Steps: []Step{{Action: ActionSend, To: testRecipient, Amount: "0.1"}}}
+	assert.NoError(t, scenario.compile())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	chain := &fakeChain{}
+	summary, err := testRunner(chain).RunBatch(ctx, scenario, testWallets(3), BatchOptions{Workers: 2, MaxStartDelay: time.Minute})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, summary.Failed)
+	for _, report := range summary.Reports {
+		assert.Equal(t, StepSkipped, report.Steps[0].Status)
+	}
+	assert.Empty(t, chain.sent)
+}
+
+func TestRunBatchSharedProfile(t *testing.T) {
+	runner := testRunner(&fakeChain{})
+	runner.Browser.UserDataDir = "profile"
+
+	_, err := runner.RunBatch(context.Background(), &Scenario{}, testWallets(2), BatchOptions{Workers: 2})
+	assert.Error(t, err)
+}
@@ -0,0 +1,119 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+const defaultActionTimeout = 30 * time.Second
+
+// BrowserAction is a single action on the page of a browser step.
+// Selector and Value are templates like the step values.
+//
+//	click: click Selector
+//	input: type Value into Selector
+//	wait:  wait until Selector (and Text, if set) is visible, or for Duration
+//	read:  read the text of Selector, the output of the step
+type BrowserAction struct {
+	Action   string        `yaml:"action"`
+	Selector string        `yaml:"selector"`
+	Text     string        `yaml:"text"`
+	Value    string        `yaml:"value"`
+	Duration time.Duration `yaml:"duration"`
+	// Timeout bounds the action, defaults to 30s
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+func (a *BrowserAction) compile() error {
+	if a.Timeout <= 0 {
+		a.Timeout = defaultActionTimeout
+	}
+
+	switch a.Action {
+	case "click", "input", "read":
+		if a.Selector == "" {
+			return errors.New("selector is required")
+		}
+	case "wait":
+		if a.Selector == "" && a.Duration <= 0 {
+			return errors.New("selector or duration is required")
+		}
+	default:
+		return fmt.Errorf("unknown action %q", a.Action)
+	}
+	return nil
+}
+
+// run runs the action on page, returning the text read by read actions
+func (a *BrowserAction) run(page *rod.Page, vars map[string]string) (string, error) {
+	selector, err := render(a.Selector, vars)
+	if err != nil {
+		return "", err
+	}
+
+	ctx := page.GetContext()
+	page = page.Timeout(a.Timeout)
+	defer page.CancelTimeout()
+
+	switch a.Action {
+	case "click":
+		el, err := page.Element(selector)
+		if err != nil {
+			return "", err
+		}
+		return "", el.Click(proto.InputMouseButtonLeft, 1)
+	case "input":
+		value, err := render(a.Value, vars)
+		if err != nil {
+			return "", err
+		}
+		el, err := page.Element(selector)
+		if err != nil {
+			return "", err
+		}
+		return "", el.Input(value)
+	case "wait":
+		if a.Duration > 0 {
+			return "", sleep(ctx, a.Duration)
+		}
+
+		var el *rod.Element
+		if a.Text != "" {
+			el, err = page.ElementR(selector, a.Text)
+		} else {
+			el, err = page.Element(selector)
+		}
+		if err != nil {
+			return "", err
+		}
+		return "", el.WaitVisible()
+	case "read":
+		el, err := page.Element(selector)
+		if err != nil {
+			return "", err
+		}
+		text, err := el.Text()
+		return strings.TrimSpace(text), err
+	}
+
+	return "", fmt.Errorf("unknown action %q", a.Action)
+}
+
+// sleep waits for d, returning early with the error of ctx
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
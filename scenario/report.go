@@ -0,0 +1,61 @@
+package scenario
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// StepStatus is the outcome of a step
+type StepStatus string
+
+const (
+	StepPassed StepStatus = "passed"
+	StepFailed StepStatus = "failed"
+	// StepSkipped means the step did not run, or was cancelled
+	StepSkipped StepStatus = "skipped"
+)
+
+// StepReport describes the outcome of a step
+type StepReport struct {
+	Name     string        `json:"name"`
+	Action   string        `json:"action"`
+	Status   StepStatus    `json:"status"`
+	Attempts int           `json:"attempts"`
+	Output   string        `json:"output,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report describes the outcome of a scenario run
+type Report struct {
+	Scenario string       `json:"scenario"`
+	Address  string       `json:"address"`
+	Passed   bool         `json:"passed"`
+	Steps    []StepReport `json:"steps"`
+	// Outputs are the step outputs stored as variables
+	Outputs  map[string]string `json:"outputs,omitempty"`
+	Duration time.Duration     `json:"duration"`
+}
+
+// Print writes the report as a table of steps
+func (r *Report) Print(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tACTION\tSTATUS\tATTEMPTS\tDURATION\tRESULT")
+	for _, step := range r.Steps {
+		result := step.Output
+		if step.Error != "" {
+			result = step.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", step.Name, step.Action, step.Status, step.Attempts,
+			step.Duration.Round(time.Millisecond), result)
+	}
+	w.Flush()
+
+	status := "PASSED"
+	if !r.Passed {
+		status = "FAILED"
+	}
+	fmt.Fprintf(out, "%s %s for %s in %s\n", status, r.Scenario, r.Address, r.Duration.Round(time.Millisecond))
+}
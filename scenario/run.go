@@ -0,0 +1,421 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/galihrivanto/omonOmon/dapp"
+	"github.com/galihrivanto/omonOmon/faucet"
+	"github.com/galihrivanto/omonOmon/wallet"
+	"github.com/go-rod/rod"
+)
+
+// maxUint256 is the amount approved by approve steps with amount max
+var maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// Chain is what steps do on chain
+type Chain interface {
+	// Balance returns the balance of address in wei, or in the smallest
+	// unit of token when set
+	Balance(ctx context.Context, token string, address string) (*big.Int, error)
+	Decimals(ctx context.Context, token string) (int, error)
+	wallet.Backend
+}
+
+// rpcChain is the Chain of the Monad testnet
+type rpcChain struct {
+	wallet.RPCBackend
+}
+
+func (rpcChain) Balance(ctx context.Context, token string, address string) (*big.Int, error) {
+	if token == "" {
+		return wallet.BalanceAt(ctx, address)
+	}
+	return wallet.TokenBalance(ctx, token, address)
+}
+
+func (rpcChain) Decimals(ctx context.Context, token string) (int, error) {
+	return wallet.TokenDecimals(ctx, token)
+}
+
+// Runner runs scenarios
+type Runner struct {
+	// Chain defaults to the Monad testnet
+	Chain Chain
+	// Claim configures faucet steps, its Wallet is set to the scenario wallet
+	Claim faucet.ClaimOptions
+	// Browser configures the browser of browser steps
+	Browser faucet.BrowserOptions
+	// Approve decides the requests of dApps opened by browser steps, nil
+	// asks about all of them on the terminal
+	Approve wallet.ApproveFunc
+	// Vars override the scenario variables
+	Vars map[string]string
+	// MinStepDelay and MaxStepDelay bound the random delay before every
+	// step but the first
+	MinStepDelay time.Duration
+	MaxStepDelay time.Duration
+	// Out receives the progress, defaults to stdout
+	Out io.Writer
+}
+
+// run is the state of a single scenario run
+type run struct {
+	*Runner
+	scenario *Scenario
+	wallet   *wallet.Wallet
+	vars     map[string]string
+
+	browser *faucet.Browser
+	session *dapp.Session
+	page    *rod.Page
+}
+
+// Run runs the scenario with w. Steps run in order until one fails for
+// good, the steps after it are skipped.
+func (r *Runner) Run(ctx context.Context, s *Scenario, w *wallet.Wallet) *Report {
+	run := &run{Runner: r, scenario: s, wallet: w, vars: map[string]string{"address": w.Address}}
+	for name, value := range s.Vars {
+		run.vars[name] = value
+	}
+	for name, value := range r.Vars {
+		run.vars[name] = value
+	}
+	defer run.close()
+
+	report := &Report{Scenario: s.Name, Address: w.Address, Passed: true, Outputs: make(map[string]string)}
+	started := time.Now()
+
+	for i := range s.Steps {
+		step := &s.Steps[i]
+		if !report.Passed {
+			report.Steps = append(report.Steps, StepReport{Name: step.Name, Action: step.Action, Status: StepSkipped})
+			continue
+		}
+
+		if i > 0 && sleep(ctx, r.stepDelay()) != nil {
+			report.Steps = append(report.Steps, StepReport{Name: step.Name, Action: step.Action, Status: StepSkipped, Error: "cancelled"})
+			report.Passed = false
+			continue
+		}
+
+		result := run.step(ctx, step)
+		report.Steps = append(report.Steps, result)
+		if result.Status != StepPassed {
+			report.Passed = false
+			continue
+		}
+
+		if step.Output != "" {
+			run.vars[step.Output] = result.Output
+			report.Outputs[step.Output] = result.Output
+		}
+	}
+
+	report.Duration = time.Since(started)
+	return report
+}
+
+func (r *Runner) out() io.Writer {
+	if r.Out == nil {
+		return os.Stdout
+	}
+	return r.Out
+}
+
+// stepDelay returns a duration between MinStepDelay and MaxStepDelay
+func (r *Runner) stepDelay() time.Duration {
+	return randomDelay(r.MinStepDelay, r.MaxStepDelay)
+}
+
+func (r *Runner) chain() Chain {
+	if r.Chain == nil {
+		return rpcChain{}
+	}
+	return r.Chain
+}
+
+// step runs a step with its retries
+func (r *run) step(ctx context.Context, step *Step) StepReport {
+	result := StepReport{Name: step.Name, Action: step.Action}
+	started := time.Now()
+
+	var err error
+	for attempt := 0; attempt <= *step.Retries; attempt++ {
+		if attempt > 0 {
+			fmt.Fprintf(r.out(), "%s: retrying %s after: %v\n", r.wallet.Address, step.Name, err)
+			if sleep(ctx, r.scenario.RetryDelay) != nil {
+				break
+			}
+		}
+		result.Attempts++
+
+		fmt.Fprintf(r.out(), "%s: running %s...\n", r.wallet.Address, step.Name)
+		result.Output, err = r.attempt(ctx, step)
+
+		// an output with an error is the hash of a transaction that was
+		// sent, sending it again could pay twice
+		if err == nil || ctx.Err() != nil || result.Output != "" {
+			break
+		}
+	}
+
+	result.Duration = time.Since(started)
+	switch {
+	case err == nil:
+		result.Status = StepPassed
+	case ctx.Err() != nil:
+		result.Status = StepSkipped
+		result.Error = fmt.Sprintf("cancelled: %v", err)
+	default:
+		result.Status = StepFailed
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// attempt runs a single attempt of a step within its timeout, wait steps
+// last their duration whatever the timeout
+func (r *run) attempt(ctx context.Context, step *Step) (string, error) {
+	if step.Action == ActionWait {
+		return r.action(ctx, step)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, step.Timeout)
+	defer cancel()
+	return r.action(ctx, step)
+}
+
+// action runs a single attempt of a step, returning its output
+func (r *run) action(ctx context.Context, step *Step) (string, error) {
+	values, err := r.render(step)
+	if err != nil {
+		return "", err
+	}
+
+	switch step.Action {
+	case ActionFaucet:
+		return r.claim(ctx, values.Faucet)
+	case ActionSend:
+		amount, err := wallet.ParseMON(values.Amount)
+		if err != nil {
+			return "", err
+		}
+		if !common.IsHexAddress(values.To) {
+			return "", fmt.Errorf("invalid address %q", values.To)
+		}
+		return r.chain().Transact(ctx, r.wallet, values.To, amount, nil)
+	case ActionApprove:
+		return r.approve(ctx, values)
+	case ActionCall:
+		return r.call(ctx, values)
+	case ActionRead:
+		return r.read(ctx, values)
+	case ActionBrowser:
+		return r.browse(ctx, values)
+	case ActionWait:
+		return "", sleep(ctx, step.Duration)
+	case ActionBalance:
+		return r.balance(ctx, values)
+	}
+
+	return "", fmt.Errorf("unknown action %q", step.Action)
+}
+
+// render returns a copy of the step with its values rendered
+func (r *run) render(step *Step) (*Step, error) {
+	values := *step
+	fields := []*string{&values.Faucet, &values.To, &values.Amount, &values.Token, &values.Spender, &values.Value,
+		&values.Address, &values.Min, &values.Max, &values.URL, &values.Connect}
+
+	values.Args = append([]string(nil), step.Args...)
+	for i := range values.Args {
+		fields = append(fields, &values.Args[i])
+	}
+
+	for _, field := range fields {
+		text, err := render(*field, r.vars)
+		if err != nil {
+			return nil, err
+		}
+		*field = text
+	}
+	return &values, nil
+}
+
+func (r *run) claim(ctx context.Context, faucetName string) (string, error) {
+	opts := r.Claim
+	opts.Wallet = r.wallet
+
+	result, err := faucet.ClaimWithOptions(ctx, faucetName, r.wallet.Address, opts)
+	if err != nil {
+		return "", err
+	}
+	return result.Reason, nil
+}
+
+func (r *run) approve(ctx context.Context, step *Step) (string, error) {
+	amount := maxUint256
+	if step.Amount != "max" {
+		decimals, err := r.chain().Decimals(ctx, step.Token)
+		if err != nil {
+			return "", err
+		}
+		amount, err = wallet.ParseUnits(step.Amount, decimals)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	method, _ := parseMethod("approve(address,uint256)", nil)
+	if !common.IsHexAddress(step.Spender) {
+		return "", fmt.Errorf("invalid spender %q", step.Spender)
+	}
+	data, err := method.Inputs.Pack(common.HexToAddress(step.Spender), amount)
+	if err != nil {
+		return "", err
+	}
+	return r.chain().Transact(ctx, r.wallet, step.Token, nil, append(method.ID, data...))
+}
+
+func (r *run) call(ctx context.Context, step *Step) (string, error) {
+	method, err := parseMethod(step.Method, nil)
+	if err != nil {
+		return "", err
+	}
+	data, err := pack(method, step.Args)
+	if err != nil {
+		return "", err
+	}
+
+	value := new(big.Int)
+	if step.Value != "" {
+		if value, err = wallet.ParseMON(step.Value); err != nil {
+			return "", err
+		}
+	}
+	return r.chain().Transact(ctx, r.wallet, step.To, value, data)
+}
+
+func (r *run) read(ctx context.Context, step *Step) (string, error) {
+	method, err := parseMethod(step.Method, step.Returns)
+	if err != nil {
+		return "", err
+	}
+	data, err := pack(method, step.Args)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := r.chain().Call(ctx, step.To, data)
+	if err != nil {
+		return "", err
+	}
+
+	values, err := method.Outputs.Unpack(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode result: %v", err)
+	}
+	return format(values), nil
+}
+
+func (r *run) balance(ctx context.Context, step *Step) (string, error) {
+	address := step.Address
+	if address == "" {
+		address = r.wallet.Address
+	}
+
+	decimals := 18
+	if step.Token != "" {
+		var err error
+		if decimals, err = r.chain().Decimals(ctx, step.Token); err != nil {
+			return "", err
+		}
+	}
+
+	balance, err := r.chain().Balance(ctx, step.Token, address)
+	if err != nil {
+		return "", err
+	}
+	formatted := wallet.FormatUnits(balance, decimals)
+
+	if step.Min != "" {
+		min, err := wallet.ParseUnits(step.Min, decimals)
+		if err != nil {
+			return "", err
+		}
+		if balance.Cmp(min) < 0 {
+			return "", fmt.Errorf("balance %s is below %s", formatted, step.Min)
+		}
+	}
+	if step.Max != "" {
+		max, err := wallet.ParseUnits(step.Max, decimals)
+		if err != nil {
+			return "", err
+		}
+		if balance.Cmp(max) > 0 {
+			return "", fmt.Errorf("balance %s is above %s", formatted, step.Max)
+		}
+	}
+
+	return formatted, nil
+}
+
+// browse runs a browser step, the browser is launched by the first one
+// and kept until the scenario ends
+func (r *run) browse(ctx context.Context, step *Step) (string, error) {
+	if r.session == nil {
+		browser, err := r.Browser.Launch(context.Background())
+		if err != nil {
+			return "", err
+		}
+		r.browser = browser
+		r.session = dapp.NewSession(browser.Browser, wallet.NewProvider(r.wallet, r.Approve))
+	}
+
+	if step.URL != "" {
+		// the page outlives the step, the wallet keeps serving it
+		page, err := r.session.Open(context.Background(), step.URL)
+		if err != nil {
+			return "", err
+		}
+		r.page = page
+	}
+	if r.page == nil {
+		return "", errors.New("no page open")
+	}
+	page := r.page.Context(ctx)
+
+	if step.Connect != "" {
+		if err := r.session.Connect(ctx, page, step.Connect); err != nil {
+			return "", err
+		}
+	}
+
+	var output string
+	for _, action := range step.Browser {
+		text, err := action.run(page, r.vars)
+		if err != nil {
+			return "", fmt.Errorf("%s %s: %v", action.Action, action.Selector, err)
+		}
+		if action.Action == "read" {
+			output = text
+		}
+	}
+	return output, nil
+}
+
+func (r *run) close() {
+	if r.session != nil {
+		r.session.Close()
+	}
+	if r.browser != nil {
+		r.browser.Close()
+	}
+}
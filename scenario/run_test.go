@@ -0,0 +1,250 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/galihrivanto/omonOmon/faucet"
+	"github.com/galihrivanto/omonOmon/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testAddress   = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	testRecipient = "0x8ba1f109551bD432803012645Ac136ddd64DBA72"
+	testToken     = "0x760AfE86e5de5fa0Ee542fc7B7B713e1c5425701"
+)
+
+var testWallet = &wallet.Wallet{Address: testAddress, PrivateKey: "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"}
+
+// transaction is a transaction sent through fakeChain
+type transaction struct {
+	to    string
+	value *big.Int
+	data  string
+}
+
+type fakeChain struct {
+	mu       sync.Mutex
+	balances map[string]*big.Int
+	sent     []transaction
+	// failures makes the first transactions fail
+	failures int
+	// reverts makes the first transactions fail once sent
+	reverts int
+}
+
+func (c *fakeChain) Balance(ctx context.Context, token string, address string) (*big.Int, error) {
+	if balance, ok := c.balances[token+address]; ok {
+		return balance, nil
+	}
+	return new(big.Int), nil
+}
+
+func (c *fakeChain) Decimals(ctx context.Context, token string) (int, error) {
+	return 6, nil
+}
+
+func (c *fakeChain) Call(ctx context.Context, to string, data []byte) ([]byte, error) {
+	// every read returns 1000
+	return common.LeftPadBytes(big.NewInt(1000).Bytes(), 32), nil
+}
+
+func (c *fakeChain) Transact(ctx context.Context, w *wallet.Wallet, to string, value *big.Int, data []byte) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.failures > 0 {
+		c.failures--
+		return "", errors.New("nonce too low")
+	}
+	c.sent = append(c.sent, transaction{to: to, value: value, data: hexutil.Encode(data)})
+	hash := common.BigToHash(big.NewInt(int64(len(c.sent)))).Hex()
+	if c.reverts > 0 {
+		c.reverts--
+		return hash, errors.New("transaction reverted")
+	}
+	return hash, nil
+}
+
+type testClaimer struct{}
+
+func (testClaimer) Claim(ctx context.Context, address string) *faucet.Result {
+	return &faucet.Result{Status: faucet.StatusClaimed, Reason: "sent 1 MON"}
+}
+
+func init() {
+	faucet.Register("scenario-test", func() faucet.FaucetClaimer { return testClaimer{} })
+}
+
+func testRunner(chain Chain) *Runner {
+	return &Runner{Chain: chain, Out: io.Discard}
+}
+
+func TestRun(t *testing.T) {
+	scenario := &Scenario{
+		Name: "daily",
+		Vars: map[string]string{"recipient": testRecipient},
+		Steps: []Step{
+			{Action: ActionFaucet, Faucet: "scenario-test", Output: "claim"},
+			{Action: ActionSend, To: "{{.recipient}}", Amount: "0.5", Output: "payment"},
+			{Action: ActionApprove, Token: testToken, Spender: "{{.recipient}}", Amount: "1.5"},
+			{Action: ActionRead, To: testToken, Method: "balanceOf(address)", Args: []string{"{{.address}}"}, Returns: []string{"uint256"}, Output: "held"},
+			{Action: ActionCall, To: testToken, Method: "transfer(address,uint256)", Args: []string{"{{.recipient}}", "{{.held}}"}},
+			{Action: ActionBalance, Token: testToken, Min: "1"},
+		},
+	}
+	assert.NoError(t, scenario.compile())
+
+	chain := &fakeChain{balances: map[string]*big.Int{testToken + testAddress: big.NewInt(2000000)}}
+	report := testRunner(chain).Run(context.Background(), scenario, testWallet)
+
+	assert.True(t, report.Passed)
+	assert.Len(t, report.Steps, 6)
+	for _, step := range report.Steps {
+		assert.Equal(t, StepPassed, step.Status, step.Name)
+	}
+	assert.Equal(t, "sent 1 MON", report.Outputs["claim"])
+	assert.Equal(t, common.BigToHash(big.NewInt(1)).Hex(), report.Outputs["payment"])
+	assert.Equal(t, "1000", report.Outputs["held"])
+	assert.Equal(t, "2", report.Steps[5].Output)
+
+	assert.Len(t, chain.sent, 3)
+	assert.Equal(t, transaction{to: testRecipient, value: big.NewInt(5e17), data: "0x"}, chain.sent[0])
+	// approve(recipient, 1.5 with 6 decimals)
+	assert.Equal(t, testToken, chain.sent[1].to)
+	assert.Equal(t, "0x095ea7b3"+"0000000000000000000000008ba1f109551bd432803012645ac136ddd64dba72"+
+		"000000000000000000000000000000000000000000000000000000000016e360", chain.sent[1].data)
+	// transfer(recipient, 1000) with the value read before
+	assert.Equal(t, "0xa9059cbb"+"0000000000000000000000008ba1f109551bd432803012645ac136ddd64dba72"+
+		"00000000000000000000000000000000000000000000000000000000000003e8", chain.sent[2].data)
+}
+
+func TestRunRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		retries  int
+		failures int
+		status   StepStatus
+		attempts int
+	}{
+		{"first attempt", 2, 0, StepPassed, 1},
+		{"passes on retry", 2, 2, StepPassed, 3},
+		{"out of retries", 1, 2, StepFailed, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scenario := &Scenario{
+				Retries:    test.retries,
+				RetryDelay: time.Millisecond,
+				Steps: []Step{
+					{Action: ActionSend, To: testRecipient, Amount: "1"},
+					{Action: ActionWait, Duration: time.Millisecond},
+				},
+			}
+			assert.NoError(t, scenario.compile())
+			scenario.RetryDelay = time.Millisecond
+
+			report := testRunner(&fakeChain{failures: test.failures}).Run(context.Background(), scenario, testWallet)
+			assert.Equal(t, test.status, report.Steps[0].Status)
+			assert.Equal(t, test.attempts, report.Steps[0].Attempts)
+			assert.Equal(t, test.status == StepPassed, report.Passed)
+
+			if test.status == StepFailed {
+				assert.Equal(t, "nonce too low", report.Steps[0].Error)
+				assert.Equal(t, StepSkipped, report.Steps[1].Status)
+				assert.Zero(t, report.Steps[1].Attempts)
+			}
+		})
+	}
+}
+
+func TestRunSentTransactionNotRetried(t *testing.T) {
+	scenario := &Scenario{
+		Retries: 2,
+		Steps:   []Step{{Action: ActionSend, To: testRecipient, Amount: "1"}},
+	}
+	assert.NoError(t, scenario.compile())
+	scenario.RetryDelay = time.Millisecond
+
+	chain := &fakeChain{reverts: 1}
+	report := testRunner(chain).Run(context.Background(), scenario, testWallet)
+
+	assert.False(t, report.Passed)
+	assert.Equal(t, StepFailed, report.Steps[0].Status)
+	assert.Equal(t, 1, report.Steps[0].Attempts)
+	assert.Equal(t, common.BigToHash(big.NewInt(1)).Hex(), report.Steps[0].Output)
+	assert.Len(t, chain.sent, 1)
+}
+
+func TestRunBalanceAssertion(t *testing.T) {
+	tests := []struct {
+		name string
+		min  string
+		max  string
+		err  string
+	}{
+		{"within", "1", "3", ""},
+		{"below", "2.5", "", "balance 2 is below 2.5"},
+		{"above", "", "1", "balance 2 is above 1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scenario := &Scenario{Steps: []Step{{Action: ActionBalance, Min: test.min, Max: test.max}}}
+			assert.NoError(t, scenario.compile())
+
+			chain := &fakeChain{balances: map[string]*big.Int{testAddress: big.NewInt(2e18)}}
+			report := testRunner(chain).Run(context.Background(), scenario, testWallet)
+			assert.Equal(t, test.err, report.Steps[0].Error)
+			assert.Equal(t, test.err == "", report.Passed)
+		})
+	}
+}
+
+func TestRunVars(t *testing.T) {
+	scenario := &Scenario{
+		Vars:  map[string]string{"recipient": "0x0000000000000000000000000000000000000001"},
+		Steps: []Step{{Action: ActionSend, To: "{{.recipient}}", Amount: "1"}, {Action: ActionSend, To: "{{.missing}}", Amount: "1"}},
+	}
+	assert.NoError(t, scenario.compile())
+
+	chain := &fakeChain{}
+	runner := testRunner(chain)
+	runner.Vars = map[string]string{"recipient": testRecipient}
+	report := runner.Run(context.Background(), scenario, testWallet)
+
+	assert.Equal(t, testRecipient, chain.sent[0].to)
+	assert.False(t, report.Passed)
+	assert.Contains(t, report.Steps[1].Error, `map has no entry for key "missing"`)
+}
+
+func TestRunWaitLongerThanTimeout(t *testing.T) {
+	scenario := &Scenario{Steps: []Step{{Action: ActionWait, Duration: 30 * time.Millisecond, Timeout: 10 * time.Millisecond}}}
+	assert.NoError(t, scenario.compile())
+
+	report := testRunner(&fakeChain{}).Run(context.Background(), scenario, testWallet)
+	assert.True(t, report.Passed)
+	assert.Equal(t, StepPassed, report.Steps[0].Status)
+}
+
+func TestRunCancelled(t *testing.T) {
+	scenario := &Scenario{Steps: []Step{{Action: ActionWait, Duration: time.Minute}, {Action: ActionWait, Duration: time.Minute}}}
+	assert.NoError(t, scenario.compile())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	report := testRunner(&fakeChain{}).Run(ctx, scenario, testWallet)
+	assert.False(t, report.Passed)
+	assert.Equal(t, StepSkipped, report.Steps[0].Status)
+	assert.Equal(t, StepSkipped, report.Steps[1].Status)
+}
@@ -0,0 +1,242 @@
+package scenario
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultStepTimeout = 5 * time.Minute
+	defaultRetryDelay  = 5 * time.Second
+)
+
+// step actions
+const (
+	ActionFaucet  = "faucet"
+	ActionSend    = "send"
+	ActionApprove = "approve"
+	ActionCall    = "call"
+	ActionRead    = "read"
+	ActionBrowser = "browser"
+	ActionWait    = "wait"
+	ActionBalance = "balance"
+)
+
+// Scenario is a sequence of steps run with one wallet. Scenarios are YAML
+// or JSON files.
+type Scenario struct {
+	Name string `yaml:"name"`
+	// Vars are the initial variables, {{.address}} is the wallet address
+	Vars map[string]string `yaml:"vars"`
+	// Retries is how often a failed step is retried, unless the step says
+	Retries int `yaml:"retries"`
+	// RetryDelay is the delay between attempts of a step, defaults to 5s
+	RetryDelay time.Duration `yaml:"retryDelay"`
+	Steps      []Step        `yaml:"steps"`
+}
+
+// Step is a single action of a scenario. Its string values are Go
+// templates receiving the variables, e.g. {{.address}} or the output of
+// an earlier step as {{.name}}. Amounts are decimal, in MON or in units
+// of the token.
+//
+//	faucet:  claim Faucet for the wallet
+//	send:    send Amount MON to To
+//	approve: let Spender spend Amount, or max, of Token
+//	call:    send a transaction calling Method with Args on To, paying Value MON
+//	read:    call Method with Args on To without a transaction, Returns
+//	         listing the types returned
+//	browser: open URL with the wallet injected, or keep using the page
+//	         of the previous browser step, click Connect and run Browser
+//	wait:    wait for Duration
+//	balance: check the MON or Token balance of Address, the wallet by
+//	         default, is at least Min and at most Max
+//
+// Transactions are waited for until mined, a step whose transaction was
+// sent is not retried. The output of a step is the
+// transaction hash, the values read, the balance, the faucet message or
+// the text of the last browser read action.
+type Step struct {
+	Name   string `yaml:"name"`
+	Action string `yaml:"action"`
+	// Output is the variable the step output is stored in
+	Output string `yaml:"output"`
+	// Retries overrides the scenario retries, 0 turns them off
+	Retries *int `yaml:"retries"`
+	// Timeout bounds every attempt of the step, defaults to 5m. Wait
+	// steps are not bounded, they last their Duration.
+	Timeout time.Duration `yaml:"timeout"`
+
+	Faucet   string          `yaml:"faucet"`
+	To       string          `yaml:"to"`
+	Amount   string          `yaml:"amount"`
+	Token    string          `yaml:"token"`
+	Spender  string          `yaml:"spender"`
+	Method   string          `yaml:"method"`
+	Args     []string        `yaml:"args"`
+	Returns  []string        `yaml:"returns"`
+	Value    string          `yaml:"value"`
+	Address  string          `yaml:"address"`
+	Min      string          `yaml:"min"`
+	Max      string          `yaml:"max"`
+	Duration time.Duration   `yaml:"duration"`
+	URL      string          `yaml:"url"`
+	Connect  string          `yaml:"connect"`
+	Browser  []BrowserAction `yaml:"browser"`
+}
+
+// Load loads and validates a scenario file
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var scenario Scenario
+	if err := yaml.Unmarshal(data, &scenario); err != nil {
+		return nil, fmt.Errorf("%s: invalid scenario: %v", path, err)
+	}
+
+	if scenario.Name == "" {
+		scenario.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	if err := scenario.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return &scenario, nil
+}
+
+// compile validates the scenario and fills in the defaults
+func (s *Scenario) compile() error {
+	if len(s.Steps) == 0 {
+		return errors.New("no steps")
+	}
+	if s.RetryDelay <= 0 {
+		s.RetryDelay = defaultRetryDelay
+	}
+
+	browsing := false
+	for i := range s.Steps {
+		step := &s.Steps[i]
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step %d (%s)", i+1, step.Action)
+		}
+		if step.Timeout <= 0 {
+			step.Timeout = defaultStepTimeout
+		}
+		if step.Retries == nil {
+			retries := s.Retries
+			step.Retries = &retries
+		}
+
+		if err := step.compile(browsing); err != nil {
+			return fmt.Errorf("%s: %v", step.Name, err)
+		}
+		browsing = browsing || step.Action == ActionBrowser
+	}
+
+	return nil
+}
+
+// compile validates the step, browsing tells whether a page was opened
+// by an earlier step
+func (s *Step) compile(browsing bool) error {
+	// require takes field name and value pairs
+	require := func(fields ...string) error {
+		for i := 0; i < len(fields); i += 2 {
+			if fields[i+1] == "" {
+				return fmt.Errorf("%s is required", fields[i])
+			}
+		}
+		return nil
+	}
+
+	var err error
+	switch s.Action {
+	case ActionFaucet:
+		err = require("faucet", s.Faucet)
+	case ActionSend:
+		err = require("to", s.To, "amount", s.Amount)
+	case ActionApprove:
+		err = require("token", s.Token, "spender", s.Spender, "amount", s.Amount)
+	case ActionCall, ActionRead:
+		if err = require("to", s.To, "method", s.Method); err == nil {
+			_, err = parseMethod(s.Method, s.Returns)
+		}
+	case ActionBrowser:
+		if s.URL == "" && !browsing {
+			return errors.New("url is required by the first browser step")
+		}
+		for i := range s.Browser {
+			if err := s.Browser[i].compile(); err != nil {
+				return fmt.Errorf("browser action %d: %v", i+1, err)
+			}
+		}
+	case ActionWait:
+		if s.Duration <= 0 {
+			return errors.New("duration is required")
+		}
+	case ActionBalance:
+		if s.Min == "" && s.Max == "" {
+			return errors.New("min or max is required")
+		}
+	default:
+		return fmt.Errorf("unknown action %q", s.Action)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, text := range s.templates() {
+		if _, err := parseTemplate(text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// templates returns the templated values of the step
+func (s *Step) templates() []string {
+	texts := []string{s.Faucet, s.To, s.Amount, s.Token, s.Spender, s.Value, s.Address, s.Min, s.Max, s.URL, s.Connect}
+	texts = append(texts, s.Args...)
+	for _, action := range s.Browser {
+		texts = append(texts, action.Selector, action.Value)
+	}
+	return texts
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %q: %v", text, err)
+	}
+	return tmpl, nil
+}
+
+// render executes a step value with the variables
+func render(text string, vars map[string]string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, vars); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,83 @@
+package scenario
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daily.yaml")
+	err := os.WriteFile(path, []byte(`
+vars:
+  recipient: "0x8ba1f109551bD432803012645Ac136ddd64DBA72"
+retries: 2
+steps:
+  - action: faucet
+    faucet: default
+  - name: pay
+    action: send
+    to: "{{.recipient}}"
+    amount: "0.01"
+    retries: 1
+    output: payment
+  - action: browser
+    retries: 0
+    url: https://app.example
+    connect: "#connect"
+    browser:
+      - action: click
+        selector: "#swap"
+`), 0644)
+	assert.NoError(t, err)
+
+	scenario, err := Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "daily", scenario.Name)
+	assert.Equal(t, defaultRetryDelay, scenario.RetryDelay)
+	assert.Len(t, scenario.Steps, 3)
+
+	assert.Equal(t, "step 1 (faucet)", scenario.Steps[0].Name)
+	assert.Equal(t, 2, *scenario.Steps[0].Retries)
+	assert.Equal(t, defaultStepTimeout, scenario.Steps[0].Timeout)
+	assert.Equal(t, "pay", scenario.Steps[1].Name)
+	assert.Equal(t, 1, *scenario.Steps[1].Retries)
+	assert.Equal(t, 0, *scenario.Steps[2].Retries)
+	assert.Equal(t, 30*time.Second, scenario.Steps[2].Browser[0].Timeout)
+}
+
+func TestCompileInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []Step
+		err   string
+	}{
+		{"no steps", nil, "no steps"},
+		{"unknown action", []Step{{Action: "dance"}}, `step 1 (dance): unknown action "dance"`},
+		{"missing amount", []Step{{Action: ActionSend, To: "0x1"}}, "step 1 (send): amount is required"},
+		{"invalid method", []Step{{Action: ActionCall, To: "0x1", Method: "transfer"}}, `step 1 (call): invalid method "transfer", expected e.g. transfer(address,uint256)`},
+		{"browser without url", []Step{{Action: ActionBrowser}}, "step 1 (browser): url is required by the first browser step"},
+		{"invalid browser action", []Step{{Action: ActionBrowser, URL: "https://app.example", Browser: []BrowserAction{{Action: "click"}}}}, "step 1 (browser): browser action 1: selector is required"},
+		{"wait without duration", []Step{{Action: ActionWait}}, "step 1 (wait): duration is required"},
+		{"balance without bounds", []Step{{Action: ActionBalance}}, "step 1 (balance): min or max is required"},
+		{"invalid template", []Step{{Action: ActionFaucet, Faucet: "{{.name"}}, `step 1 (faucet): invalid template "{{.name"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := (&Scenario{Steps: test.steps}).compile()
+			assert.ErrorContains(t, err, test.err)
+		})
+	}
+}
+
+func TestCompileLaterBrowserStep(t *testing.T) {
+	scenario := &Scenario{Steps: []Step{
+		{Action: ActionBrowser, URL: "https://app.example"},
+		{Action: ActionBrowser, Connect: "#connect"},
+	}}
+	assert.NoError(t, scenario.compile())
+}
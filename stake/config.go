@@ -0,0 +1,226 @@
+package stake
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultNetwork is the network the wallet is on
+const DefaultNetwork = "monad-testnet"
+
+// Argument names standing for the values of an operation, any other
+// argument is a literal of the type of its parameter
+const (
+	// ArgAmount is the MON deposited, in wei
+	ArgAmount = "amount"
+	// ArgShares is the shares withdrawn, or 1e18 shares for the rate
+	ArgShares = "shares"
+	// ArgAddress is the wallet address
+	ArgAddress = "address"
+	// ArgID and ArgIDs are the withdrawal request ids claimed
+	ArgID  = "id"
+	ArgIDs = "ids"
+)
+
+// Config describes the liquid staking protocols of every network, for
+// example:
+//
+//	networks:
+//	  monad-testnet:
+//	    protocols:
+//	      apriori:
+//	        address: "0x..."
+//	        abi: aprmon.json
+//	        symbol: aprMON
+//	        deposit: {method: deposit, args: [amount, address]}
+//	        balance: {method: balanceOf, args: [address]}
+//	        rate: {method: convertToAssets, args: [shares]}
+//	        withdraw: {method: requestRedeem, args: [shares, address, address]}
+//	        requests: {method: getUserRequests, args: [address]}
+//	        claim: {method: redeem, args: [ids, address]}
+type Config struct {
+	Networks map[string]*Network `yaml:"networks"`
+}
+
+// Network holds the staking protocols of a network
+type Network struct {
+	Protocols map[string]*Protocol `yaml:"protocols"`
+}
+
+// Protocol describes a liquid staking contract and the methods of each
+// operation
+type Protocol struct {
+	Address string `yaml:"address"`
+	// ABI is the JSON ABI of the contract, or the path of a file holding
+	// it, relative to the config
+	ABI string `yaml:"abi"`
+	// Symbol is the symbol of the shares, defaults to shares
+	Symbol string `yaml:"symbol"`
+
+	// Deposit stakes the MON sent along, the method must be payable
+	Deposit *Operation `yaml:"deposit"`
+	// Balance returns the shares held
+	Balance *Operation `yaml:"balance"`
+	// Rate returns the MON, in wei, of 1e18 shares
+	Rate *Operation `yaml:"rate"`
+	// Withdraw requests the withdrawal of shares
+	Withdraw *Operation `yaml:"withdraw"`
+	// Requests returns the pending withdrawal requests of an address,
+	// either as request ids or as a list of tuples
+	Requests *Operation `yaml:"requests"`
+	// Request returns the details of a request id, when Requests returns
+	// ids
+	Request *Operation `yaml:"request"`
+	// Claim completes withdrawal requests, one id or many ids at a time
+	Claim *Operation `yaml:"claim"`
+
+	abi abi.ABI
+}
+
+// Operation is a method of the contract and its arguments
+type Operation struct {
+	Method string   `yaml:"method"`
+	Args   []string `yaml:"args"`
+
+	method abi.Method
+}
+
+// LoadConfig reads a YAML or JSON configuration file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: invalid config: %v", path, err)
+	}
+
+	for networkName, network := range config.Networks {
+		for name, protocol := range network.Protocols {
+			if err := protocol.compile(filepath.Dir(path)); err != nil {
+				return nil, fmt.Errorf("%s: network %s: protocol %s: %v", path, networkName, name, err)
+			}
+		}
+	}
+
+	return &config, nil
+}
+
+// Network returns the network called name
+func (c *Config) Network(name string) (*Network, error) {
+	network, ok := c.Networks[name]
+	if !ok {
+		return nil, fmt.Errorf("network %s is not configured", name)
+	}
+	return network, nil
+}
+
+// Protocol returns the protocol called name, which may be left out when
+// the network has a single one
+func (n *Network) Protocol(name string) (*Protocol, error) {
+	if name == "" {
+		if len(n.Protocols) == 1 {
+			for _, protocol := range n.Protocols {
+				return protocol, nil
+			}
+		}
+		if len(n.Protocols) == 0 {
+			return nil, errors.New("no staking protocol is configured")
+		}
+
+		names := make([]string, 0, len(n.Protocols))
+		for name := range n.Protocols {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return nil, fmt.Errorf("several staking protocols are configured, choose one of %s", strings.Join(names, ", "))
+	}
+
+	protocol, ok := n.Protocols[name]
+	if !ok {
+		return nil, fmt.Errorf("staking protocol %s is not configured", name)
+	}
+	return protocol, nil
+}
+
+// compile parses the ABI, read relative to dir, and checks the
+// operations against it
+func (p *Protocol) compile(dir string) error {
+	if !common.IsHexAddress(p.Address) {
+		return fmt.Errorf("invalid address %q", p.Address)
+	}
+	if p.Symbol == "" {
+		p.Symbol = "shares"
+	}
+
+	definition := strings.TrimSpace(p.ABI)
+	if definition == "" {
+		return errors.New("abi is required")
+	}
+	if !strings.HasPrefix(definition, "[") {
+		path := definition
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read abi: %v", err)
+		}
+		definition = string(data)
+	}
+
+	var err error
+	if p.abi, err = abi.JSON(strings.NewReader(definition)); err != nil {
+		return fmt.Errorf("invalid abi: %v", err)
+	}
+
+	if p.Deposit == nil || p.Balance == nil {
+		return errors.New("deposit and balance are required")
+	}
+	for _, op := range []struct {
+		name      string
+		operation *Operation
+	}{
+		{"deposit", p.Deposit},
+		{"balance", p.Balance},
+		{"rate", p.Rate},
+		{"withdraw", p.Withdraw},
+		{"requests", p.Requests},
+		{"request", p.Request},
+		{"claim", p.Claim},
+	} {
+		if op.operation == nil {
+			continue
+		}
+		if err := op.operation.compile(p.abi); err != nil {
+			return fmt.Errorf("%s: %v", op.name, err)
+		}
+	}
+	if !p.Deposit.method.IsPayable() {
+		return fmt.Errorf("deposit: %s is not payable, MON can not be sent along", p.Deposit.method.Sig)
+	}
+
+	return nil
+}
+
+func (o *Operation) compile(contractABI abi.ABI) error {
+	method, ok := contractABI.Methods[o.Method]
+	if !ok {
+		return fmt.Errorf("method %q is not in the abi", o.Method)
+	}
+	if len(o.Args) != len(method.Inputs) {
+		return fmt.Errorf("%s takes %d arguments, got %d", method.Sig, len(method.Inputs), len(o.Args))
+	}
+	o.method = method
+	return nil
+}
@@ -0,0 +1,99 @@
+package stake
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol string
+		err      string
+	}{
+		{
+			name: "inline abi",
+			protocol: `
+        abi: '[{"name": "balanceOf", "type": "function", "inputs": [{"name": "owner", "type": "address"}], "outputs": [{"name": "", "type": "uint256"}]}, {"name": "deposit", "type": "function", "stateMutability": "payable", "inputs": [], "outputs": []}]'
+        deposit: {method: deposit}
+        balance: {method: balanceOf, args: [address]}`,
+		},
+		{
+			name: "unknown method",
+			protocol: `
+        abi: staking.json
+        deposit: {method: stake, args: [amount]}
+        balance: {method: balanceOf, args: [address]}`,
+			err: `protocol apriori: deposit: method "stake" is not in the abi`,
+		},
+		{
+			name: "argument count",
+			protocol: `
+        abi: staking.json
+        deposit: {method: deposit, args: [amount]}
+        balance: {method: balanceOf, args: [address]}`,
+			err: "protocol apriori: deposit: deposit(uint256,address) takes 2 arguments, got 1",
+		},
+		{
+			name: "non-payable deposit",
+			protocol: `
+        abi: '[{"name": "balanceOf", "type": "function", "inputs": [{"name": "owner", "type": "address"}], "outputs": [{"name": "", "type": "uint256"}]}, {"name": "deposit", "type": "function", "stateMutability": "nonpayable", "inputs": [{"name": "assets", "type": "uint256"}], "outputs": []}]'
+        deposit: {method: deposit, args: [amount]}
+        balance: {method: balanceOf, args: [address]}`,
+			err: "protocol apriori: deposit: deposit(uint256) is not payable, MON can not be sent along",
+		},
+		{
+			name: "missing balance",
+			protocol: `
+        abi: staking.json
+        deposit: {method: deposit, args: [amount, address]}`,
+			err: "protocol apriori: deposit and balance are required",
+		},
+		{
+			name: "missing abi file",
+			protocol: `
+        abi: missing.json`,
+			err: "protocol apriori: failed to read abi",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "staking.json", testABI)
+			path := writeFile(t, dir, "stake.yaml", `
+networks:
+  monad-testnet:
+    protocols:
+      apriori:
+        address: "`+testAddress+`"`+test.protocol+"\n")
+
+			config, err := LoadConfig(path)
+			if test.err != "" {
+				assert.ErrorContains(t, err, path+": network monad-testnet: "+test.err)
+				return
+			}
+			assert.NoError(t, err)
+
+			network, err := config.Network(DefaultNetwork)
+			assert.NoError(t, err)
+			protocol, err := network.Protocol("")
+			assert.NoError(t, err)
+			assert.Equal(t, "shares", protocol.Symbol)
+		})
+	}
+}
+
+func TestNetworkProtocol(t *testing.T) {
+	network := &Network{Protocols: map[string]*Protocol{"apriori": {}, "kintsu": {}}}
+
+	_, err := network.Protocol("")
+	assert.EqualError(t, err, "several staking protocols are configured, choose one of apriori, kintsu")
+
+	_, err = network.Protocol("magma")
+	assert.EqualError(t, err, "staking protocol magma is not configured")
+
+	_, err = (&Network{}).Protocol("")
+	assert.EqualError(t, err, "no staking protocol is configured")
+}
@@ -0,0 +1,357 @@
+package stake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/galihrivanto/omonOmon/wallet"
+)
+
+// oneShare is 1e18, the shares the rate is given for
+var oneShare = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+// Staker stakes MON with a liquid staking protocol
+type Staker struct {
+	Protocol *Protocol
+	// Backend defaults to the Monad testnet
+	Backend wallet.Backend
+}
+
+// NewStaker creates a Staker of protocol
+func NewStaker(protocol *Protocol) *Staker {
+	return &Staker{Protocol: protocol, Backend: wallet.RPCBackend{}}
+}
+
+// Request is a pending withdrawal request
+type Request struct {
+	// ID is nil when the protocol lists requests without their ids
+	ID *big.Int
+	// Fields are the details of the request, in the order of the ABI
+	Fields []Field
+}
+
+// Field is a named value of a request
+type Field struct {
+	Name  string
+	Value string
+}
+
+// values are the values the argument names stand for
+type values struct {
+	amount  *big.Int
+	shares  *big.Int
+	address string
+	id      *big.Int
+	ids     []*big.Int
+}
+
+// Deposit stakes amount of MON, in wei, sending it along
+func (s *Staker) Deposit(ctx context.Context, w *wallet.Wallet, amount *big.Int) (string, error) {
+	return s.transact(ctx, w, s.Protocol.Deposit, amount, values{amount: amount, address: w.Address})
+}
+
+// Shares returns the shares held by owner
+func (s *Staker) Shares(ctx context.Context, owner string) (*big.Int, error) {
+	return s.callUint(ctx, s.Protocol.Balance, values{address: owner})
+}
+
+// Rate returns the MON, in wei, 1e18 shares are worth
+func (s *Staker) Rate(ctx context.Context) (*big.Int, error) {
+	if s.Protocol.Rate == nil {
+		return nil, unsupported("rate")
+	}
+	return s.callUint(ctx, s.Protocol.Rate, values{shares: oneShare})
+}
+
+// Value returns the MON, in wei, shares are worth at rate
+func Value(shares *big.Int, rate *big.Int) *big.Int {
+	value := new(big.Int).Mul(shares, rate)
+	return value.Quo(value, oneShare)
+}
+
+// RequestWithdraw requests the withdrawal of shares
+func (s *Staker) RequestWithdraw(ctx context.Context, w *wallet.Wallet, shares *big.Int) (string, error) {
+	if s.Protocol.Withdraw == nil {
+		return "", unsupported("withdraw")
+	}
+	return s.transact(ctx, w, s.Protocol.Withdraw, nil, values{shares: shares, address: w.Address})
+}
+
+// Claim completes the withdrawal requests ids, in one transaction when
+// the claim method takes them all, else in one transaction per id. A
+// claim method taking no id needs no ids.
+func (s *Staker) Claim(ctx context.Context, w *wallet.Wallet, ids []*big.Int) ([]string, error) {
+	op := s.Protocol.Claim
+	if op == nil {
+		return nil, unsupported("claim")
+	}
+
+	if !slices.Contains(op.Args, ArgID) {
+		hash, err := s.transact(ctx, w, op, nil, values{address: w.Address, ids: ids})
+		return []string{hash}, err
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("request ids are needed to claim")
+	}
+
+	var hashes []string
+	for _, id := range ids {
+		hash, err := s.transact(ctx, w, op, nil, values{address: w.Address, id: id})
+		if err != nil {
+			return hashes, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
+
+// Requests returns the pending withdrawal requests of owner
+func (s *Staker) Requests(ctx context.Context, owner string) ([]Request, error) {
+	op := s.Protocol.Requests
+	if op == nil {
+		return nil, unsupported("requests")
+	}
+
+	results, err := s.call(ctx, op, values{address: owner})
+	if err != nil {
+		return nil, err
+	}
+
+	list := reflect.ValueOf(results[0])
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%s returns %s, expected a list of ids or of tuples", op.Method, op.method.Outputs[0].Type)
+	}
+
+	var requests []Request
+	for i := 0; i < list.Len(); i++ {
+		item := list.Index(i)
+		if item.Kind() == reflect.Struct {
+			request := Request{Fields: structFields(item)}
+			for _, field := range request.Fields {
+				if name := strings.ToLower(field.Name); name == "id" || name == "requestid" {
+					request.ID, _ = new(big.Int).SetString(field.Value, 10)
+				}
+			}
+			requests = append(requests, request)
+			continue
+		}
+
+		id, ok := toBig(item.Interface())
+		if !ok {
+			return nil, fmt.Errorf("%s returns %s, expected a list of ids or of tuples", op.Method, op.method.Outputs[0].Type)
+		}
+		request := Request{ID: id}
+		if s.Protocol.Request != nil {
+			details, err := s.call(ctx, s.Protocol.Request, values{address: owner, id: id})
+			if err != nil {
+				return nil, err
+			}
+			request.Fields = outputFields(s.Protocol.Request.method.Outputs, details)
+		}
+		requests = append(requests, request)
+	}
+	return requests, nil
+}
+
+func unsupported(operation string) error {
+	return fmt.Errorf("the staking protocol has no %s configured", operation)
+}
+
+func (s *Staker) call(ctx context.Context, op *Operation, v values) ([]interface{}, error) {
+	data, err := pack(op, v)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.Backend.Call(ctx, s.Protocol.Address, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %v", op.Method, err)
+	}
+
+	results, err := op.method.Outputs.Unpack(result)
+	if err != nil || len(results) == 0 {
+		return nil, fmt.Errorf("failed to call %s: unexpected result %x", op.Method, result)
+	}
+	return results, nil
+}
+
+func (s *Staker) callUint(ctx context.Context, op *Operation, v values) (*big.Int, error) {
+	results, err := s.call(ctx, op, v)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := toBig(results[0])
+	if !ok {
+		return nil, fmt.Errorf("%s returns %s, expected an integer", op.Method, op.method.Outputs[0].Type)
+	}
+	return value, nil
+}
+
+func (s *Staker) transact(ctx context.Context, w *wallet.Wallet, op *Operation, value *big.Int, v values) (string, error) {
+	data, err := pack(op, v)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := s.Backend.Transact(ctx, w, s.Protocol.Address, value, data)
+	if err != nil {
+		return hash, fmt.Errorf("failed to %s: %v", op.Method, err)
+	}
+	return hash, nil
+}
+
+// pack encodes a call of the operation with the argument values
+func pack(op *Operation, v values) ([]byte, error) {
+	args := make([]interface{}, len(op.Args))
+	for i, arg := range op.Args {
+		value, err := argument(op.method.Inputs[i].Type, arg, v)
+		if err != nil {
+			return nil, fmt.Errorf("%s argument %d: %v", op.Method, i+1, err)
+		}
+		args[i] = value
+	}
+
+	data, err := op.method.Inputs.Pack(args...)
+	if err != nil {
+		return nil, err
+	}
+	return append(slices.Clone(op.method.ID), data...), nil
+}
+
+// argument returns the value of an argument name, or parses a literal
+func argument(typ abi.Type, arg string, v values) (interface{}, error) {
+	switch arg {
+	case ArgAmount:
+		return integer(typ, arg, v.amount)
+	case ArgShares:
+		return integer(typ, arg, v.shares)
+	case ArgID:
+		return integer(typ, arg, v.id)
+	case ArgAddress:
+		if typ.T != abi.AddressTy {
+			return nil, fmt.Errorf("address is passed as %s", typ)
+		}
+		return common.HexToAddress(v.address), nil
+	case ArgIDs:
+		if typ.T != abi.SliceTy {
+			return nil, fmt.Errorf("ids are passed as %s", typ)
+		}
+		list := reflect.MakeSlice(typ.GetType(), len(v.ids), len(v.ids))
+		for i, id := range v.ids {
+			value, err := integer(*typ.Elem, arg, id)
+			if err != nil {
+				return nil, err
+			}
+			list.Index(i).Set(reflect.ValueOf(value))
+		}
+		return list.Interface(), nil
+	}
+
+	switch typ.T {
+	case abi.AddressTy:
+		if !common.IsHexAddress(arg) {
+			return nil, fmt.Errorf("invalid address %q", arg)
+		}
+		return common.HexToAddress(arg), nil
+	case abi.UintTy, abi.IntTy:
+		n, ok := new(big.Int).SetString(arg, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s %q", typ, arg)
+		}
+		return integer(typ, arg, n)
+	case abi.BoolTy:
+		return strconv.ParseBool(arg)
+	case abi.StringTy:
+		return arg, nil
+	}
+	return nil, fmt.Errorf("%s literals are not supported", typ)
+}
+
+// integer converts n to the Go type of an integer ABI type
+func integer(typ abi.Type, name string, n *big.Int) (interface{}, error) {
+	if n == nil {
+		return nil, fmt.Errorf("%s is not known here", name)
+	}
+	if typ.T != abi.UintTy && typ.T != abi.IntTy {
+		return nil, fmt.Errorf("%s is passed as %s", name, typ)
+	}
+	if typ.Size > 64 {
+		return n, nil
+	}
+
+	value := reflect.New(typ.GetType()).Elem()
+	switch {
+	case typ.T == abi.UintTy && n.IsUint64() && !value.OverflowUint(n.Uint64()):
+		value.SetUint(n.Uint64())
+	case typ.T == abi.IntTy && n.IsInt64() && !value.OverflowInt(n.Int64()):
+		value.SetInt(n.Int64())
+	default:
+		return nil, fmt.Errorf("%s out of range for %s", n, typ)
+	}
+	return value.Interface(), nil
+}
+
+// toBig converts an unpacked integer to a big.Int
+func toBig(value interface{}) (*big.Int, bool) {
+	if n, ok := value.(*big.Int); ok {
+		return n, true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(rv.Uint()), true
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int()), true
+	}
+	return nil, false
+}
+
+// outputFields names the outputs of a call, the fields of a single tuple
+// output being listed instead
+func outputFields(outputs abi.Arguments, results []interface{}) []Field {
+	if len(results) == 1 && reflect.ValueOf(results[0]).Kind() == reflect.Struct {
+		return structFields(reflect.ValueOf(results[0]))
+	}
+
+	fields := make([]Field, len(results))
+	for i, result := range results {
+		name := outputs[i].Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		fields[i] = Field{Name: name, Value: formatValue(result)}
+	}
+	return fields
+}
+
+// structFields lists the fields of an unpacked tuple by their ABI names
+func structFields(tuple reflect.Value) []Field {
+	fields := make([]Field, tuple.NumField())
+	for i := range fields {
+		name := tuple.Type().Field(i).Tag.Get("json")
+		if name == "" {
+			name = tuple.Type().Field(i).Name
+		}
+		fields[i] = Field{Name: name, Value: formatValue(tuple.Field(i).Interface())}
+	}
+	return fields
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case common.Address:
+		return v.Hex()
+	case []byte:
+		return hexutil.Encode(v)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,190 @@
+package stake
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/galihrivanto/omonOmon/wallet"
+	"github.com/galihrivanto/omonOmon/wallet/wallettest"
+	"github.com/stretchr/testify/assert"
+)
+
+const testABI = `[
+	{"name": "deposit", "type": "function", "stateMutability": "payable",
+		"inputs": [{"name": "assets", "type": "uint256"}, {"name": "receiver", "type": "address"}],
+		"outputs": [{"name": "", "type": "uint256"}]},
+	{"name": "balanceOf", "type": "function", "stateMutability": "view",
+		"inputs": [{"name": "owner", "type": "address"}],
+		"outputs": [{"name": "", "type": "uint256"}]},
+	{"name": "convertToAssets", "type": "function", "stateMutability": "view",
+		"inputs": [{"name": "shares", "type": "uint256"}],
+		"outputs": [{"name": "", "type": "uint256"}]},
+	{"name": "requestRedeem", "type": "function", "stateMutability": "nonpayable",
+		"inputs": [{"name": "shares", "type": "uint256"}, {"name": "controller", "type": "address"}, {"name": "owner", "type": "address"}],
+		"outputs": [{"name": "", "type": "uint256"}]},
+	{"name": "getUserRequestIds", "type": "function", "stateMutability": "view",
+		"inputs": [{"name": "owner", "type": "address"}],
+		"outputs": [{"name": "", "type": "uint256[]"}]},
+	{"name": "getRequest", "type": "function", "stateMutability": "view",
+		"inputs": [{"name": "id", "type": "uint256"}],
+		"outputs": [{"name": "assets", "type": "uint256"}, {"name": "claimable", "type": "bool"}]},
+	{"name": "getUserRequests", "type": "function", "stateMutability": "view",
+		"inputs": [{"name": "owner", "type": "address"}],
+		"outputs": [{"name": "", "type": "tuple[]", "components": [{"name": "id", "type": "uint256"}, {"name": "assets", "type": "uint96"}, {"name": "claimable", "type": "bool"}]}]},
+	{"name": "redeem", "type": "function", "stateMutability": "nonpayable",
+		"inputs": [{"name": "ids", "type": "uint256[]"}, {"name": "receiver", "type": "address"}],
+		"outputs": []},
+	{"name": "claim", "type": "function", "stateMutability": "nonpayable",
+		"inputs": [{"name": "id", "type": "uint64"}],
+		"outputs": []}
+]`
+
+const testAddress = "0x1111111111111111111111111111111111111111"
+
+var testWallet = &wallet.Wallet{Address: "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"}
+
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testProtocol loads a protocol with the operations given as YAML
+func testProtocol(t *testing.T, operations string) *Protocol {
+	dir := t.TempDir()
+	writeFile(t, dir, "staking.json", testABI)
+	path := writeFile(t, dir, "stake.yaml", `
+networks:
+  monad-testnet:
+    protocols:
+      apriori:
+        address: "`+testAddress+`"
+        abi: staking.json
+        symbol: aprMON
+        deposit: {method: deposit, args: [amount, address]}
+        balance: {method: balanceOf, args: [address]}
+`+operations)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.Networks[DefaultNetwork].Protocols["apriori"]
+}
+
+func testStaker(t *testing.T, operations string, results map[string][]interface{}) (*Staker, *wallettest.Backend) {
+	protocol := testProtocol(t, operations)
+	backend := wallettest.NewBackend(results, protocol.abi)
+	return &Staker{Protocol: protocol, Backend: backend}, backend
+}
+
+func TestStakerDeposit(t *testing.T) {
+	staker, backend := testStaker(t, "", nil)
+
+	_, err := staker.Deposit(context.Background(), testWallet, big.NewInt(1000))
+	assert.NoError(t, err)
+	assert.Equal(t, []wallettest.Tx{{
+		To:     testAddress,
+		Value:  big.NewInt(1000),
+		Method: "deposit",
+		Args:   []interface{}{big.NewInt(1000), common.HexToAddress(testWallet.Address)},
+	}}, backend.Sent)
+}
+
+func TestStakerShares(t *testing.T) {
+	staker, _ := testStaker(t, "        rate: {method: convertToAssets, args: [shares]}\n", map[string][]interface{}{
+		"balanceOf":       {big.NewInt(2000000000000000000)},
+		"convertToAssets": {big.NewInt(1100000000000000000)},
+	})
+
+	shares, err := staker.Shares(context.Background(), testWallet.Address)
+	assert.NoError(t, err)
+	rate, err := staker.Rate(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(2200000000000000000), Value(shares, rate))
+
+	staker, _ = testStaker(t, "", nil)
+	_, err = staker.Rate(context.Background())
+	assert.EqualError(t, err, "the staking protocol has no rate configured")
+}
+
+func TestStakerRequestWithdraw(t *testing.T) {
+	staker, backend := testStaker(t, "        withdraw: {method: requestRedeem, args: [shares, address, address]}\n", nil)
+
+	_, err := staker.RequestWithdraw(context.Background(), testWallet, big.NewInt(500))
+	assert.NoError(t, err)
+	address := common.HexToAddress(testWallet.Address)
+	assert.Equal(t, []wallettest.Tx{{To: testAddress, Method: "requestRedeem", Args: []interface{}{big.NewInt(500), address, address}}}, backend.Sent)
+}
+
+func TestStakerRequests(t *testing.T) {
+	t.Run("ids", func(t *testing.T) {
+		staker, _ := testStaker(t, `        requests: {method: getUserRequestIds, args: [address]}
+        request: {method: getRequest, args: [id]}
+`, map[string][]interface{}{
+			"getUserRequestIds": {[]*big.Int{big.NewInt(3)}},
+			"getRequest":        {big.NewInt(1500), true},
+		})
+
+		requests, err := staker.Requests(context.Background(), testWallet.Address)
+		assert.NoError(t, err)
+		assert.Equal(t, []Request{{ID: big.NewInt(3), Fields: []Field{{"assets", "1500"}, {"claimable", "true"}}}}, requests)
+	})
+
+	t.Run("tuples", func(t *testing.T) {
+		protocol := testProtocol(t, "        requests: {method: getUserRequests, args: [address]}\n")
+		method := protocol.abi.Methods["getUserRequests"]
+		// encode the tuples as the contract would
+		data, err := method.Outputs.Pack([]struct {
+			Id        *big.Int `json:"id"`
+			Assets    *big.Int `json:"assets"`
+			Claimable bool     `json:"claimable"`
+		}{{big.NewInt(4), big.NewInt(700), false}})
+		assert.NoError(t, err)
+
+		staker := &Staker{Protocol: protocol, Backend: rawBackend(data)}
+		requests, err := staker.Requests(context.Background(), testWallet.Address)
+		assert.NoError(t, err)
+		assert.Equal(t, []Request{{ID: big.NewInt(4), Fields: []Field{{"id", "4"}, {"assets", "700"}, {"claimable", "false"}}}}, requests)
+	})
+}
+
+// rawBackend returns the same result to every call
+type rawBackend []byte
+
+func (b rawBackend) Call(ctx context.Context, to string, data []byte) ([]byte, error) {
+	return b, nil
+}
+
+func (b rawBackend) Transact(ctx context.Context, w *wallet.Wallet, to string, value *big.Int, data []byte) (string, error) {
+	return "", fmt.Errorf("unexpected transaction")
+}
+
+func TestStakerClaim(t *testing.T) {
+	ids := []*big.Int{big.NewInt(3), big.NewInt(4)}
+
+	staker, backend := testStaker(t, "        claim: {method: redeem, args: [ids, address]}\n", nil)
+	hashes, err := staker.Claim(context.Background(), testWallet, ids)
+	assert.NoError(t, err)
+	assert.Len(t, hashes, 1)
+	assert.Equal(t, []interface{}{ids, common.HexToAddress(testWallet.Address)}, backend.Sent[0].Args)
+
+	staker, backend = testStaker(t, "        claim: {method: claim, args: [id]}\n", nil)
+	hashes, err = staker.Claim(context.Background(), testWallet, ids)
+	assert.NoError(t, err)
+	assert.Len(t, hashes, 2)
+	assert.Equal(t, []wallettest.Tx{
+		{To: testAddress, Method: "claim", Args: []interface{}{uint64(3)}},
+		{To: testAddress, Method: "claim", Args: []interface{}{uint64(4)}},
+	}, backend.Sent)
+
+	_, err = staker.Claim(context.Background(), testWallet, nil)
+	assert.EqualError(t, err, "request ids are needed to claim")
+}
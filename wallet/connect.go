@@ -4,10 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/gorilla/websocket"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
 // WalletClient represents the wallet side of WalletConnect
@@ -16,7 +17,7 @@ type WalletClient struct {
 	key            string
 	clientId       string
 	peerMeta       PeerMeta
-	conn           *websocket.Conn
+	transport      *Transport
 	handshakeTopic string
 	connected      bool
 }
@@ -80,6 +81,12 @@ func ParseWalletConnectURI(uri string) (bridge string, handshakeTopic string, ke
 
 // ConnectToURI connects to a dApp using a WalletConnect URI
 func ConnectToURI(uri string, walletAddress common.Address) (*WalletClient, error) {
+	return ConnectToURIContext(context.Background(), uri, walletAddress, DefaultTransportOptions())
+}
+
+// ConnectToURIContext connects to a dApp using a WalletConnect URI,
+// giving up on the initial dial when ctx is cancelled
+func ConnectToURIContext(ctx context.Context, uri string, walletAddress common.Address, opts TransportOptions) (*WalletClient, error) {
 	bridge, topic, key, err := ParseWalletConnectURI(uri)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse URI: %v", err)
@@ -88,18 +95,19 @@ func ConnectToURI(uri string, walletAddress common.Address) (*WalletClient, erro
 	client := &WalletClient{
 		bridge:         bridge,
 		key:            key,
+		clientId:       newUUID(),
 		handshakeTopic: topic,
 	}
 
 	// Connect to bridge
-	conn, _, err := websocket.DefaultDialer.Dial(bridge, nil)
-	if err != nil {
+	client.transport = NewTransport(bridge, opts)
+	if err := client.transport.Connect(ctx); err != nil {
 		return nil, fmt.Errorf("failed to connect to bridge: %v", err)
 	}
-	client.conn = conn
 
 	// Subscribe to session request topic
 	if err := client.subscribe(topic); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to subscribe: %v", err)
 	}
 
@@ -114,7 +122,7 @@ func (c *WalletClient) HandleSessionRequest(ctx context.Context) (*SessionReques
 		Payload json.RawMessage `json:"payload"`
 	}
 
-	if err := c.conn.ReadJSON(&msg); err != nil {
+	if err := c.transport.ReadJSON(ctx, &msg); err != nil {
 		return nil, fmt.Errorf("failed to read message: %v", err)
 	}
 
@@ -155,39 +163,83 @@ func (c *WalletClient) ApproveSession(address common.Address, chainId int) error
 		Payload: payload,
 	}
 
-	return c.conn.WriteJSON(msg)
+	return c.transport.WriteJSON(msg)
+}
+
+// RejectSession tells the dApp its session request was rejected
+func (c *WalletClient) RejectSession() error {
+	msg := struct {
+		Topic   string      `json:"topic"`
+		Type    string      `json:"type"`
+		Payload interface{} `json:"payload"`
+	}{
+		Topic: c.handshakeTopic,
+		Type:  "pub",
+		Payload: map[string]interface{}{
+			"approved": false,
+			"peerId":   c.clientId,
+		},
+	}
+
+	return c.transport.WriteJSON(msg)
+}
+
+// RequestResponse is sent back to the dApp once a request has been handled.
+// Requests are handled in order, so responses arrive in request order.
+type RequestResponse struct {
+	Method string `json:"method"`
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Respond sends the outcome of a request back to the dApp
+func (c *WalletClient) Respond(method string, result string, err error) error {
+	response := RequestResponse{Method: method, Result: result}
+	if err != nil {
+		response.Error = err.Error()
+	}
+
+	msg := struct {
+		Topic   string          `json:"topic"`
+		Type    string          `json:"type"`
+		Payload RequestResponse `json:"payload"`
+	}{
+		Topic:   c.handshakeTopic,
+		Type:    "response",
+		Payload: response,
+	}
+
+	return c.transport.WriteJSON(msg)
 }
 
 // HandleRequests listens for and handles incoming requests from the dApp
 func (c *WalletClient) HandleRequests(ctx context.Context, handlers RequestHandlers) error {
 	for {
-		select {
-		case <-ctx.Done():
-			return nil
-		default:
-			var msg struct {
-				Type    string          `json:"type"`
-				Payload json.RawMessage `json:"payload"`
-			}
+		var msg struct {
+			Type    string          `json:"type"`
+			Payload json.RawMessage `json:"payload"`
+		}
 
-			if err := c.conn.ReadJSON(&msg); err != nil {
-				return fmt.Errorf("failed to read message: %v", err)
+		if err := c.transport.ReadJSON(ctx, &msg); err != nil {
+			if ctx.Err() != nil {
+				return nil
 			}
+			return fmt.Errorf("failed to read message: %v", err)
+		}
 
-			// Handle different request types
-			switch msg.Type {
-			case "eth_sendTransaction":
-				if handlers.SendTransaction != nil {
-					handlers.SendTransaction(msg.Payload)
-				}
-			case "eth_sign":
-				if handlers.Sign != nil {
-					handlers.Sign(msg.Payload)
-				}
-			case "personal_sign":
-				if handlers.PersonalSign != nil {
-					handlers.PersonalSign(msg.Payload)
-				}
+		// Handle different request types
+		switch msg.Type {
+		case "eth_sendTransaction":
+			if handlers.SendTransaction != nil {
+				handlers.SendTransaction(msg.Payload)
+			}
+		case "eth_sign":
+			if handlers.Sign != nil {
+				handlers.Sign(msg.Payload)
+			}
+		case "personal_sign":
+			if handlers.PersonalSign != nil {
+				handlers.PersonalSign(msg.Payload)
 			}
 		}
 	}
@@ -201,54 +253,169 @@ type RequestHandlers struct {
 }
 
 func (c *WalletClient) subscribe(topic string) error {
-	msg := struct {
-		Topic string `json:"topic"`
-		Type  string `json:"type"`
-	}{
-		Topic: topic,
-		Type:  "sub",
-	}
-	return c.conn.WriteJSON(msg)
+	return c.transport.Subscribe(topic)
 }
 
 // Close closes the WalletConnect connection
 func (c *WalletClient) Close() error {
-	if c.conn != nil {
-		return c.conn.Close()
+	if c.transport != nil {
+		return c.transport.Close()
 	}
 	return nil
 }
 
+// ConnectOptions controls how WalletConnect sessions are vetted and approved
+type ConnectOptions struct {
+	// Policy decides requests, nil means DefaultPolicy
+	Policy *Policy
+	// Validator vets the dApp before its session is approved,
+	// nil means a validator without domain lists
+	Validator *PeerValidator
+}
+
+func (o ConnectOptions) withDefaults() ConnectOptions {
+	if o.Policy == nil {
+		o.Policy = DefaultPolicy()
+	}
+	if o.Validator == nil {
+		o.Validator = NewPeerValidator(nil)
+	}
+	return o
+}
+
+// WalletConnect connects the wallet to a dApp and serves its requests,
+// asking for session approval on the terminal
 func (w *Wallet) WalletConnect(uri string) error {
+	return w.WalletConnectWithOptions(uri, ConnectOptions{})
+}
+
+// WalletConnectWithOptions connects the wallet to a dApp and serves its
+// requests, deciding them with the options policy and asking on the
+// terminal for requests the policy does not match
+func (w *Wallet) WalletConnectWithOptions(uri string, opts ConnectOptions) error {
+	approver := NewPromptApprover(os.Stdin, os.Stdout)
+	opts = opts.withDefaults()
+
+	return w.serveSession(context.Background(), uri, sessionConfig{
+		policy:    opts.Policy,
+		validator: opts.Validator,
+		prompt: func(req *ApprovalRequest) (bool, error) {
+			return approver.Approve(req), nil
+		},
+		logf: func(format string, args ...interface{}) {
+			fmt.Printf(format, args...)
+		},
+	})
+}
+
+// sessionConfig controls how a session is approved and reported
+type sessionConfig struct {
+	policy    *Policy
+	validator *PeerValidator
+	prompt    func(req *ApprovalRequest) (bool, error)
+	logf      func(format string, args ...interface{})
+}
+
+// approve decides req with the policy, escalating to the prompt when
+// no rule decides it or the dApp raised warnings, and logs the outcome
+func (cfg sessionConfig) approve(req *ApprovalRequest) (bool, error) {
+	decision := cfg.policy.Evaluate(req)
+
+	switch {
+	case decision.Action == PolicyApprove && len(req.Warnings) > 0:
+		cfg.logf("Policy: %s from %s escalated to prompt (rule %q, %d warnings)\n",
+			req.Method, peerDomain(req.PeerMeta), decision.Rule, len(req.Warnings))
+		return cfg.promptFor(req)
+	case decision.Action == PolicyApprove:
+		cfg.logf("Policy: %s from %s approved by rule %q\n", req.Method, peerDomain(req.PeerMeta), decision.Rule)
+		return true, nil
+	case decision.Action == PolicyReject:
+		cfg.logf("Policy: %s from %s rejected by rule %q\n", req.Method, peerDomain(req.PeerMeta), decision.Rule)
+		return false, nil
+	}
+
+	rule := "no matching rule"
+	if decision.Rule != "" {
+		rule = fmt.Sprintf("rule %q", decision.Rule)
+	}
+	cfg.logf("Policy: %s from %s escalated to prompt (%s)\n", req.Method, peerDomain(req.PeerMeta), rule)
+
+	return cfg.promptFor(req)
+}
+
+// promptFor asks the operator to decide req and logs the answer
+func (cfg sessionConfig) promptFor(req *ApprovalRequest) (bool, error) {
+	approved, err := cfg.prompt(req)
+	if err != nil {
+		return false, err
+	}
+
+	if approved {
+		cfg.logf("Policy: %s from %s approved by operator\n", req.Method, peerDomain(req.PeerMeta))
+	} else {
+		cfg.logf("Policy: %s from %s rejected by operator\n", req.Method, peerDomain(req.PeerMeta))
+	}
+	return approved, nil
+}
+
+// serveSession runs a WalletConnect session until the dApp disconnects
+// or ctx is cancelled
+func (w *Wallet) serveSession(ctx context.Context, uri string, cfg sessionConfig) error {
 	address := common.HexToAddress(w.Address)
 
-	client, err := ConnectToURI(uri, address)
+	client, err := ConnectToURIContext(ctx, uri, address, DefaultTransportOptions())
 	if err != nil {
 		return fmt.Errorf("failed to connect: %v", err)
 	}
 	defer client.Close()
 
-	fmt.Println("Connected to WalletConnect bridge")
-
-	ctx := context.Background()
+	cfg.logf("Connected to WalletConnect bridge\n")
 
 	// Handle session request
 	request, err := client.HandleSessionRequest(ctx)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
 		return fmt.Errorf("failed to handle session request: %v", err)
 	}
+	if request == nil {
+		return fmt.Errorf("failed to handle session request: unexpected message")
+	}
 
-	// Display dApp info and ask for confirmation
-	fmt.Printf("\nConnection request from dApp:\n")
-	fmt.Printf("Name: %s\n", request.PeerMeta.Name)
-	fmt.Printf("URL: %s\n", request.PeerMeta.URL)
-	fmt.Printf("Description: %s\n", request.PeerMeta.Description)
+	// Vet the dApp before anyone is asked to approve it
+	check := cfg.validator.Validate(request)
+	for _, warning := range check.Warnings {
+		cfg.logf("WARNING: %s\n", warning)
+	}
+	if check.Blocked {
+		if err := client.RejectSession(); err != nil {
+			cfg.logf("failed to notify dApp of rejection: %v\n", err)
+		}
+		return fmt.Errorf("connection rejected: %s is denylisted", peerDomain(request.PeerMeta))
+	}
+	if check.Trusted {
+		cfg.logf("dApp %s is allowlisted\n", peerDomain(request.PeerMeta))
+	}
 
-	fmt.Print("\nApprove connection? (y/N): ")
-	var response string
-	fmt.Scanln(&response)
+	approved, err := cfg.approve(&ApprovalRequest{
+		Account:  w.Address,
+		Method:   MethodSessionRequest,
+		PeerMeta: request.PeerMeta,
+		ChainId:  request.ChainId,
+		Warnings: check.Warnings,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
 
-	if strings.ToLower(response) != "y" {
+	if !approved {
+		if err := client.RejectSession(); err != nil {
+			cfg.logf("failed to notify dApp of rejection: %v\n", err)
+		}
 		return fmt.Errorf("connection rejected by user")
 	}
 
@@ -257,45 +424,106 @@ func (w *Wallet) WalletConnect(uri string) error {
 		return fmt.Errorf("failed to approve session: %v", err)
 	}
 
-	fmt.Println("Connection approved! Listening for requests...")
+	cfg.logf("Connection approved! Listening for requests...\n")
+
+	// Handle incoming requests until interrupted
+	err = client.HandleRequests(ctx, w.requestHandlers(ctx, client, request, cfg))
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// requestHandlers builds the handlers signing approved dApp requests with
+// the wallet and sending the outcome back to the dApp
+func (w *Wallet) requestHandlers(ctx context.Context, client *WalletClient, session *SessionRequest, cfg sessionConfig) RequestHandlers {
+	logf := cfg.logf
+
+	approve := func(method string, payload json.RawMessage) bool {
+		approved, err := cfg.approve(&ApprovalRequest{
+			Account:  w.Address,
+			Method:   method,
+			PeerMeta: session.PeerMeta,
+			ChainId:  session.ChainId,
+			Payload:  payload,
+		})
+		if err != nil {
+			logf("failed to approve %s: %v\n", method, err)
+			respond(client, logf, method, "", err)
+			return false
+		}
+		if !approved {
+			logf("%s request rejected\n", method)
+			respond(client, logf, method, "", fmt.Errorf("request rejected by user"))
+		}
+		return approved
+	}
 
-	// Set up request handlers
-	handlers := RequestHandlers{
+	return RequestHandlers{
 		SendTransaction: func(payload json.RawMessage) {
-			fmt.Printf("\nTransaction request received: %s\n", string(payload))
+			logf("\nTransaction request received: %s\n", string(payload))
 			request := TransactionRequest{}
 			if err := json.Unmarshal(payload, &request); err != nil {
-				fmt.Printf("failed to parse transaction request: %v", err)
+				logf("failed to parse transaction request: %v\n", err)
+				respond(client, logf, MethodSendTransaction, "", err)
+				return
+			}
+
+			if !approve(MethodSendTransaction, payload) {
 				return
 			}
 
 			txHash, err := w.SendTransactionFromRequest(ctx, request)
 			if err != nil {
-				fmt.Printf("failed to send transaction: %v", err)
+				logf("failed to send transaction: %v\n", err)
+				respond(client, logf, MethodSendTransaction, "", err)
 				return
 			}
-			fmt.Printf("Transaction sent with hash: %s\n", txHash)
+			logf("Transaction sent with hash: %s\n", txHash)
+			respond(client, logf, MethodSendTransaction, txHash, nil)
 		},
 		Sign: func(payload json.RawMessage) {
-			fmt.Printf("\nSign request received: %s\n", string(payload))
+			logf("\nSign request received: %s\n", string(payload))
+			if !approve("eth_sign", payload) {
+				return
+			}
+
 			signature, err := w.Sign(payload)
 			if err != nil {
-				fmt.Printf("failed to sign message: %v", err)
+				logf("failed to sign message: %v\n", err)
+				respond(client, logf, "eth_sign", "", err)
 				return
 			}
-			fmt.Printf("Signature: %s\n", signature)
+			logf("Signature: %s\n", hexutil.Encode(signature))
+			respond(client, logf, "eth_sign", hexutil.Encode(signature), nil)
 		},
 		PersonalSign: func(payload json.RawMessage) {
-			fmt.Printf("\nPersonal sign request received: %s\n", string(payload))
-			signature, err := w.PersonalSign(string(payload))
+			logf("\nPersonal sign request received: %s\n", string(payload))
+			if !approve("personal_sign", payload) {
+				return
+			}
+
+			// the message is usually sent as a JSON string
+			var message string
+			if err := json.Unmarshal(payload, &message); err != nil {
+				message = string(payload)
+			}
+
+			signature, err := w.PersonalSign(message)
 			if err != nil {
-				fmt.Printf("failed to sign message: %v", err)
+				logf("failed to sign message: %v\n", err)
+				respond(client, logf, "personal_sign", "", err)
 				return
 			}
-			fmt.Printf("Signature: %s\n", signature)
+			logf("Signature: %s\n", signature)
+			respond(client, logf, "personal_sign", signature, nil)
 		},
 	}
+}
 
-	// Handle incoming requests until interrupted
-	return client.HandleRequests(ctx, handlers)
+// respond sends a request outcome to the dApp, logging delivery failures
+func respond(client *WalletClient, logf func(format string, args ...interface{}), method string, result string, err error) {
+	if err := client.Respond(method, result, err); err != nil {
+		logf("failed to respond to %s: %v\n", method, err)
+	}
 }
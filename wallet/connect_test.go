@@ -124,11 +124,11 @@ func TestWalletClient_HandleSessionRequest(t *testing.T) {
 	}
 
 	// Connect to test server
-	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	client.transport = NewTransport(wsURL, TransportOptions{})
+	err := client.transport.Connect(context.Background())
 	if err != nil {
 		t.Fatalf("failed to connect to test server: %v", err)
 	}
-	client.conn = conn
 	defer client.Close()
 
 	// Test HandleSessionRequest
@@ -176,11 +176,11 @@ func TestWalletClient_ApproveSession(t *testing.T) {
 	}
 
 	// Connect to test server
-	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	client.transport = NewTransport(wsURL, TransportOptions{})
+	err := client.transport.Connect(context.Background())
 	if err != nil {
 		t.Fatalf("failed to connect to test server: %v", err)
 	}
-	client.conn = conn
 	defer client.Close()
 
 	// Test ApproveSession
@@ -253,11 +253,11 @@ func TestWalletClient_HandleRequests(t *testing.T) {
 	}
 
 	// Connect to test server
-	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	client.transport = NewTransport(wsURL, TransportOptions{})
+	err := client.transport.Connect(context.Background())
 	if err != nil {
 		t.Fatalf("failed to connect to test server: %v", err)
 	}
-	client.conn = conn
 	defer client.Close()
 
 	// Create channels for synchronization
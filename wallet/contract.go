@@ -0,0 +1,254 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// receiptPollInterval is the delay between transaction receipt checks
+var receiptPollInterval = 2 * time.Second
+
+const erc20JSON = `[
+	{"name": "allowance", "type": "function", "stateMutability": "view", "inputs": [{"name": "owner", "type": "address"}, {"name": "spender", "type": "address"}], "outputs": [{"name": "", "type": "uint256"}]},
+	{"name": "approve", "type": "function", "stateMutability": "nonpayable", "inputs": [{"name": "spender", "type": "address"}, {"name": "amount", "type": "uint256"}], "outputs": [{"name": "", "type": "bool"}]},
+	{"name": "balanceOf", "type": "function", "stateMutability": "view", "inputs": [{"name": "owner", "type": "address"}], "outputs": [{"name": "", "type": "uint256"}]},
+	{"name": "decimals", "type": "function", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint8"}]}
+]`
+
+// ERC20ABI is the part of the ERC-20 ABI used by ERC20
+var ERC20ABI = MustParseABI(erc20JSON)
+
+// MustParseABI parses a JSON ABI known to be valid, it panics otherwise
+func MustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// Backend is the chain contracts are called and transacted on
+type Backend interface {
+	Call(ctx context.Context, to string, data []byte) ([]byte, error)
+	// Transact sends a transaction and waits for it to be mined
+	Transact(ctx context.Context, w *Wallet, to string, value *big.Int, data []byte) (string, error)
+}
+
+// RPCBackend is the Backend of the Monad testnet
+type RPCBackend struct{}
+
+func (RPCBackend) Call(ctx context.Context, to string, data []byte) ([]byte, error) {
+	return Call(ctx, to, data)
+}
+
+func (RPCBackend) Transact(ctx context.Context, w *Wallet, to string, value *big.Int, data []byte) (string, error) {
+	return w.Transact(ctx, to, value, data)
+}
+
+// Transact sends a transaction calling the contract at to with data,
+// estimating its gas, and waits for it to be mined. The transaction hash
+// is returned with the error of a reverted transaction too.
+func (w *Wallet) Transact(ctx context.Context, to string, value *big.Int, data []byte) (string, error) {
+	client, err := ethclient.DialContext(ctx, RPC_URL)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	privateKey, err := crypto.HexToECDSA(w.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid private key: %v", err)
+	}
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+	toAddress := common.HexToAddress(to)
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	nonce, err := client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return "", fmt.Errorf("failed to get nonce: %v", err)
+	}
+
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to suggest gas price: %v", err)
+	}
+
+	gasLimit, err := client.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &toAddress, Value: value, Data: data})
+	if err != nil {
+		return "", fmt.Errorf("failed to estimate gas: %v", err)
+	}
+
+	tx := types.NewTransaction(nonce, toAddress, value, gasLimit, gasPrice, data)
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(CHAIN_ID)), privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign transaction: %v", err)
+	}
+
+	if err := client.SendTransaction(ctx, signedTx); err != nil {
+		return "", fmt.Errorf("failed to send transaction: %v", err)
+	}
+
+	hash := signedTx.Hash().Hex()
+	if _, err := waitReceipt(ctx, client, signedTx.Hash()); err != nil {
+		return hash, err
+	}
+	return hash, nil
+}
+
+// WaitReceipt waits until the transaction is mined, failing when it reverted
+func WaitReceipt(ctx context.Context, hash string) (*types.Receipt, error) {
+	client, err := ethclient.DialContext(ctx, RPC_URL)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	return waitReceipt(ctx, client, common.HexToHash(hash))
+}
+
+func waitReceipt(ctx context.Context, client *ethclient.Client, hash common.Hash) (*types.Receipt, error) {
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+
+	for {
+		receipt, err := client.TransactionReceipt(ctx, hash)
+		switch {
+		case err == nil && receipt.Status != types.ReceiptStatusSuccessful:
+			return receipt, fmt.Errorf("transaction %s reverted", hash.Hex())
+		case err == nil:
+			return receipt, nil
+		case !errors.Is(err, ethereum.NotFound):
+			return nil, fmt.Errorf("failed to get receipt of %s: %v", hash.Hex(), err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("transaction %s not mined: %v", hash.Hex(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Call runs a read only call of the contract at to with data
+func Call(ctx context.Context, to string, data []byte) ([]byte, error) {
+	client, err := ethclient.DialContext(ctx, RPC_URL)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	toAddress := common.HexToAddress(to)
+	return client.CallContract(ctx, ethereum.CallMsg{To: &toAddress, Data: data}, nil)
+}
+
+// TokenBalance returns the ERC-20 token balance of owner, in the token
+// smallest unit
+func TokenBalance(ctx context.Context, token string, owner string) (*big.Int, error) {
+	return ERC20{RPCBackend{}, token}.BalanceOf(ctx, owner)
+}
+
+// TokenDecimals returns the decimals of an ERC-20 token
+func TokenDecimals(ctx context.Context, token string) (int, error) {
+	return ERC20{RPCBackend{}, token}.Decimals(ctx)
+}
+
+// ERC20 is an ERC-20 token used through a backend
+type ERC20 struct {
+	Backend Backend
+	Address string
+}
+
+// BalanceOf returns the balance of owner, in the token smallest unit
+func (t ERC20) BalanceOf(ctx context.Context, owner string) (*big.Int, error) {
+	var balance *big.Int
+	if err := t.call(ctx, "balanceOf", &balance, common.HexToAddress(owner)); err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+// Decimals returns the decimals of the token
+func (t ERC20) Decimals(ctx context.Context) (int, error) {
+	var decimals uint8
+	if err := t.call(ctx, "decimals", &decimals); err != nil {
+		return 0, err
+	}
+	return int(decimals), nil
+}
+
+// Allowance returns the amount spender may spend of owner's tokens
+func (t ERC20) Allowance(ctx context.Context, owner string, spender string) (*big.Int, error) {
+	var allowance *big.Int
+	if err := t.call(ctx, "allowance", &allowance, common.HexToAddress(owner), common.HexToAddress(spender)); err != nil {
+		return nil, err
+	}
+	return allowance, nil
+}
+
+// Approve lets spender spend amount of the tokens of w
+func (t ERC20) Approve(ctx context.Context, w *Wallet, spender string, amount *big.Int) (string, error) {
+	data, err := ERC20ABI.Pack("approve", common.HexToAddress(spender), amount)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := t.Backend.Transact(ctx, w, t.Address, nil, data)
+	if err != nil {
+		return hash, fmt.Errorf("failed to approve: %v", err)
+	}
+	return hash, nil
+}
+
+func (t ERC20) call(ctx context.Context, method string, out interface{}, args ...interface{}) error {
+	data, err := ERC20ABI.Pack(method, args...)
+	if err != nil {
+		return err
+	}
+
+	result, err := t.Backend.Call(ctx, t.Address, data)
+	if err != nil {
+		return fmt.Errorf("failed to call %s of %s: %v", method, t.Address, err)
+	}
+
+	values, err := ERC20ABI.Unpack(method, result)
+	if err != nil || len(values) == 0 {
+		return fmt.Errorf("failed to call %s of %s: unexpected result %x", method, t.Address, result)
+	}
+	return ERC20ABI.Methods[method].Outputs.Copy(out, values)
+}
+
+// ParseUnits converts a decimal amount into the smallest unit of a token
+// with the given decimals
+func ParseUnits(amount string, decimals int) (*big.Int, error) {
+	value, ok := new(big.Rat).SetString(amount)
+	if !ok || value.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+
+	value.Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	return new(big.Int).Quo(value.Num(), value.Denom()), nil
+}
+
+// FormatUnits formats an amount in the smallest unit of a token with the
+// given decimals as a decimal number, without trailing zeros
+func FormatUnits(value *big.Int, decimals int) string {
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	text := new(big.Rat).SetFrac(value, unit).FloatString(decimals)
+	if strings.Contains(text, ".") {
+		text = strings.TrimRight(strings.TrimRight(text, "0"), ".")
+	}
+	return text
+}
@@ -0,0 +1,57 @@
+package wallet
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUnits(t *testing.T) {
+	tests := []struct {
+		amount   string
+		decimals int
+		expected string
+		err      bool
+	}{
+		{"1", 18, "1000000000000000000", false},
+		{"0.5", 6, "500000", false},
+		{"1.2345678", 6, "1234567", false},
+		{"10", 0, "10", false},
+		{"-1", 18, "", true},
+		{"one", 18, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.amount, func(t *testing.T) {
+			value, err := ParseUnits(test.amount, test.decimals)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, value.String())
+		})
+	}
+}
+
+func TestFormatUnits(t *testing.T) {
+	tests := []struct {
+		value    string
+		decimals int
+		expected string
+	}{
+		{"1000000000000000000", 18, "1"},
+		{"1500000000000000000", 18, "1.5"},
+		{"1", 6, "0.000001"},
+		{"0", 18, "0"},
+		{"42", 0, "42"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			value, _ := new(big.Int).SetString(test.value, 10)
+			assert.Equal(t, test.expected, FormatUnits(value, test.decimals))
+		})
+	}
+}
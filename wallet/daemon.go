@@ -0,0 +1,256 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ApprovalRequest represents a decision a session needs from the operator
+type ApprovalRequest struct {
+	SessionID string
+	Account   string
+	Method    string
+	PeerMeta  PeerMeta
+	ChainId   int
+	Payload   json.RawMessage
+	// Warnings raised while validating the dApp
+	Warnings []string
+
+	reply chan bool
+}
+
+// Approver decides whether a pending request should be approved
+type Approver interface {
+	Approve(req *ApprovalRequest) bool
+}
+
+// ApproverFunc adapts a plain function to the Approver interface
+type ApproverFunc func(req *ApprovalRequest) bool
+
+// Approve calls f(req)
+func (f ApproverFunc) Approve(req *ApprovalRequest) bool {
+	return f(req)
+}
+
+// PromptApprover asks for confirmation on a terminal
+type PromptApprover struct {
+	in  io.Reader
+	out io.Writer
+}
+
+// NewPromptApprover creates an approver reading answers from in and writing prompts to out
+func NewPromptApprover(in io.Reader, out io.Writer) *PromptApprover {
+	return &PromptApprover{in: in, out: out}
+}
+
+// Approve displays the request and waits for a y/N answer
+func (p *PromptApprover) Approve(req *ApprovalRequest) bool {
+	if req.Method == MethodSessionRequest || req.Method == "" {
+		fmt.Fprintf(p.out, "\nConnection request from dApp")
+	} else {
+		fmt.Fprintf(p.out, "\n%s request from dApp", req.Method)
+	}
+	if req.SessionID != "" {
+		fmt.Fprintf(p.out, " [%s]", req.SessionID)
+	}
+	fmt.Fprintf(p.out, ":\n")
+	if req.Account != "" {
+		fmt.Fprintf(p.out, "Account: %s\n", req.Account)
+	}
+	fmt.Fprintf(p.out, "Name: %s\n", req.PeerMeta.Name)
+	fmt.Fprintf(p.out, "URL: %s\n", req.PeerMeta.URL)
+	fmt.Fprintf(p.out, "Description: %s\n", req.PeerMeta.Description)
+	if len(req.Payload) > 0 {
+		fmt.Fprintf(p.out, "Payload: %s\n", string(req.Payload))
+	}
+	for _, warning := range req.Warnings {
+		fmt.Fprintf(p.out, "WARNING: %s\n", warning)
+	}
+
+	if req.Method == MethodSessionRequest || req.Method == "" {
+		fmt.Fprint(p.out, "\nApprove connection? (y/N): ")
+	} else {
+		fmt.Fprint(p.out, "\nApprove request? (y/N): ")
+	}
+	var response string
+	fmt.Fscanln(p.in, &response)
+
+	return strings.ToLower(response) == "y"
+}
+
+// ApprovalQueue serializes approval requests coming from many sessions
+// so they can be answered one at a time by a single approver
+type ApprovalQueue struct {
+	requests chan *ApprovalRequest
+}
+
+// NewApprovalQueue creates a queue buffering up to size pending requests
+func NewApprovalQueue(size int) *ApprovalQueue {
+	return &ApprovalQueue{requests: make(chan *ApprovalRequest, size)}
+}
+
+// Submit enqueues a request and waits for its decision
+func (q *ApprovalQueue) Submit(ctx context.Context, req *ApprovalRequest) (bool, error) {
+	req.reply = make(chan bool, 1)
+
+	select {
+	case q.requests <- req:
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+
+	select {
+	case approved := <-req.reply:
+		return approved, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// Run answers queued requests with approver until ctx is cancelled
+func (q *ApprovalQueue) Run(ctx context.Context, approver Approver) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case req := <-q.requests:
+			req.reply <- approver.Approve(req)
+		}
+	}
+}
+
+// Session is a single WalletConnect session served by a Daemon
+type Session struct {
+	ID     string
+	URI    string
+	Wallet *Wallet
+
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	err    error
+	done   chan struct{}
+}
+
+// Err returns the error the session ended with, if any
+func (s *Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Done returns a channel closed when the session ends
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close stops the session
+func (s *Session) Close() {
+	s.cancel()
+}
+
+// Daemon holds many WalletConnect sessions, each running in its own goroutine
+type Daemon struct {
+	queue *ApprovalQueue
+	opts  ConnectOptions
+	out   io.Writer
+	outMu sync.Mutex
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+	wg       sync.WaitGroup
+}
+
+// NewDaemon creates a daemon vetting and deciding requests with opts,
+// routing those needing a prompt through queue and writing session logs to out
+func NewDaemon(queue *ApprovalQueue, opts ConnectOptions, out io.Writer) *Daemon {
+	return &Daemon{
+		queue:    queue,
+		opts:     opts.withDefaults(),
+		out:      out,
+		sessions: make(map[string]*Session),
+	}
+}
+
+// logf writes a session log line without interleaving with other sessions
+func (d *Daemon) logf(id string, format string, args ...interface{}) {
+	msg := strings.TrimLeft(fmt.Sprintf(format, args...), "\n")
+
+	d.outMu.Lock()
+	defer d.outMu.Unlock()
+	fmt.Fprintf(d.out, "[%s] %s", id, msg)
+}
+
+// Start connects w to the dApp behind uri in a new goroutine
+func (d *Daemon) Start(ctx context.Context, w *Wallet, uri string) (*Session, error) {
+	_, topic, _, err := ParseWalletConnectURI(uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URI: %v", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, exists := d.sessions[topic]; exists {
+		return nil, fmt.Errorf("session %s already running", topic)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	session := &Session{
+		ID:     topic,
+		URI:    uri,
+		Wallet: w,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	d.sessions[topic] = session
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer close(session.done)
+		defer cancel()
+
+		err := w.serveSession(ctx, uri, sessionConfig{
+			policy:    d.opts.Policy,
+			validator: d.opts.Validator,
+			prompt: func(req *ApprovalRequest) (bool, error) {
+				req.SessionID = session.ID
+				return d.queue.Submit(ctx, req)
+			},
+			logf: func(format string, args ...interface{}) {
+				d.logf(session.ID, format, args...)
+			},
+		})
+
+		session.mu.Lock()
+		session.err = err
+		session.mu.Unlock()
+
+		d.mu.Lock()
+		delete(d.sessions, topic)
+		d.mu.Unlock()
+	}()
+
+	return session, nil
+}
+
+// Sessions returns the sessions currently running
+func (d *Daemon) Sessions() []*Session {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	sessions := make([]*Session, 0, len(d.sessions))
+	for _, s := range d.sessions {
+		sessions = append(sessions, s)
+	}
+	return sessions
+}
+
+// Wait blocks until all sessions have ended
+func (d *Daemon) Wait() {
+	d.wg.Wait()
+}
@@ -0,0 +1,131 @@
+package wallet
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApprovalQueue_Submit(t *testing.T) {
+	queue := NewApprovalQueue(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go queue.Run(ctx, ApproverFunc(func(req *ApprovalRequest) bool {
+		return req.PeerMeta.Name == "Trusted"
+	}))
+
+	approved, err := queue.Submit(ctx, &ApprovalRequest{PeerMeta: PeerMeta{Name: "Trusted"}})
+	assert.NoError(t, err)
+	assert.True(t, approved)
+
+	approved, err = queue.Submit(ctx, &ApprovalRequest{PeerMeta: PeerMeta{Name: "Unknown"}})
+	assert.NoError(t, err)
+	assert.False(t, approved)
+}
+
+func TestApprovalQueue_SubmitCancelled(t *testing.T) {
+	queue := NewApprovalQueue(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// nobody runs the queue, so the request must give up with the context
+	_, err := queue.Submit(ctx, &ApprovalRequest{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestDaemon_MultipleSessions(t *testing.T) {
+	var mu sync.Mutex
+	approvals := make(map[string]bool)
+
+	// Create test bridge answering every subscription with a session request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var sub map[string]interface{}
+		if err := conn.ReadJSON(&sub); err != nil {
+			return
+		}
+		topic, _ := sub["topic"].(string)
+
+		conn.WriteJSON(struct {
+			Type    string         `json:"type"`
+			Payload SessionRequest `json:"payload"`
+		}{
+			Type: "pub",
+			Payload: SessionRequest{
+				PeerId:   "peer-" + topic,
+				PeerMeta: PeerMeta{Name: topic, URL: "https://" + topic + ".test"},
+				ChainId:  CHAIN_ID,
+			},
+		})
+
+		var approval map[string]interface{}
+		if err := conn.ReadJSON(&approval); err != nil {
+			return
+		}
+
+		mu.Lock()
+		approvals[approval["topic"].(string)] = true
+		mu.Unlock()
+
+		// keep the session open until the client goes away
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	queue := NewApprovalQueue(2)
+	go queue.Run(ctx, ApproverFunc(func(req *ApprovalRequest) bool { return true }))
+
+	daemon := NewDaemon(queue, ConnectOptions{}, io.Discard)
+	w := GenerateWallet()
+
+	for _, topic := range []string{"topic-a", "topic-b"} {
+		_, err := daemon.Start(ctx, w, "wc:"+topic+"@1?bridge="+wsURL)
+		assert.NoError(t, err)
+	}
+
+	_, err := daemon.Start(ctx, w, "wc:topic-a@1?bridge="+wsURL)
+	assert.Error(t, err, "duplicate session should be rejected")
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return approvals["topic-a"] && approvals["topic-b"]
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Len(t, daemon.Sessions(), 2)
+
+	// cancelling the context must stop sessions blocked on reads
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		daemon.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for sessions to stop")
+	}
+	assert.Empty(t, daemon.Sessions())
+}
@@ -0,0 +1,169 @@
+package wallet
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrSessionRejected is returned when the wallet rejects a session request
+var ErrSessionRejected = errors.New("session rejected by wallet")
+
+// DAppClient represents the dApp side of WalletConnect, it is meant for
+// driving wallets from scripts and tests
+type DAppClient struct {
+	bridge    string
+	topic     string
+	key       string
+	clientId  string
+	meta      PeerMeta
+	transport *Transport
+}
+
+// SessionApproval is the wallet answer to a session request
+type SessionApproval struct {
+	Approved bool     `json:"approved"`
+	ChainId  int      `json:"chainId"`
+	Accounts []string `json:"accounts"`
+	PeerId   string   `json:"peerId"`
+	PeerMeta PeerMeta `json:"peerMeta"`
+}
+
+// NewDAppClient connects to bridge as a dApp described by meta and
+// subscribes to a fresh handshake topic
+func NewDAppClient(ctx context.Context, bridge string, meta PeerMeta, opts TransportOptions) (*DAppClient, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	client := &DAppClient{
+		bridge:   bridge,
+		topic:    newUUID(),
+		key:      hex.EncodeToString(key),
+		clientId: newUUID(),
+		meta:     meta,
+	}
+
+	client.transport = NewTransport(bridge, opts)
+	if err := client.transport.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to bridge: %v", err)
+	}
+
+	if err := client.transport.Subscribe(client.topic); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to subscribe: %v", err)
+	}
+
+	return client, nil
+}
+
+// URI returns the wc: URI a wallet uses to pair with this dApp
+func (d *DAppClient) URI() string {
+	return fmt.Sprintf("wc:%s@1?bridge=%s&key=%s", d.topic, d.bridge, d.key)
+}
+
+// RequestSession asks the wallet for a session on chainId and waits for its answer
+func (d *DAppClient) RequestSession(ctx context.Context, chainId int) (*SessionApproval, error) {
+	err := d.publish("pub", SessionRequest{
+		PeerId:   d.clientId,
+		PeerMeta: d.meta,
+		ChainId:  chainId,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send session request: %v", err)
+	}
+
+	var approval SessionApproval
+	if err := d.read(ctx, "pub", &approval); err != nil {
+		return nil, fmt.Errorf("failed to read session approval: %v", err)
+	}
+
+	if !approval.Approved {
+		return &approval, ErrSessionRejected
+	}
+	return &approval, nil
+}
+
+// SendTransaction asks the wallet to send tx and returns its hash
+func (d *DAppClient) SendTransaction(ctx context.Context, tx TransactionRequest) (string, error) {
+	return d.request(ctx, MethodSendTransaction, tx)
+}
+
+// PersonalSign asks the wallet to sign message and returns the signature
+func (d *DAppClient) PersonalSign(ctx context.Context, message string) (string, error) {
+	return d.request(ctx, "personal_sign", message)
+}
+
+// Close closes the connection to the bridge
+func (d *DAppClient) Close() error {
+	return d.transport.Close()
+}
+
+// request sends a request and waits for the wallet response
+func (d *DAppClient) request(ctx context.Context, method string, params interface{}) (string, error) {
+	if err := d.publish(method, params); err != nil {
+		return "", fmt.Errorf("failed to send %s request: %v", method, err)
+	}
+
+	var response RequestResponse
+	if err := d.read(ctx, "response", &response); err != nil {
+		return "", fmt.Errorf("failed to read %s response: %v", method, err)
+	}
+
+	if response.Method != method {
+		return "", fmt.Errorf("unexpected %s response to %s request", response.Method, method)
+	}
+	if response.Error != "" {
+		return "", errors.New(response.Error)
+	}
+	return response.Result, nil
+}
+
+func (d *DAppClient) publish(typ string, payload interface{}) error {
+	msg := struct {
+		Topic   string      `json:"topic"`
+		Type    string      `json:"type"`
+		Payload interface{} `json:"payload"`
+	}{
+		Topic:   d.topic,
+		Type:    typ,
+		Payload: payload,
+	}
+
+	return d.transport.WriteJSON(msg)
+}
+
+// read waits for the next message of type typ and decodes its payload into v
+func (d *DAppClient) read(ctx context.Context, typ string, v interface{}) error {
+	for {
+		var msg struct {
+			Type    string          `json:"type"`
+			Payload json.RawMessage `json:"payload"`
+		}
+
+		if err := d.transport.ReadJSON(ctx, &msg); err != nil {
+			return err
+		}
+
+		if msg.Type == typ {
+			return json.Unmarshal(msg.Payload, v)
+		}
+	}
+}
+
+// newUUID returns a random version 4 UUID
+func newUUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
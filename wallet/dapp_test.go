@@ -0,0 +1,92 @@
+package wallet
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/galihrivanto/omonOmon/bridge"
+	"github.com/stretchr/testify/assert"
+)
+
+// pairOverBridge runs a daemon session for w against a dApp client, both
+// talking through an in-process bridge
+func pairOverBridge(t *testing.T, ctx context.Context, w *Wallet, policy *Policy) *DAppClient {
+	server := httptest.NewServer(bridge.NewServer(bridge.Options{}))
+	t.Cleanup(server.Close)
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	dapp, err := NewDAppClient(ctx, wsURL, PeerMeta{
+		Name: "Test dApp",
+		URL:  "https://dapp.test",
+	}, TransportOptions{})
+	if err != nil {
+		t.Fatalf("failed to create dApp client: %v", err)
+	}
+	t.Cleanup(func() { dapp.Close() })
+
+	queue := NewApprovalQueue(1)
+	go queue.Run(ctx, ApproverFunc(func(req *ApprovalRequest) bool { return false }))
+
+	daemon := NewDaemon(queue, ConnectOptions{Policy: policy}, io.Discard)
+	_, err = daemon.Start(ctx, w, dapp.URI())
+	assert.NoError(t, err)
+
+	return dapp
+}
+
+func TestDAppClient_EndToEnd(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	policy := &Policy{Rules: []PolicyRule{
+		{Name: "test", Action: PolicyApprove, Domains: []string{"dapp.test"}},
+	}}
+	w := GenerateWallet()
+	dapp := pairOverBridge(t, ctx, w, policy)
+
+	approval, err := dapp.RequestSession(ctx, CHAIN_ID)
+	assert.NoError(t, err)
+	assert.True(t, approval.Approved)
+	assert.Equal(t, CHAIN_ID, approval.ChainId)
+	assert.Equal(t, []string{w.Address}, approval.Accounts)
+
+	signature, err := dapp.PersonalSign(ctx, "hello")
+	assert.NoError(t, err)
+
+	sig, err := hexutil.Decode(signature)
+	assert.NoError(t, err)
+	sig[64] -= 27
+	hash := crypto.Keccak256([]byte("\x19Ethereum Signed Message:\n5hello"))
+	pub, err := crypto.SigToPub(hash, sig)
+	assert.NoError(t, err)
+	assert.Equal(t, w.Address, crypto.PubkeyToAddress(*pub).Hex())
+}
+
+func TestDAppClient_Rejected(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	policy := &Policy{Rules: []PolicyRule{
+		{Name: "test", Action: PolicyReject, Domains: []string{"dapp.test"}},
+	}}
+	dapp := pairOverBridge(t, ctx, GenerateWallet(), policy)
+
+	_, err := dapp.RequestSession(ctx, CHAIN_ID)
+	assert.ErrorIs(t, err, ErrSessionRejected)
+}
+
+func TestDAppClient_URI(t *testing.T) {
+	dapp := &DAppClient{bridge: "ws://127.0.0.1:5001", topic: newUUID(), key: "abcd"}
+
+	bridge, topic, version, err := ParseWalletConnectURI(dapp.URI())
+	assert.NoError(t, err)
+	assert.Equal(t, "ws://127.0.0.1:5001", bridge)
+	assert.Equal(t, dapp.topic, topic)
+	assert.Equal(t, "1", version)
+}
@@ -0,0 +1,219 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLookalikeDistance is the largest edit distance at which a domain is
+// considered a lookalike of a known dApp
+const maxLookalikeDistance = 2
+
+// knownDApps are well-known dApp domains, lookalikes of them are flagged
+// along with lookalikes of the allowlist
+var knownDApps = []string{
+	"*.monad.xyz",
+	"*.monadexplorer.com",
+	"*.uniswap.org",
+	"*.pancakeswap.finance",
+	"*.sushi.com",
+	"*.curve.fi",
+	"*.aave.com",
+	"*.1inch.io",
+	"*.opensea.io",
+	"*.metamask.io",
+	"*.walletconnect.com",
+	"*.kuru.io",
+	"*.ambient.finance",
+}
+
+// DomainList is an allowlist and denylist of dApp domains, entries may be
+// exact domains or "*.example.com" patterns
+type DomainList struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// LoadDomainList loads a JSON domain list file
+func LoadDomainList(path string) (*DomainList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var list DomainList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid domain list: %v", err)
+	}
+
+	return &list, nil
+}
+
+// PeerValidator vets the metadata of a dApp before its session is approved
+type PeerValidator struct {
+	Domains *DomainList
+	ChainId int
+}
+
+// NewPeerValidator creates a validator for the configured network,
+// a nil domain list only runs the heuristics
+func NewPeerValidator(domains *DomainList) *PeerValidator {
+	if domains == nil {
+		domains = &DomainList{}
+	}
+	return &PeerValidator{Domains: domains, ChainId: CHAIN_ID}
+}
+
+// PeerCheck is the outcome of validating a session request
+type PeerCheck struct {
+	// Blocked is set when the dApp is denylisted
+	Blocked bool
+	// Trusted is set when the dApp is allowlisted
+	Trusted  bool
+	Warnings []string
+}
+
+// Validate checks the dApp domain and the requested chain
+func (v *PeerValidator) Validate(req *SessionRequest) PeerCheck {
+	var check PeerCheck
+
+	if req.ChainId != v.ChainId {
+		check.Warnings = append(check.Warnings,
+			fmt.Sprintf("requested chain %d does not match configured network %d", req.ChainId, v.ChainId))
+	}
+
+	u, err := url.Parse(req.PeerMeta.URL)
+	if err != nil || u.Hostname() == "" {
+		check.Warnings = append(check.Warnings, fmt.Sprintf("dApp URL %q is invalid", req.PeerMeta.URL))
+		return check
+	}
+	domain := strings.ToLower(u.Hostname())
+
+	if matchDomain(v.Domains.Deny, domain) {
+		check.Blocked = true
+		check.Warnings = append(check.Warnings, fmt.Sprintf("domain %s is denylisted", domain))
+		return check
+	}
+
+	if u.Scheme != "https" {
+		check.Warnings = append(check.Warnings, fmt.Sprintf("dApp URL uses insecure scheme %q", u.Scheme))
+	}
+
+	if matchDomain(v.Domains.Allow, domain) {
+		check.Trusted = true
+		return check
+	}
+
+	if isPunycode(domain) {
+		check.Warnings = append(check.Warnings, fmt.Sprintf("domain %s uses punycode or non-ASCII characters", domain))
+	}
+
+	if known := v.lookalikeOf(domain); known != "" {
+		check.Warnings = append(check.Warnings, fmt.Sprintf("domain %s looks like known dApp %s", domain, known))
+	}
+
+	return check
+}
+
+// lookalikeOf returns the allowlisted or well-known domain that domain
+// imitates, if any
+func (v *PeerValidator) lookalikeOf(domain string) string {
+	if matchDomain(knownDApps, domain) {
+		return ""
+	}
+
+	for _, known := range slices.Concat(v.Domains.Allow, knownDApps) {
+		known = strings.ToLower(known)
+
+		// a wildcard entry is compared with as many labels of domain
+		candidate := domain
+		if suffix, ok := strings.CutPrefix(known, "*."); ok {
+			known = suffix
+			candidate = lastLabels(domain, strings.Count(known, ".")+1)
+		}
+
+		// known domain used as a subdomain, e.g. app.example.com.evil.io
+		if strings.HasPrefix(domain, known+".") || strings.Contains(domain, "."+known+".") {
+			return known
+		}
+
+		if normalizeConfusables(candidate) == normalizeConfusables(known) {
+			return known
+		}
+
+		// short domains are too close to each other to compare
+		if d := levenshtein(candidate, known); d <= maxLookalikeDistance && d*5 <= len(known) {
+			return known
+		}
+	}
+
+	return ""
+}
+
+// lastLabels returns the last n labels of domain
+func lastLabels(domain string, n int) string {
+	labels := strings.Split(domain, ".")
+	if len(labels) <= n {
+		return domain
+	}
+	return strings.Join(labels[len(labels)-n:], ".")
+}
+
+// isPunycode reports whether domain has IDN labels
+func isPunycode(domain string) bool {
+	if utf8.RuneCountInString(domain) != len(domain) {
+		return true
+	}
+
+	for _, label := range strings.Split(domain, ".") {
+		if strings.HasPrefix(label, "xn--") {
+			return true
+		}
+	}
+	return false
+}
+
+// confusables maps character sequences commonly used to imitate others
+var confusables = strings.NewReplacer(
+	"rn", "m",
+	"vv", "w",
+	"0", "o",
+	"1", "l",
+	"3", "e",
+	"5", "s",
+	"-", "",
+)
+
+func normalizeConfusables(domain string) string {
+	return confusables.Replace(domain)
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,144 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerValidator_Validate(t *testing.T) {
+	validator := NewPeerValidator(&DomainList{
+		Allow: []string{"app.uniswap.org", "*.monad.xyz", "wormhole.com"},
+		Deny:  []string{"*.drainer.io"},
+	})
+
+	tests := []struct {
+		name         string
+		url          string
+		chainId      int
+		wantBlocked  bool
+		wantTrusted  bool
+		wantWarnings []string
+	}{
+		{
+			name:        "Allowlisted domain",
+			url:         "https://app.uniswap.org",
+			chainId:     CHAIN_ID,
+			wantTrusted: true,
+		},
+		{
+			name:        "Allowlisted wildcard",
+			url:         "https://testnet.monad.xyz/faucet",
+			chainId:     CHAIN_ID,
+			wantTrusted: true,
+		},
+		{
+			name:         "Denylisted domain",
+			url:          "https://claim.drainer.io",
+			chainId:      CHAIN_ID,
+			wantBlocked:  true,
+			wantWarnings: []string{"domain claim.drainer.io is denylisted"},
+		},
+		{
+			name:         "Edit distance lookalike",
+			url:          "https://app.unlswap.org",
+			chainId:      CHAIN_ID,
+			wantWarnings: []string{"domain app.unlswap.org looks like known dApp app.uniswap.org"},
+		},
+		{
+			name:         "Confusable lookalike",
+			url:          "https://vvorrnho1e.com",
+			chainId:      CHAIN_ID,
+			wantWarnings: []string{"domain vvorrnho1e.com looks like known dApp wormhole.com"},
+		},
+		{
+			name:         "Known domain as subdomain",
+			url:          "https://app.uniswap.org.claims.io",
+			chainId:      CHAIN_ID,
+			wantWarnings: []string{"domain app.uniswap.org.claims.io looks like known dApp app.uniswap.org"},
+		},
+		{
+			name:    "Punycode domain",
+			url:     "https://xn--uniswp-6ya.org",
+			chainId: CHAIN_ID,
+			wantWarnings: []string{
+				"domain xn--uniswp-6ya.org uses punycode or non-ASCII characters",
+			},
+		},
+		{
+			name:    "Chain mismatch and insecure scheme",
+			url:     "http://unknown.test",
+			chainId: 1,
+			wantWarnings: []string{
+				"requested chain 1 does not match configured network 210425",
+				`dApp URL uses insecure scheme "http"`,
+			},
+		},
+		{
+			name:         "Missing URL",
+			url:          "",
+			chainId:      CHAIN_ID,
+			wantWarnings: []string{`dApp URL "" is invalid`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := validator.Validate(&SessionRequest{
+				PeerMeta: PeerMeta{URL: tt.url},
+				ChainId:  tt.chainId,
+			})
+			assert.Equal(t, tt.wantBlocked, check.Blocked)
+			assert.Equal(t, tt.wantTrusted, check.Trusted)
+			assert.Equal(t, tt.wantWarnings, check.Warnings)
+		})
+	}
+}
+
+func TestPeerValidator_KnownDApps(t *testing.T) {
+	// without an allowlist the well-known dApps are still imitated
+	validator := NewPeerValidator(&DomainList{Deny: []string{"*.drainer.io"}})
+
+	tests := []struct {
+		name         string
+		url          string
+		wantWarnings []string
+	}{
+		{
+			name: "Well-known domain",
+			url:  "https://app.uniswap.org",
+		},
+		{
+			name:         "Edit distance lookalike",
+			url:          "https://app.unlswap.org",
+			wantWarnings: []string{"domain app.unlswap.org looks like known dApp uniswap.org"},
+		},
+		{
+			name:         "Known domain as subdomain",
+			url:          "https://testnet.monad.xyz.claims.io",
+			wantWarnings: []string{"domain testnet.monad.xyz.claims.io looks like known dApp monad.xyz"},
+		},
+		{
+			name: "Unrelated domain",
+			url:  "https://example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := validator.Validate(&SessionRequest{
+				PeerMeta: PeerMeta{URL: tt.url},
+				ChainId:  CHAIN_ID,
+			})
+			assert.False(t, check.Trusted)
+			assert.Equal(t, tt.wantWarnings, check.Warnings)
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("monad.xyz", "monad.xyz"))
+	assert.Equal(t, 1, levenshtein("monad.xyz", "m0nad.xyz"))
+	assert.Equal(t, 2, levenshtein("monad.xyz", "monadd.xy"))
+	assert.Equal(t, 3, levenshtein("", "abc"))
+}
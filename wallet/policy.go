@@ -0,0 +1,256 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	// MethodSessionRequest is the method used for session approval requests
+	MethodSessionRequest = "wc_sessionRequest"
+	// MethodSendTransaction is the method used for transaction requests
+	MethodSendTransaction = "eth_sendTransaction"
+	// MethodPersonalSign is the method used for message signing requests
+	MethodPersonalSign = "personal_sign"
+)
+
+// PolicyAction is what a policy does with a matching request
+type PolicyAction string
+
+const (
+	PolicyApprove PolicyAction = "approve"
+	PolicyReject  PolicyAction = "reject"
+	PolicyPrompt  PolicyAction = "prompt"
+)
+
+// PolicyRule matches requests and decides them. Every non-empty criterion
+// must match. Rules with transaction criteria (contracts, selectors,
+// maxValue, maxGas) only match eth_sendTransaction requests.
+type PolicyRule struct {
+	Name      string       `json:"name"`
+	Action    PolicyAction `json:"action"`
+	Domains   []string     `json:"domains"`
+	Methods   []string     `json:"methods"`
+	Contracts []string     `json:"contracts"`
+	Selectors []string     `json:"selectors"`
+	// MaxValue is the largest transaction value allowed, in MON
+	MaxValue string `json:"maxValue"`
+	// MaxGas is the largest gas limit allowed
+	MaxGas uint64 `json:"maxGas"`
+
+	maxValue *big.Int
+}
+
+// Policy decides approval requests without asking the operator
+type Policy struct {
+	Rules []PolicyRule `json:"rules"`
+}
+
+// PolicyDecision is the outcome of evaluating a request against a policy
+type PolicyDecision struct {
+	Action PolicyAction
+	Rule   string
+}
+
+// DefaultPolicy signs every request of a WalletConnect session, once the
+// operator approved the session
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Rules: []PolicyRule{
+			{
+				Name:    "default",
+				Action:  PolicyApprove,
+				Methods: []string{MethodSendTransaction, "eth_sign", "personal_sign"},
+			},
+		},
+	}
+}
+
+// LoadPolicy loads a JSON policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("invalid policy file: %v", err)
+	}
+
+	if err := policy.compile(); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// compile validates the rules and parses their limits
+func (p *Policy) compile() error {
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule #%d", i+1)
+		}
+
+		switch rule.Action {
+		case PolicyApprove, PolicyReject, PolicyPrompt:
+		default:
+			return fmt.Errorf("%s: invalid action %q", rule.Name, rule.Action)
+		}
+
+		for _, contract := range rule.Contracts {
+			if !common.IsHexAddress(contract) {
+				return fmt.Errorf("%s: invalid contract address %q", rule.Name, contract)
+			}
+		}
+
+		for _, selector := range rule.Selectors {
+			if b, err := hexutil.Decode(selector); err != nil || len(b) != 4 {
+				return fmt.Errorf("%s: invalid function selector %q", rule.Name, selector)
+			}
+		}
+
+		if rule.MaxValue != "" {
+			value, err := ParseMON(rule.MaxValue)
+			if err != nil {
+				return fmt.Errorf("%s: invalid max value %q", rule.Name, rule.MaxValue)
+			}
+			rule.maxValue = value
+		}
+	}
+
+	return nil
+}
+
+// Evaluate returns the decision of the first matching rule,
+// unmatched requests are escalated to a prompt
+func (p *Policy) Evaluate(req *ApprovalRequest) PolicyDecision {
+	if p != nil {
+		for i := range p.Rules {
+			if p.Rules[i].matches(req) {
+				return PolicyDecision{Action: p.Rules[i].Action, Rule: p.Rules[i].Name}
+			}
+		}
+	}
+
+	return PolicyDecision{Action: PolicyPrompt}
+}
+
+func (r *PolicyRule) matches(req *ApprovalRequest) bool {
+	if len(r.Domains) > 0 && !matchDomain(r.Domains, peerDomain(req.PeerMeta)) {
+		return false
+	}
+
+	if len(r.Methods) > 0 && !containsFold(r.Methods, req.Method) {
+		return false
+	}
+
+	if !r.hasTransactionCriteria() {
+		return true
+	}
+
+	if req.Method != MethodSendTransaction {
+		return false
+	}
+
+	var tx TransactionRequest
+	if err := json.Unmarshal(req.Payload, &tx); err != nil {
+		return false
+	}
+
+	if len(r.Contracts) > 0 && !containsFold(r.Contracts, tx.To) {
+		return false
+	}
+
+	if len(r.Selectors) > 0 {
+		data := strings.TrimPrefix(tx.Data, "0x")
+		if len(data) < 8 || !containsFold(r.Selectors, "0x"+data[:8]) {
+			return false
+		}
+	}
+
+	if r.maxValue != nil {
+		value, err := parseQuantity(tx.Value)
+		if err != nil || value.Cmp(r.maxValue) > 0 {
+			return false
+		}
+	}
+
+	if r.MaxGas > 0 {
+		// without an explicit gas limit the default transfer gas is used
+		if tx.GasLimit != "" {
+			gas, err := hexutil.DecodeUint64(tx.GasLimit)
+			if err != nil || gas > r.MaxGas {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (r *PolicyRule) hasTransactionCriteria() bool {
+	return len(r.Contracts) > 0 || len(r.Selectors) > 0 || r.maxValue != nil || r.MaxGas > 0
+}
+
+// peerDomain returns the lowercase host name of the dApp URL
+func peerDomain(meta PeerMeta) string {
+	u, err := url.Parse(meta.URL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// matchDomain reports whether domain matches one of patterns,
+// a pattern "*.example.com" matches example.com and all its subdomains
+func matchDomain(patterns []string, domain string) bool {
+	if domain == "" {
+		return false
+	}
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if domain == suffix || strings.HasSuffix(domain, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if domain == pattern {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseQuantity parses a hex encoded quantity, empty means zero
+func parseQuantity(s string) (*big.Int, error) {
+	if s == "" {
+		return new(big.Int), nil
+	}
+
+	value, ok := new(big.Int).SetString(strings.TrimPrefix(s, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid quantity %q", s)
+	}
+	return value, nil
+}
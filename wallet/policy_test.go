@@ -0,0 +1,135 @@
+package wallet
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPolicy = `{
+	"rules": [
+		{
+			"name": "block-phish",
+			"action": "reject",
+			"domains": ["*.phish.test"]
+		},
+		{
+			"name": "trusted-sessions",
+			"action": "approve",
+			"domains": ["app.trusted.test"],
+			"methods": ["wc_sessionRequest"]
+		},
+		{
+			"name": "small-swaps",
+			"action": "approve",
+			"domains": ["app.trusted.test"],
+			"contracts": ["0x742d35Cc6634C0532925a3b844Bc454e4438f44e"],
+			"selectors": ["0x38ed1739"],
+			"maxValue": "0.5",
+			"maxGas": 300000
+		}
+	]
+}`
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	assert.NoError(t, os.WriteFile(path, []byte(testPolicy), 0644))
+
+	policy, err := LoadPolicy(path)
+	assert.NoError(t, err)
+	assert.Len(t, policy.Rules, 3)
+
+	invalid := []string{
+		`{"rules": [{"action": "maybe"}]}`,
+		`{"rules": [{"action": "approve", "contracts": ["0x123"]}]}`,
+		`{"rules": [{"action": "approve", "selectors": ["0x1234"]}]}`,
+		`{"rules": [{"action": "approve", "maxValue": "lots"}]}`,
+	}
+	for _, data := range invalid {
+		assert.NoError(t, os.WriteFile(path, []byte(data), 0644))
+		_, err := LoadPolicy(path)
+		assert.Error(t, err, data)
+	}
+}
+
+func TestPolicy_Evaluate(t *testing.T) {
+	var policy Policy
+	assert.NoError(t, json.Unmarshal([]byte(testPolicy), &policy))
+	assert.NoError(t, policy.compile())
+
+	trusted := PeerMeta{URL: "https://app.trusted.test/swap"}
+	swap := func(value string, gas string, data string) json.RawMessage {
+		payload, _ := json.Marshal(TransactionRequest{
+			To:       "0x742d35cc6634c0532925a3b844bc454e4438f44e",
+			Value:    value,
+			GasLimit: gas,
+			Data:     data,
+		})
+		return payload
+	}
+
+	tests := []struct {
+		name       string
+		req        ApprovalRequest
+		wantAction PolicyAction
+		wantRule   string
+	}{
+		{
+			name:       "Denied domain",
+			req:        ApprovalRequest{Method: MethodSessionRequest, PeerMeta: PeerMeta{URL: "https://wallet.phish.test"}},
+			wantAction: PolicyReject,
+			wantRule:   "block-phish",
+		},
+		{
+			name:       "Trusted session",
+			req:        ApprovalRequest{Method: MethodSessionRequest, PeerMeta: trusted},
+			wantAction: PolicyApprove,
+			wantRule:   "trusted-sessions",
+		},
+		{
+			name:       "Swap within limits",
+			req:        ApprovalRequest{Method: MethodSendTransaction, PeerMeta: trusted, Payload: swap("0x6f05b59d3b20000", "0x30d40", "0x38ed1739abcd")},
+			wantAction: PolicyApprove,
+			wantRule:   "small-swaps",
+		},
+		{
+			name:       "Swap over max value",
+			req:        ApprovalRequest{Method: MethodSendTransaction, PeerMeta: trusted, Payload: swap("0xde0b6b3a7640000", "0x30d40", "0x38ed1739abcd")},
+			wantAction: PolicyPrompt,
+		},
+		{
+			name:       "Swap over max gas",
+			req:        ApprovalRequest{Method: MethodSendTransaction, PeerMeta: trusted, Payload: swap("0x0", "0x1e8480", "0x38ed1739abcd")},
+			wantAction: PolicyPrompt,
+		},
+		{
+			name:       "Unknown selector",
+			req:        ApprovalRequest{Method: MethodSendTransaction, PeerMeta: trusted, Payload: swap("0x0", "", "0x095ea7b3abcd")},
+			wantAction: PolicyPrompt,
+		},
+		{
+			name:       "Unknown domain",
+			req:        ApprovalRequest{Method: "personal_sign", PeerMeta: PeerMeta{URL: "https://other.test"}},
+			wantAction: PolicyPrompt,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := policy.Evaluate(&tt.req)
+			assert.Equal(t, tt.wantAction, decision.Action)
+			assert.Equal(t, tt.wantRule, decision.Rule)
+		})
+	}
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	policy := DefaultPolicy()
+
+	assert.Equal(t, PolicyPrompt, policy.Evaluate(&ApprovalRequest{Method: MethodSessionRequest}).Action)
+	assert.Equal(t, PolicyApprove, policy.Evaluate(&ApprovalRequest{Method: MethodSendTransaction}).Action)
+	assert.Equal(t, PolicyApprove, policy.Evaluate(&ApprovalRequest{Method: "personal_sign"}).Action)
+}
@@ -0,0 +1,369 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// EIP-1193 provider error codes
+const (
+	ProviderUserRejected      = 4001
+	ProviderUnauthorized      = 4100
+	ProviderUnsupported       = 4200
+	ProviderUnrecognizedChain = 4902
+	ProviderInvalidParams     = -32602
+	ProviderInternal          = -32603
+)
+
+// ProviderError is an EIP-1193 error, it is handed to the page as is
+type ProviderError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+func providerError(code int, format string, args ...interface{}) *ProviderError {
+	return &ProviderError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// ProviderRequest is a request() call of a dApp
+type ProviderRequest struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	// Origin is the origin of the calling page, e.g. https://app.example.
+	// It is set by the caller, never read from the request.
+	Origin string `json:"-"`
+}
+
+// ApproveFunc decides whether a dApp request may be signed
+type ApproveFunc func(req *ApprovalRequest) (bool, error)
+
+// PolicyApprover decides requests with policy and asks prompt about the
+// requests the policy leaves open, all of them when policy is nil.
+// Decisions are logged to out.
+func PolicyApprover(policy *Policy, prompt Approver, out io.Writer) ApproveFunc {
+	// pages send requests concurrently, prompts are asked one at a time
+	var mu sync.Mutex
+	cfg := sessionConfig{
+		policy: policy,
+		prompt: func(req *ApprovalRequest) (bool, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return prompt.Approve(req), nil
+		},
+		logf: func(format string, args ...interface{}) {
+			fmt.Fprintf(out, format, args...)
+		},
+	}
+	return cfg.approve
+}
+
+// readMethods are forwarded to the RPC node as they are
+var readMethods = map[string]bool{
+	"eth_blockNumber":           true,
+	"eth_call":                  true,
+	"eth_estimateGas":           true,
+	"eth_feeHistory":            true,
+	"eth_gasPrice":              true,
+	"eth_getBalance":            true,
+	"eth_getBlockByHash":        true,
+	"eth_getBlockByNumber":      true,
+	"eth_getCode":               true,
+	"eth_getLogs":               true,
+	"eth_getStorageAt":          true,
+	"eth_getTransactionByHash":  true,
+	"eth_getTransactionCount":   true,
+	"eth_getTransactionReceipt": true,
+	"eth_maxPriorityFeePerGas":  true,
+}
+
+// Provider answers the EIP-1193 requests of a dApp with the wallet, it is
+// the Go side of an injected window.ethereum
+type Provider struct {
+	// RPCURL is the node read requests are forwarded to, defaults to RPC_URL
+	RPCURL string
+
+	wallet  *Wallet
+	approve ApproveFunc
+
+	mu        sync.Mutex
+	connected bool
+	rpc       *rpc.Client
+}
+
+// NewProvider creates a provider for w on the Monad testnet. approve
+// decides the transactions and signatures asked for, nil asks about all
+// of them on the terminal.
+func NewProvider(w *Wallet, approve ApproveFunc) *Provider {
+	if approve == nil {
+		approve = PolicyApprover(nil, NewPromptApprover(os.Stdin, os.Stdout), os.Stdout)
+	}
+	return &Provider{RPCURL: RPC_URL, wallet: w, approve: approve}
+}
+
+// Request handles a single request() call of the dApp
+func (p *Provider) Request(ctx context.Context, req ProviderRequest) (interface{}, error) {
+	switch req.Method {
+	case "eth_requestAccounts":
+		p.mu.Lock()
+		p.connected = true
+		p.mu.Unlock()
+		return []string{p.wallet.Address}, nil
+	case "eth_accounts":
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if !p.connected {
+			return []string{}, nil
+		}
+		return []string{p.wallet.Address}, nil
+	case "eth_chainId":
+		return hexutil.EncodeUint64(CHAIN_ID), nil
+	case "net_version":
+		return strconv.Itoa(CHAIN_ID), nil
+	case "wallet_switchEthereumChain":
+		return p.switchChain(req.Params)
+	case MethodPersonalSign:
+		return p.personalSign(req)
+	case "eth_signTypedData_v4":
+		return p.signTypedData(req)
+	case MethodSendTransaction:
+		return p.sendTransaction(ctx, req)
+	}
+
+	if readMethods[req.Method] {
+		return p.forward(ctx, req)
+	}
+	return nil, providerError(ProviderUnsupported, "method %s is not supported", req.Method)
+}
+
+// Close closes the connection to the RPC node
+func (p *Provider) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.rpc != nil {
+		p.rpc.Close()
+		p.rpc = nil
+	}
+}
+
+// switchChain accepts switching to the chain the wallet is on
+func (p *Provider) switchChain(params json.RawMessage) (interface{}, error) {
+	var args []struct {
+		ChainId string `json:"chainId"`
+	}
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, providerError(ProviderInvalidParams, "invalid chain")
+	}
+
+	chainId, err := hexutil.DecodeUint64(args[0].ChainId)
+	if err != nil || chainId != CHAIN_ID {
+		return nil, providerError(ProviderUnrecognizedChain, "unrecognized chain %s", args[0].ChainId)
+	}
+	return nil, nil
+}
+
+// personalSign signs a personal_sign message. Params are [message, address],
+// some dApps send them the other way round.
+func (p *Provider) personalSign(req ProviderRequest) (interface{}, error) {
+	var args []string
+	if err := json.Unmarshal(req.Params, &args); err != nil || len(args) < 2 {
+		return nil, providerError(ProviderInvalidParams, "expected message and address")
+	}
+
+	message, address := args[0], args[1]
+	if common.IsHexAddress(message) && !common.IsHexAddress(address) {
+		message, address = address, message
+	}
+	if err := p.authorize(address); err != nil {
+		return nil, err
+	}
+
+	payload, _ := json.Marshal(message)
+	if err := p.confirm(req, payload); err != nil {
+		return nil, err
+	}
+	return p.wallet.PersonalSign(message)
+}
+
+// signTypedData signs EIP-712 typed data, params are [address, data]
+// with data as a JSON string or object
+func (p *Provider) signTypedData(req ProviderRequest) (interface{}, error) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(req.Params, &args); err != nil || len(args) < 2 {
+		return nil, providerError(ProviderInvalidParams, "expected address and typed data")
+	}
+
+	var address string
+	if err := json.Unmarshal(args[0], &address); err != nil {
+		return nil, providerError(ProviderInvalidParams, "invalid address")
+	}
+	if err := p.authorize(address); err != nil {
+		return nil, err
+	}
+
+	data := []byte(args[1])
+	var text string
+	if json.Unmarshal(args[1], &text) == nil {
+		data = []byte(text)
+	}
+
+	if err := p.confirm(req, data); err != nil {
+		return nil, err
+	}
+
+	signature, err := p.wallet.SignTypedDataV4(data)
+	if err != nil {
+		return nil, providerError(ProviderInvalidParams, "%v", err)
+	}
+	return signature, nil
+}
+
+// sendTransaction signs and sends a transaction, estimating its gas when
+// the dApp did not
+func (p *Provider) sendTransaction(ctx context.Context, req ProviderRequest) (interface{}, error) {
+	var args []TransactionRequest
+	if err := json.Unmarshal(req.Params, &args); err != nil || len(args) == 0 {
+		return nil, providerError(ProviderInvalidParams, "expected a transaction")
+	}
+	tx := args[0]
+
+	if tx.From == "" {
+		tx.From = p.wallet.Address
+	}
+	if err := p.authorize(tx.From); err != nil {
+		return nil, err
+	}
+	for name, value := range map[string]string{"nonce": tx.Nonce, "gas": tx.GasLimit} {
+		if value == "" {
+			continue
+		}
+		if _, err := hexutil.DecodeUint64(value); err != nil {
+			return nil, providerError(ProviderInvalidParams, "invalid %s %q", name, value)
+		}
+	}
+
+	if tx.GasLimit == "" {
+		gas, err := p.estimateGas(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+		tx.GasLimit = gas
+	}
+
+	payload, _ := json.Marshal(tx)
+	if err := p.confirm(req, payload); err != nil {
+		return nil, err
+	}
+
+	hash, err := p.wallet.SendTransactionFromRequest(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	return hash, nil
+}
+
+func (p *Provider) estimateGas(ctx context.Context, tx TransactionRequest) (string, error) {
+	call := map[string]string{"from": tx.From, "to": tx.To}
+	if tx.Value != "" {
+		call["value"] = tx.Value
+	}
+	if tx.Data != "" {
+		call["data"] = tx.Data
+	}
+
+	params, _ := json.Marshal([]interface{}{call})
+	result, err := p.forward(ctx, ProviderRequest{Method: "eth_estimateGas", Params: params})
+	if err != nil {
+		return "", fmt.Errorf("failed to estimate gas: %v", err)
+	}
+
+	var gas string
+	if err := json.Unmarshal(result.(json.RawMessage), &gas); err != nil {
+		return "", fmt.Errorf("failed to estimate gas: %v", err)
+	}
+	return gas, nil
+}
+
+// authorize checks that the dApp asks for the wallet account
+func (p *Provider) authorize(address string) error {
+	if !strings.EqualFold(address, p.wallet.Address) {
+		return providerError(ProviderUnauthorized, "account %s is not available", address)
+	}
+	return nil
+}
+
+// confirm asks the approver whether req may be signed
+func (p *Provider) confirm(req ProviderRequest, payload json.RawMessage) error {
+	approved, err := p.approve(&ApprovalRequest{
+		Account:  p.wallet.Address,
+		Method:   req.Method,
+		PeerMeta: PeerMeta{URL: req.Origin},
+		ChainId:  CHAIN_ID,
+		Payload:  payload,
+	})
+	if err != nil {
+		return err
+	}
+	if !approved {
+		return providerError(ProviderUserRejected, "request rejected by user")
+	}
+	return nil
+}
+
+// forward sends a read request to the RPC node
+func (p *Provider) forward(ctx context.Context, req ProviderRequest) (interface{}, error) {
+	client, err := p.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var params []interface{}
+	if len(req.Params) > 0 && string(req.Params) != "null" {
+		var raw []json.RawMessage
+		if err := json.Unmarshal(req.Params, &raw); err != nil {
+			return nil, providerError(ProviderInvalidParams, "params must be an array")
+		}
+		for _, param := range raw {
+			params = append(params, param)
+		}
+	}
+
+	var result json.RawMessage
+	if err := client.CallContext(ctx, &result, req.Method, params...); err != nil {
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) {
+			return nil, &ProviderError{Code: rpcErr.ErrorCode(), Message: rpcErr.Error()}
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (p *Provider) client(ctx context.Context) (*rpc.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.rpc == nil {
+		client, err := rpc.DialContext(ctx, p.RPCURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to %s: %v", p.RPCURL, err)
+		}
+		p.rpc = client
+	}
+	return p.rpc, nil
+}
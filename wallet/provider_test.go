@@ -0,0 +1,208 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testProviderKey     = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	testProviderAddress = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+)
+
+func testProvider() *Provider {
+	return NewProvider(&Wallet{Address: testProviderAddress, PrivateKey: testProviderKey}, func(*ApprovalRequest) (bool, error) {
+		return true, nil
+	})
+}
+
+func TestProviderAccounts(t *testing.T) {
+	provider := testProvider()
+
+	accounts, err := provider.Request(context.Background(), ProviderRequest{Method: "eth_accounts"})
+	assert.NoError(t, err)
+	assert.Empty(t, accounts)
+
+	accounts, err = provider.Request(context.Background(), ProviderRequest{Method: "eth_requestAccounts"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{testProviderAddress}, accounts)
+
+	accounts, err = provider.Request(context.Background(), ProviderRequest{Method: "eth_accounts"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{testProviderAddress}, accounts)
+
+	chainId, err := provider.Request(context.Background(), ProviderRequest{Method: "eth_chainId"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0x335f9", chainId)
+}
+
+func TestProviderSwitchChain(t *testing.T) {
+	provider := testProvider()
+
+	_, err := provider.Request(context.Background(), ProviderRequest{Method: "wallet_switchEthereumChain", Params: json.RawMessage(`[{"chainId": "0x335f9"}]`)})
+	assert.NoError(t, err)
+
+	_, err = provider.Request(context.Background(), ProviderRequest{Method: "wallet_switchEthereumChain", Params: json.RawMessage(`[{"chainId": "0x1"}]`)})
+	assert.Equal(t, ProviderUnrecognizedChain, err.(*ProviderError).Code)
+}
+
+func TestProviderPersonalSign(t *testing.T) {
+	provider := testProvider()
+	message := "Sign in to the faucet"
+
+	tests := []struct {
+		name   string
+		params string
+		code   int
+	}{
+		{"message first", `["` + message + `", "` + testProviderAddress + `"]`, 0},
+		{"address first", `["` + testProviderAddress + `", "` + message + `"]`, 0},
+		{"other account", `["` + message + `", "0x8ba1f109551bD432803012645Ac136ddd64DBA72"]`, ProviderUnauthorized},
+		{"missing address", `["` + message + `"]`, ProviderInvalidParams},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := provider.Request(context.Background(), ProviderRequest{Method: "personal_sign", Params: json.RawMessage(test.params)})
+			if test.code != 0 {
+				assert.Equal(t, test.code, err.(*ProviderError).Code)
+				return
+			}
+			assert.NoError(t, err)
+
+			signature := hexutil.MustDecode(result.(string))
+			signature[64] -= 27
+			hash := crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)))
+			key, err := crypto.SigToPub(hash, signature)
+			assert.NoError(t, err)
+			assert.Equal(t, testProviderAddress, crypto.PubkeyToAddress(*key).Hex())
+		})
+	}
+}
+
+func TestProviderUnsupported(t *testing.T) {
+	_, err := testProvider().Request(context.Background(), ProviderRequest{Method: "eth_coinbase"})
+	assert.Equal(t, ProviderUnsupported, err.(*ProviderError).Code)
+}
+
+func TestProviderApproval(t *testing.T) {
+	var asked *ApprovalRequest
+	provider := NewProvider(&Wallet{Address: testProviderAddress, PrivateKey: testProviderKey}, func(req *ApprovalRequest) (bool, error) {
+		asked = req
+		return false, nil
+	})
+
+	_, err := provider.Request(context.Background(), ProviderRequest{
+		Method: "personal_sign",
+		Params: json.RawMessage(`["hello", "` + testProviderAddress + `"]`),
+		Origin: "https://app.example",
+	})
+	assert.Equal(t, ProviderUserRejected, err.(*ProviderError).Code)
+	assert.Equal(t, "personal_sign", asked.Method)
+	assert.Equal(t, "https://app.example", asked.PeerMeta.URL)
+	assert.Equal(t, json.RawMessage(`"hello"`), asked.Payload)
+}
+
+func TestProviderNilPolicy(t *testing.T) {
+	var asked []string
+	prompt := ApproverFunc(func(req *ApprovalRequest) bool {
+		asked = append(asked, req.Method)
+		return false
+	})
+	provider := NewProvider(&Wallet{Address: testProviderAddress, PrivateKey: testProviderKey}, PolicyApprover(nil, prompt, io.Discard))
+
+	_, err := provider.Request(context.Background(), ProviderRequest{
+		Method: MethodSendTransaction,
+		Params: json.RawMessage(`[{"to": "` + testProviderAddress + `", "value": "0x1", "gas": "0x5208"}]`),
+		Origin: "https://app.example",
+	})
+	assert.Equal(t, ProviderUserRejected, err.(*ProviderError).Code)
+	assert.Equal(t, []string{MethodSendTransaction}, asked)
+}
+
+func TestProviderInvalidTransaction(t *testing.T) {
+	tests := []struct {
+		name string
+		tx   string
+	}{
+		{"nonce", `{"to": "` + testProviderAddress + `", "nonce": "0xzz", "gas": "0x5208"}`},
+		{"gas", `{"to": "` + testProviderAddress + `", "gas": "0xzz"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := testProvider().Request(context.Background(), ProviderRequest{Method: MethodSendTransaction, Params: json.RawMessage(`[` + test.tx + `]`)})
+			assert.Equal(t, ProviderInvalidParams, err.(*ProviderError).Code)
+		})
+	}
+}
+
+func TestProviderSignTypedData(t *testing.T) {
+	typedData := `{
+		"types": {
+			"EIP712Domain": [{"name": "name", "type": "string"}, {"name": "chainId", "type": "uint256"}],
+			"Login": [{"name": "wallet", "type": "address"}, {"name": "nonce", "type": "uint256"}]
+		},
+		"primaryType": "Login",
+		"domain": {"name": "Example", "chainId": 10143},
+		"message": {"wallet": "` + testProviderAddress + `", "nonce": 7}
+	}`
+	quoted, _ := json.Marshal(typedData)
+
+	tests := []struct {
+		name   string
+		params string
+	}{
+		{"object", `["` + testProviderAddress + `", ` + typedData + `]`},
+		{"string", `["` + testProviderAddress + `", ` + string(quoted) + `]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := testProvider().Request(context.Background(), ProviderRequest{Method: "eth_signTypedData_v4", Params: json.RawMessage(test.params)})
+			assert.NoError(t, err)
+
+			var data apitypes.TypedData
+			assert.NoError(t, json.Unmarshal([]byte(typedData), &data))
+			hash, _, err := apitypes.TypedDataAndHash(data)
+			assert.NoError(t, err)
+
+			signature := hexutil.MustDecode(result.(string))
+			signature[64] -= 27
+			key, err := crypto.SigToPub(hash, signature)
+			assert.NoError(t, err)
+			assert.Equal(t, testProviderAddress, crypto.PubkeyToAddress(*key).Hex())
+		})
+	}
+}
+
+func TestProviderForward(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, "eth_blockNumber", req.Method)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"0x10"}`, req.ID)
+	}))
+	defer server.Close()
+
+	provider := testProvider()
+	provider.RPCURL = server.URL
+	defer provider.Close()
+
+	result, err := provider.Request(context.Background(), ProviderRequest{Method: "eth_blockNumber", Params: json.RawMessage(`[]`)})
+	assert.NoError(t, err)
+	assert.Equal(t, json.RawMessage(`"0x10"`), result)
+}
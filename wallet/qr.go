@@ -0,0 +1,109 @@
+package wallet
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// qrMargin is the quiet zone around rendered QR codes, in modules
+const qrMargin = 2
+
+// DecodeQRImage reads the content of the QR code in a PNG or JPEG image
+func DecodeQRImage(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %v", err)
+	}
+
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %v", err)
+	}
+
+	// screenshots rarely contain a pure barcode, so search the whole image
+	hints := map[gozxing.DecodeHintType]interface{}{
+		gozxing.DecodeHintType_TRY_HARDER: true,
+	}
+	result, err := qrcode.NewQRCodeReader().Decode(bitmap, hints)
+	if err != nil {
+		return "", fmt.Errorf("no QR code found: %v", err)
+	}
+
+	return result.GetText(), nil
+}
+
+// ResolveWalletConnectURI returns arg when it is a wc: URI, otherwise it
+// treats arg as a path to a QR code image holding the URI
+func ResolveWalletConnectURI(arg string) (string, error) {
+	if strings.HasPrefix(arg, "wc:") {
+		return arg, nil
+	}
+
+	if _, err := os.Stat(arg); err != nil {
+		return "", fmt.Errorf("%s is neither a WalletConnect URI nor an image: %v", arg, err)
+	}
+
+	uri, err := DecodeQRImage(arg)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(uri, "wc:") {
+		return "", fmt.Errorf("QR code does not contain a WalletConnect URI")
+	}
+	return uri, nil
+}
+
+// RenderQR writes content as a QR code made of block characters, two
+// modules per line. Light modules are drawn so the code scans on dark
+// terminal backgrounds.
+func RenderQR(w io.Writer, content string) error {
+	hints := map[gozxing.EncodeHintType]interface{}{
+		gozxing.EncodeHintType_MARGIN:           qrMargin,
+		gozxing.EncodeHintType_ERROR_CORRECTION: "L",
+	}
+	matrix, err := qrcode.NewQRCodeWriter().Encode(content, gozxing.BarcodeFormat_QR_CODE, 0, 0, hints)
+	if err != nil {
+		return fmt.Errorf("failed to encode QR code: %v", err)
+	}
+
+	width, height := matrix.GetWidth(), matrix.GetHeight()
+	light := func(x, y int) bool {
+		return y >= height || !matrix.Get(x, y)
+	}
+
+	var sb strings.Builder
+	for y := 0; y < height; y += 2 {
+		for x := 0; x < width; x++ {
+			top, bottom := light(x, y), light(x, y+1)
+			switch {
+			case top && bottom:
+				sb.WriteString("█")
+			case top:
+				sb.WriteString("▀")
+			case bottom:
+				sb.WriteString("▄")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
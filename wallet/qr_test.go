@@ -0,0 +1,93 @@
+package wallet
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/stretchr/testify/assert"
+)
+
+const testURI = "wc:8a5e5bdc-a0e4-4702-ba63-8f1a5655744f@1?bridge=wss://custom.bridge.org&key=41791102999c339c844880b23950704cc43aa840f3739e365323cda4dfa89e7a"
+
+// writeQRImage saves content as a QR code PNG, the way a dApp modal shows it
+func writeQRImage(t *testing.T, content string) string {
+	matrix, err := qrcode.NewQRCodeWriter().EncodeWithoutHint(content, gozxing.BarcodeFormat_QR_CODE, 300, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// surround the code with unrelated page content
+	img := image.NewGray(image.Rect(0, 0, 400, 500))
+	for y := 0; y < 500; y++ {
+		for x := 0; x < 400; x++ {
+			img.SetGray(x, y, color.Gray{Y: 230})
+		}
+	}
+	for y := 0; y < matrix.GetHeight(); y++ {
+		for x := 0; x < matrix.GetWidth(); x++ {
+			if matrix.Get(x, y) {
+				img.SetGray(50+x, 150+y, color.Gray{Y: 0})
+			} else {
+				img.SetGray(50+x, 150+y, color.Gray{Y: 255})
+			}
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "modal.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDecodeQRImage(t *testing.T) {
+	path := writeQRImage(t, testURI)
+
+	uri, err := DecodeQRImage(path)
+	assert.NoError(t, err)
+	assert.Equal(t, testURI, uri)
+}
+
+func TestResolveWalletConnectURI(t *testing.T) {
+	uri, err := ResolveWalletConnectURI(testURI)
+	assert.NoError(t, err)
+	assert.Equal(t, testURI, uri)
+
+	uri, err = ResolveWalletConnectURI(writeQRImage(t, testURI))
+	assert.NoError(t, err)
+	assert.Equal(t, testURI, uri)
+
+	_, err = ResolveWalletConnectURI(writeQRImage(t, "https://example.com"))
+	assert.Error(t, err)
+
+	_, err = ResolveWalletConnectURI(filepath.Join(t.TempDir(), "missing.png"))
+	assert.Error(t, err)
+}
+
+func TestRenderQR(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, RenderQR(&buf, testURI))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.NotEmpty(t, lines)
+
+	// the quiet zone is drawn as light modules
+	assert.Equal(t, strings.Repeat("█", len([]rune(lines[0]))), lines[0])
+	for _, line := range lines {
+		assert.Equal(t, len([]rune(lines[0])), len([]rune(line)))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"sort"
@@ -14,6 +15,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
 
 // TransactionRequest represents a transaction request from WalletConnect
@@ -85,7 +87,10 @@ func (w *Wallet) SendTransactionFromRequest(ctx context.Context, req Transaction
 
 	var nonce uint64
 	if req.Nonce != "" {
-		nonce = hexutil.MustDecodeUint64(req.Nonce)
+		nonce, err = hexutil.DecodeUint64(req.Nonce)
+		if err != nil {
+			return "", fmt.Errorf("invalid nonce %q: %v", req.Nonce, err)
+		}
 	} else {
 		nonce, err = client.PendingNonceAt(ctx, fromAddress)
 		if err != nil {
@@ -95,7 +100,10 @@ func (w *Wallet) SendTransactionFromRequest(ctx context.Context, req Transaction
 
 	gasLimit := uint64(21000) // default
 	if req.GasLimit != "" {
-		gasLimit = hexutil.MustDecodeUint64(req.GasLimit)
+		gasLimit, err = hexutil.DecodeUint64(req.GasLimit)
+		if err != nil {
+			return "", fmt.Errorf("invalid gas %q: %v", req.GasLimit, err)
+		}
 	}
 
 	gasPrice, err := client.SuggestGasPrice(ctx)
@@ -301,6 +309,33 @@ func (w *Wallet) TypedDataSign(data TypedData) (string, error) {
 	return hexutil.Encode(signature), nil
 }
 
+// SignTypedDataV4 signs EIP-712 typed data given as JSON, as
+// eth_signTypedData_v4 does
+func (w *Wallet) SignTypedDataV4(data []byte) (string, error) {
+	var typedData apitypes.TypedData
+	if err := json.Unmarshal(data, &typedData); err != nil {
+		return "", fmt.Errorf("invalid typed data: %v", err)
+	}
+
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		return "", fmt.Errorf("invalid typed data: %v", err)
+	}
+
+	privateKey, err := crypto.HexToECDSA(w.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid private key: %v", err)
+	}
+
+	signature, err := crypto.Sign(hash, privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign typed data: %v", err)
+	}
+	signature[64] += 27
+
+	return hexutil.Encode(signature), nil
+}
+
 // Helper functions for encoding values
 func encodeDomainValues(domain TypedDataDomain) ([]byte, error) {
 	// Implementation depends on your specific needs
@@ -0,0 +1,344 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrTransportClosed is returned when reading from or writing to a closed transport
+var ErrTransportClosed = errors.New("transport closed")
+
+// TransportOptions configures the bridge connection
+type TransportOptions struct {
+	// WriteTimeout bounds every write to the bridge
+	WriteTimeout time.Duration
+	// PongTimeout is how long the connection may stay silent before it is considered dead
+	PongTimeout time.Duration
+	// PingInterval is how often pings are sent, must be less than PongTimeout
+	PingInterval time.Duration
+	// MinBackoff and MaxBackoff bound the delay between reconnect attempts
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxReconnects limits consecutive failed reconnects, zero means unlimited
+	MaxReconnects int
+}
+
+// DefaultTransportOptions returns the options used by ConnectToURI
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		WriteTimeout: 10 * time.Second,
+		PongTimeout:  60 * time.Second,
+		PingInterval: 25 * time.Second,
+		MinBackoff:   500 * time.Millisecond,
+		MaxBackoff:   30 * time.Second,
+	}
+}
+
+// Transport is a websocket connection to a WalletConnect bridge which
+// keeps itself alive with heartbeats and reconnects when it drops,
+// resubscribing to every topic subscribed so far
+type Transport struct {
+	url  string
+	opts TransportOptions
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	topics  []string
+	err     error
+
+	// messages are queued until read, the connection is read and kept
+	// alive while the consumer is busy, e.g. waiting for an approval
+	messages [][]byte
+	queued   chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewTransport creates a transport for the bridge at url,
+// zero options fall back to DefaultTransportOptions
+func NewTransport(url string, opts TransportOptions) *Transport {
+	defaults := DefaultTransportOptions()
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = defaults.WriteTimeout
+	}
+	if opts.PongTimeout <= 0 {
+		opts.PongTimeout = defaults.PongTimeout
+	}
+	if opts.PingInterval <= 0 || opts.PingInterval >= opts.PongTimeout {
+		opts.PingInterval = opts.PongTimeout * 9 / 10
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = defaults.MinBackoff
+	}
+	if opts.MaxBackoff < opts.MinBackoff {
+		opts.MaxBackoff = opts.MinBackoff
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Transport{
+		url:    url,
+		opts:   opts,
+		queued: make(chan struct{}, 1),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+}
+
+// Connect dials the bridge and starts reading messages in the background
+func (t *Transport) Connect(ctx context.Context) error {
+	conn, err := t.dial(ctx)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.conn = conn
+	t.mu.Unlock()
+
+	go t.run(conn)
+	go t.ping()
+
+	return nil
+}
+
+// Subscribe subscribes to topic, the subscription survives reconnects
+func (t *Transport) Subscribe(topic string) error {
+	t.mu.Lock()
+	subscribed := false
+	for _, existing := range t.topics {
+		if existing == topic {
+			subscribed = true
+			break
+		}
+	}
+	if !subscribed {
+		t.topics = append(t.topics, topic)
+	}
+	t.mu.Unlock()
+
+	return t.WriteJSON(subscribeMessage(topic))
+}
+
+// WriteJSON writes v to the bridge
+func (t *Transport) WriteJSON(v interface{}) error {
+	t.mu.Lock()
+	conn := t.conn
+	t.mu.Unlock()
+
+	if conn == nil || t.ctx.Err() != nil {
+		return ErrTransportClosed
+	}
+
+	return t.write(conn, v)
+}
+
+// ReadJSON waits for the next message and decodes it into v
+func (t *Transport) ReadJSON(ctx context.Context, v interface{}) error {
+	for {
+		t.mu.Lock()
+		if len(t.messages) > 0 {
+			data := t.messages[0]
+			t.messages = t.messages[1:]
+			if len(t.messages) > 0 {
+				t.notify()
+			}
+			t.mu.Unlock()
+			return json.Unmarshal(data, v)
+		}
+		t.mu.Unlock()
+
+		select {
+		case <-t.queued:
+		case <-t.done:
+			return t.Err()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// notify wakes up a reader waiting for messages
+func (t *Transport) notify() {
+	select {
+	case t.queued <- struct{}{}:
+	default:
+	}
+}
+
+// Err returns the reason the transport stopped, if it has
+func (t *Transport) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// Close shuts the transport down and closes the connection
+func (t *Transport) Close() error {
+	t.cancel()
+
+	t.mu.Lock()
+	conn := t.conn
+	if t.err == nil {
+		t.err = ErrTransportClosed
+	}
+	t.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+
+	t.writeMu.Lock()
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(t.opts.WriteTimeout))
+	t.writeMu.Unlock()
+
+	err := conn.Close()
+	<-t.done
+	return err
+}
+
+func (t *Transport) dial(ctx context.Context) (*websocket.Conn, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, t.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(t.opts.PongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(t.opts.PongTimeout))
+	})
+
+	return conn, nil
+}
+
+func (t *Transport) write(conn *websocket.Conn, v interface{}) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	conn.SetWriteDeadline(time.Now().Add(t.opts.WriteTimeout))
+	return conn.WriteJSON(v)
+}
+
+// run reads messages until the transport is closed, reconnecting on failure
+func (t *Transport) run(conn *websocket.Conn) {
+	defer close(t.done)
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err == nil {
+			conn.SetReadDeadline(time.Now().Add(t.opts.PongTimeout))
+			t.mu.Lock()
+			t.messages = append(t.messages, data)
+			t.notify()
+			t.mu.Unlock()
+			continue
+		}
+
+		if t.ctx.Err() != nil {
+			return
+		}
+		conn.Close()
+
+		conn, err = t.reconnect()
+		if err != nil {
+			t.mu.Lock()
+			if t.err == nil {
+				t.err = fmt.Errorf("connection lost: %v", err)
+			}
+			t.mu.Unlock()
+			return
+		}
+	}
+}
+
+// reconnect dials the bridge with exponential backoff and resubscribes
+func (t *Transport) reconnect() (*websocket.Conn, error) {
+	backoff := t.opts.MinBackoff
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-time.After(backoff):
+		case <-t.ctx.Done():
+			return nil, t.ctx.Err()
+		}
+
+		conn, err := t.dial(t.ctx)
+		if err == nil {
+			err = t.resubscribe(conn)
+			if err == nil {
+				return conn, nil
+			}
+			conn.Close()
+		}
+
+		if t.opts.MaxReconnects > 0 && attempt >= t.opts.MaxReconnects {
+			return nil, err
+		}
+
+		backoff *= 2
+		if backoff > t.opts.MaxBackoff {
+			backoff = t.opts.MaxBackoff
+		}
+	}
+}
+
+func (t *Transport) resubscribe(conn *websocket.Conn) error {
+	t.mu.Lock()
+	topics := append([]string(nil), t.topics...)
+	t.mu.Unlock()
+
+	for _, topic := range topics {
+		if err := t.write(conn, subscribeMessage(topic)); err != nil {
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ctx.Err() != nil {
+		return t.ctx.Err()
+	}
+	t.conn = conn
+	return nil
+}
+
+// ping keeps the connection alive until the transport is closed
+func (t *Transport) ping() {
+	ticker := time.NewTicker(t.opts.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.mu.Lock()
+			conn := t.conn
+			t.mu.Unlock()
+
+			// a failed ping surfaces as a read error which triggers a reconnect
+			t.writeMu.Lock()
+			conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(t.opts.WriteTimeout))
+			t.writeMu.Unlock()
+		case <-t.ctx.Done():
+			return
+		}
+	}
+}
+
+func subscribeMessage(topic string) interface{} {
+	return struct {
+		Topic string `json:"topic"`
+		Type  string `json:"type"`
+	}{
+		Topic: topic,
+		Type:  "sub",
+	}
+}
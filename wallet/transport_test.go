@@ -0,0 +1,171 @@
+package wallet
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransport_ReconnectResubscribes(t *testing.T) {
+	var connections int32
+
+	// Create test server dropping the first connection after subscription
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		n := atomic.AddInt32(&connections, 1)
+
+		var sub map[string]interface{}
+		if err := conn.ReadJSON(&sub); err != nil {
+			return
+		}
+		if n == 1 {
+			return
+		}
+
+		conn.WriteJSON(map[string]interface{}{
+			"topic":   sub["topic"],
+			"type":    "pub",
+			"payload": "after reconnect",
+		})
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	transport := NewTransport(wsURL, TransportOptions{MinBackoff: 10 * time.Millisecond})
+	defer transport.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, transport.Connect(ctx))
+	assert.NoError(t, transport.Subscribe("test-topic"))
+
+	var msg map[string]interface{}
+	assert.NoError(t, transport.ReadJSON(ctx, &msg))
+	assert.Equal(t, "test-topic", msg["topic"])
+	assert.Equal(t, "after reconnect", msg["payload"])
+	assert.Equal(t, int32(2), atomic.LoadInt32(&connections))
+}
+
+func TestTransport_SlowConsumer(t *testing.T) {
+	var connections int32
+
+	// Create test server publishing a message, then answering pings
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		atomic.AddInt32(&connections, 1)
+		conn.WriteJSON(map[string]interface{}{"type": "pub", "payload": "published"})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	transport := NewTransport(wsURL, TransportOptions{
+		PongTimeout:  100 * time.Millisecond,
+		PingInterval: 20 * time.Millisecond,
+		MinBackoff:   10 * time.Millisecond,
+	})
+	defer transport.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, transport.Connect(ctx))
+
+	// the consumer is busy for longer than the pong timeout
+	time.Sleep(300 * time.Millisecond)
+
+	var msg map[string]interface{}
+	assert.NoError(t, transport.ReadJSON(ctx, &msg))
+	assert.Equal(t, "published", msg["payload"])
+
+	// the connection was kept alive, it is not reconnected
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&connections))
+}
+
+func TestTransport_MaxReconnects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}))
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	transport := NewTransport(wsURL, TransportOptions{
+		MinBackoff:    10 * time.Millisecond,
+		MaxReconnects: 2,
+	})
+	defer transport.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, transport.Connect(ctx))
+	server.Close()
+
+	var msg map[string]interface{}
+	err := transport.ReadJSON(ctx, &msg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "connection lost")
+}
+
+func TestWalletClient_HandleRequestsCancel(t *testing.T) {
+	// Create test server which never sends anything
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	client := &WalletClient{bridge: wsURL, connected: true}
+	client.transport = NewTransport(wsURL, TransportOptions{})
+	err := client.transport.Connect(context.Background())
+	if err != nil {
+		t.Fatalf("failed to connect to test server: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- client.HandleRequests(ctx, RequestHandlers{})
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("HandleRequests did not return after cancellation")
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"log"
 	"math/big"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -62,18 +64,23 @@ func LoadWallet(path string) *Wallet {
 
 // Check balance
 func (w *Wallet) Balance() (*big.Float, error) {
-	client, err := ethclient.Dial(RPC_URL)
+	balance, err := BalanceAt(context.Background(), w.Address)
 	if err != nil {
 		return nil, err
 	}
 
-	account := common.HexToAddress(w.Address)
-	balance, err := client.BalanceAt(context.Background(), account, nil)
+	return new(big.Float).Quo(new(big.Float).SetInt(balance), big.NewFloat(1e18)), nil
+}
+
+// BalanceAt returns the balance of address in wei
+func BalanceAt(ctx context.Context, address string) (*big.Int, error) {
+	client, err := ethclient.Dial(RPC_URL)
 	if err != nil {
 		return nil, err
 	}
+	defer client.Close()
 
-	return new(big.Float).Quo(new(big.Float).SetInt(balance), big.NewFloat(1e18)), nil
+	return client.BalanceAt(ctx, common.HexToAddress(address), nil)
 }
 
 // Send send tokens (MON) to an address
@@ -122,6 +129,88 @@ func (w *Wallet) Send(toAddress string, amount float64) (string, error) {
 	return signedTx.Hash().Hex(), nil
 }
 
+// ParseMON converts a decimal MON amount into wei
+func ParseMON(amount string) (*big.Int, error) {
+	return ParseUnits(amount, 18)
+}
+
+// LoadWallets reads the wallets of many accounts. path is either a file
+// listing one private key per line, where blank lines and lines starting
+// with # are ignored, or a directory of wallet files as written by Save.
+// An address in place of a private key gives a wallet without a key,
+// which can only receive.
+func LoadWallets(path string) ([]*Wallet, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// key is a private key and where it was read from
+	type key struct {
+		source string
+		hex    string
+	}
+
+	var keys []key
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			file := filepath.Join(path, entry.Name())
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key{file, strings.TrimSpace(string(data))})
+		}
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for i, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			keys = append(keys, key{fmt.Sprintf("%s:%d", path, i+1), line})
+		}
+	}
+
+	var wallets []*Wallet
+	seen := make(map[string]*Wallet)
+	for _, k := range keys {
+		var w *Wallet
+		if common.IsHexAddress(k.hex) {
+			w = &Wallet{Address: common.HexToAddress(k.hex).Hex()}
+		} else {
+			privateKeyHex := strings.TrimPrefix(k.hex, "0x")
+			privateKey, err := crypto.HexToECDSA(privateKeyHex)
+			if err != nil {
+				return nil, fmt.Errorf("%s: not an address or private key", k.source)
+			}
+			w = &Wallet{Address: crypto.PubkeyToAddress(privateKey.PublicKey).Hex(), PrivateKey: privateKeyHex}
+		}
+
+		// an address listed along with its key keeps the key
+		if first, ok := seen[w.Address]; ok {
+			if first.PrivateKey == "" {
+				first.PrivateKey = w.PrivateKey
+			}
+			continue
+		}
+		seen[w.Address] = w
+		wallets = append(wallets, w)
+	}
+
+	return wallets, nil
+}
+
 // Save a wallet to a file
 // currently only naive implementation
 func (w *Wallet) Save(path string) error {
@@ -0,0 +1,76 @@
+package wallet
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadWallets(t *testing.T) {
+	other := GenerateWallet()
+
+	list := filepath.Join(t.TempDir(), "keys.txt")
+	assert.NoError(t, os.WriteFile(list, []byte("# accounts\n"+testProviderKey+"\n\n0x"+other.PrivateKey+"\n"+testProviderKey+"\n"), 0644))
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a"), []byte(testProviderKey), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "b"), []byte(other.PrivateKey+"\n"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden"), []byte("junk"), 0600))
+
+	for _, path := range []string{list, dir} {
+		wallets, err := LoadWallets(path)
+		assert.NoError(t, err)
+		assert.Equal(t, []*Wallet{
+			{Address: testProviderAddress, PrivateKey: testProviderKey},
+			{Address: other.Address, PrivateKey: other.PrivateKey},
+		}, wallets)
+	}
+
+	// an address gives a wallet without a key
+	addresses := filepath.Join(t.TempDir(), "addresses.txt")
+	assert.NoError(t, os.WriteFile(addresses, []byte(strings.ToLower(other.Address)+"\n"+testProviderKey+"\n"), 0644))
+	wallets, err := LoadWallets(addresses)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Wallet{
+		{Address: other.Address},
+		{Address: testProviderAddress, PrivateKey: testProviderKey},
+	}, wallets)
+
+	// the key of an address listed before it is kept
+	assert.NoError(t, os.WriteFile(addresses, []byte(testProviderAddress+"\n"+testProviderKey+"\n"), 0644))
+	wallets, err = LoadWallets(addresses)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Wallet{{Address: testProviderAddress, PrivateKey: testProviderKey}}, wallets)
+
+	invalid := filepath.Join(t.TempDir(), "keys.txt")
+	assert.NoError(t, os.WriteFile(invalid, []byte(testProviderKey+"\nnot-a-key\n"), 0644))
+	_, err = LoadWallets(invalid)
+	assert.EqualError(t, err, invalid+":2: not an address or private key")
+}
+
+func TestTransferValidation(t *testing.T) {
+	w := &Wallet{Address: testProviderAddress, PrivateKey: testProviderKey}
+	ctx := context.Background()
+	token := "0x3333333333333333333333333333333333333333"
+
+	tests := []struct {
+		name     string
+		transfer func() (string, error)
+		err      string
+	}{
+		{"wrap nothing", func() (string, error) { return w.Wrap(ctx, token, new(big.Int)) }, "amount must be positive"},
+		{"unwrap without contract", func() (string, error) { return w.Unwrap(ctx, "", big.NewInt(1)) }, `invalid address ""`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.transfer()
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package wallettest
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/galihrivanto/omonOmon/wallet"
+)
+
+// Tx is a transaction sent through a Backend
+type Tx struct {
+	To     string
+	Value  *big.Int
+	Method string
+	Args   []interface{}
+}
+
+// Backend is a fake wallet.Backend, it answers the calls of contracts by
+// method name and records the transactions sent
+type Backend struct {
+	ABIs []abi.ABI
+	// Results are the outputs of the methods called
+	Results map[string][]interface{}
+	Sent    []Tx
+}
+
+// NewBackend creates a Backend answering with results, the methods
+// called are looked up in abis
+func NewBackend(results map[string][]interface{}, abis ...abi.ABI) *Backend {
+	return &Backend{ABIs: abis, Results: results}
+}
+
+func (b *Backend) method(data []byte) (*abi.Method, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("no method in %x", data)
+	}
+	for _, contractABI := range b.ABIs {
+		if method, err := contractABI.MethodById(data[:4]); err == nil {
+			return method, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown method %x", data[:4])
+}
+
+func (b *Backend) Call(ctx context.Context, to string, data []byte) ([]byte, error) {
+	method, err := b.method(data)
+	if err != nil {
+		return nil, err
+	}
+	result, ok := b.Results[method.Name]
+	if !ok {
+		return nil, fmt.Errorf("unexpected call of %s", method.Name)
+	}
+	return method.Outputs.Pack(result...)
+}
+
+// Transact records the transaction, its hash is its position in Sent
+func (b *Backend) Transact(ctx context.Context, w *wallet.Wallet, to string, value *big.Int, data []byte) (string, error) {
+	method, err := b.method(data)
+	if err != nil {
+		return "", err
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return "", err
+	}
+	b.Sent = append(b.Sent, Tx{To: to, Value: value, Method: method.Name, Args: args})
+	return common.BigToHash(big.NewInt(int64(len(b.Sent)))).Hex(), nil
+}
@@ -0,0 +1,66 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// wmonABI is the part of the WETH9 style wrapped MON contract used here
+const wmonABI = `[
+	{"name": "deposit", "type": "function", "stateMutability": "payable", "inputs": [], "outputs": []},
+	{"name": "withdraw", "type": "function", "stateMutability": "nonpayable", "inputs": [{"name": "wad", "type": "uint256"}], "outputs": []}
+]`
+
+var wmon = MustParseABI(wmonABI)
+
+// Wrap deposits amount of MON, in wei, into the WMON contract at token
+// and waits for the transaction to be mined
+func (w *Wallet) Wrap(ctx context.Context, token string, amount *big.Int) (string, error) {
+	if err := checkTransfer(token, amount); err != nil {
+		return "", err
+	}
+
+	data, err := wmon.Pack("deposit")
+	if err != nil {
+		return "", err
+	}
+	return w.Transact(ctx, token, amount, data)
+}
+
+// Unwrap withdraws amount of MON, in wei, from the WMON contract at
+// token and waits for the transaction to be mined
+func (w *Wallet) Unwrap(ctx context.Context, token string, amount *big.Int) (string, error) {
+	if err := checkTransfer(token, amount); err != nil {
+		return "", err
+	}
+
+	balance, err := TokenBalance(ctx, token, w.Address)
+	if err != nil {
+		return "", err
+	}
+	if balance.Cmp(amount) < 0 {
+		return "", fmt.Errorf("only %s WMON held", FormatUnits(balance, 18))
+	}
+
+	data, err := wmon.Pack("withdraw", amount)
+	if err != nil {
+		return "", err
+	}
+	return w.Transact(ctx, token, nil, data)
+}
+
+// checkTransfer rejects invalid recipients and amounts before anything
+// is sent
+func checkTransfer(to string, amount *big.Int) error {
+	if !common.IsHexAddress(to) {
+		return fmt.Errorf("invalid address %q", to)
+	}
+	if amount == nil || amount.Sign() <= 0 {
+		return errors.New("amount must be positive")
+	}
+	return nil
+}